@@ -0,0 +1,17 @@
+CREATE TABLE refresh_tokens (
+    id              UUID PRIMARY KEY DEFAULT uuidv7(),
+    developer_id    UUID NOT NULL REFERENCES developers(id) ON DELETE CASCADE,
+    family_id       UUID NOT NULL,
+    token_hash      VARCHAR(64) NOT NULL UNIQUE,
+
+    -- Lifecycle
+    expires_at      TIMESTAMP WITH TIME ZONE NOT NULL,
+    consumed_at     TIMESTAMP WITH TIME ZONE,
+    revoked_at      TIMESTAMP WITH TIME ZONE,
+
+    -- Timestamps
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+CREATE INDEX idx_refresh_tokens_developer_id ON refresh_tokens (developer_id);
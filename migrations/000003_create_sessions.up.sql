@@ -0,0 +1,37 @@
+CREATE TABLE sessions (
+    id              UUID PRIMARY KEY DEFAULT uuidv7(),
+    developer_id    UUID NOT NULL REFERENCES developers(id) ON DELETE CASCADE,
+
+    -- Client info
+    device_name     VARCHAR(255),
+    ip_address      VARCHAR(45),
+    user_agent      TEXT,
+
+    -- Lifecycle
+    expires_at      TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at      TIMESTAMP WITH TIME ZONE,
+
+    -- Timestamps
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_used_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_sessions_developer_id ON sessions (developer_id);
+CREATE INDEX idx_sessions_revoked_at ON sessions (revoked_at) WHERE revoked_at IS NOT NULL;
+
+-- Existing refresh token families become sessions
+INSERT INTO sessions (id, developer_id, expires_at, revoked_at, created_at, last_used_at)
+SELECT family_id,
+       developer_id,
+       MAX(expires_at),
+       CASE WHEN BOOL_AND(revoked_at IS NOT NULL) THEN MAX(revoked_at) END,
+       MIN(created_at),
+       MAX(created_at)
+FROM refresh_tokens
+GROUP BY family_id, developer_id;
+
+ALTER TABLE refresh_tokens RENAME COLUMN family_id TO session_id;
+ALTER INDEX idx_refresh_tokens_family_id RENAME TO idx_refresh_tokens_session_id;
+ALTER TABLE refresh_tokens
+    ADD CONSTRAINT fk_refresh_tokens_session
+    FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE;
@@ -0,0 +1,16 @@
+CREATE TABLE one_time_tokens (
+    id              UUID PRIMARY KEY DEFAULT uuidv7(),
+    developer_id    UUID NOT NULL REFERENCES developers(id) ON DELETE CASCADE,
+    purpose         VARCHAR(30) NOT NULL
+                    CHECK (purpose IN ('email_verification')),
+    token_hash      VARCHAR(64) NOT NULL UNIQUE,
+
+    -- Lifecycle
+    expires_at      TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at         TIMESTAMP WITH TIME ZONE,
+
+    -- Timestamps
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_one_time_tokens_developer_purpose ON one_time_tokens (developer_id, purpose, created_at DESC);
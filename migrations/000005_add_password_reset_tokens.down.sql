@@ -0,0 +1,4 @@
+DELETE FROM one_time_tokens WHERE purpose = 'password_reset';
+ALTER TABLE one_time_tokens DROP CONSTRAINT one_time_tokens_purpose_check;
+ALTER TABLE one_time_tokens ADD CONSTRAINT one_time_tokens_purpose_check
+    CHECK (purpose IN ('email_verification'));
\ No newline at end of file
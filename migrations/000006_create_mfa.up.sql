@@ -0,0 +1,26 @@
+CREATE TABLE developer_mfa (
+    developer_id    UUID PRIMARY KEY REFERENCES developers(id) ON DELETE CASCADE,
+
+    -- AES-GCM encrypted base32 TOTP secret
+    totp_secret     TEXT NOT NULL,
+
+    -- NULL until the developer confirms enrollment with a valid code
+    enabled_at      TIMESTAMP WITH TIME ZONE,
+
+    -- Last accepted 30s time step, to reject replayed codes
+    last_used_step  BIGINT,
+
+    -- Timestamps
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE mfa_recovery_codes (
+    id              UUID PRIMARY KEY DEFAULT uuidv7(),
+    developer_id    UUID NOT NULL REFERENCES developers(id) ON DELETE CASCADE,
+    code_hash       VARCHAR(64) NOT NULL,
+    used_at         TIMESTAMP WITH TIME ZONE,
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_mfa_recovery_codes_developer_id ON mfa_recovery_codes (developer_id);
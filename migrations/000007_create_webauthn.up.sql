@@ -0,0 +1,26 @@
+CREATE TABLE webauthn_credentials (
+    id              UUID PRIMARY KEY DEFAULT uuidv7(),
+    developer_id    UUID NOT NULL REFERENCES developers(id) ON DELETE CASCADE,
+    name            VARCHAR(255) NOT NULL,
+    credential_id   BYTEA NOT NULL UNIQUE,
+
+    -- Public key, flags, transports and sign count as verified by the library
+    data            JSONB NOT NULL,
+
+    -- Timestamps
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_used_at    TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_webauthn_credentials_developer_id ON webauthn_credentials (developer_id);
+
+-- Short-lived server side state of an in-flight registration or login ceremony
+CREATE TABLE webauthn_challenges (
+    id              UUID PRIMARY KEY DEFAULT uuidv7(),
+    developer_id    UUID REFERENCES developers(id) ON DELETE CASCADE,
+    ceremony        VARCHAR(20) NOT NULL
+                    CHECK (ceremony IN ('registration', 'login')),
+    session_data    JSONB NOT NULL,
+    expires_at      TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
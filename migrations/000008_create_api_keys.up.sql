@@ -0,0 +1,23 @@
+CREATE TABLE api_keys (
+    id              UUID PRIMARY KEY DEFAULT uuidv7(),
+    developer_id    UUID NOT NULL REFERENCES developers(id) ON DELETE CASCADE,
+    name            VARCHAR(255) NOT NULL,
+
+    -- Visible start of the key so it can be recognised in lists; only the hash of the full key is kept
+    prefix          VARCHAR(16) NOT NULL,
+    key_hash        CHAR(64) NOT NULL UNIQUE,
+    scopes          TEXT[] NOT NULL DEFAULT '{}',
+
+    -- Lifecycle
+    expires_at      TIMESTAMP WITH TIME ZONE,
+    revoked_at      TIMESTAMP WITH TIME ZONE,
+
+    -- Usage
+    last_used_at    TIMESTAMP WITH TIME ZONE,
+    last_used_ip    VARCHAR(45),
+
+    -- Timestamps
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_api_keys_developer_id ON api_keys (developer_id);
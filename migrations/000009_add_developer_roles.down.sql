@@ -0,0 +1 @@
+ALTER TABLE developers DROP COLUMN IF EXISTS role;
\ No newline at end of file
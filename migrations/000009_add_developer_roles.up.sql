@@ -0,0 +1,5 @@
+ALTER TABLE developers
+    ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'developer'
+    CHECK (role IN ('developer', 'support', 'admin', 'superadmin'));
+
+CREATE INDEX idx_developers_role ON developers (role) WHERE role != 'developer';
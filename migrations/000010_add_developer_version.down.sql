@@ -0,0 +1 @@
+ALTER TABLE developers DROP COLUMN IF EXISTS version;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- Bumped on every change to the developer's profile; exposed as the ETag
+ALTER TABLE developers ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,2 @@
+-- Backs keyset pagination of the developer listing
+CREATE INDEX idx_developers_created_at_id ON developers (created_at DESC, id DESC);
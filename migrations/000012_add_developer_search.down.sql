@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_developers_last_login_at_id;
+DROP INDEX IF EXISTS idx_developers_updated_at_id;
+DROP INDEX IF EXISTS idx_developers_company_name_trgm;
+DROP INDEX IF EXISTS idx_developers_full_name_trgm;
+DROP INDEX IF EXISTS idx_developers_email_trgm;
\ No newline at end of file
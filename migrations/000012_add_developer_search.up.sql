@@ -0,0 +1,10 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Substring search over email, name and company
+CREATE INDEX idx_developers_email_trgm ON developers USING GIN (email gin_trgm_ops);
+CREATE INDEX idx_developers_full_name_trgm ON developers USING GIN (full_name gin_trgm_ops);
+CREATE INDEX idx_developers_company_name_trgm ON developers USING GIN (company_name gin_trgm_ops);
+
+-- Alternative listing orders
+CREATE INDEX idx_developers_updated_at_id ON developers (updated_at DESC, id DESC);
+CREATE INDEX idx_developers_last_login_at_id ON developers ((COALESCE(last_login_at, 'epoch'::timestamptz)) DESC, id DESC);
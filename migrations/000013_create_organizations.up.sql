@@ -0,0 +1,49 @@
+CREATE TABLE organizations (
+    id              UUID PRIMARY KEY DEFAULT uuidv7(),
+    name            VARCHAR(255) NOT NULL,
+    slug            VARCHAR(63) NOT NULL UNIQUE,
+
+    -- Timestamps
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE organization_members (
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    developer_id    UUID NOT NULL REFERENCES developers(id) ON DELETE CASCADE,
+    role            VARCHAR(20) NOT NULL
+                    CHECK (role IN ('owner', 'admin', 'member', 'billing')),
+
+    -- Timestamps
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (organization_id, developer_id)
+);
+
+CREATE INDEX idx_organization_members_developer_id ON organization_members (developer_id);
+-- Exactly one owner per organization; transfers swap roles in one transaction
+CREATE UNIQUE INDEX idx_organization_members_owner ON organization_members (organization_id) WHERE role = 'owner';
+
+CREATE TABLE organization_invitations (
+    id              UUID PRIMARY KEY DEFAULT uuidv7(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    email           VARCHAR(255) NOT NULL,
+    role            VARCHAR(20) NOT NULL
+                    CHECK (role IN ('admin', 'member', 'billing')),
+    token_hash      CHAR(64) NOT NULL UNIQUE,
+    invited_by      UUID REFERENCES developers(id) ON DELETE SET NULL,
+
+    -- Lifecycle
+    expires_at      TIMESTAMP WITH TIME ZONE NOT NULL,
+    accepted_at     TIMESTAMP WITH TIME ZONE,
+    revoked_at      TIMESTAMP WITH TIME ZONE,
+
+    -- Timestamps
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_organization_invitations_organization_id ON organization_invitations (organization_id, created_at DESC);
+
+-- Organization a session currently acts within; carried into its access tokens
+ALTER TABLE sessions
+    ADD COLUMN organization_id UUID REFERENCES organizations(id) ON DELETE SET NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS project_environments;
+DROP TABLE IF EXISTS projects;
\ No newline at end of file
@@ -0,0 +1,36 @@
+CREATE TABLE projects (
+    id                      UUID PRIMARY KEY DEFAULT uuidv7(),
+    name                    VARCHAR(255) NOT NULL,
+    slug                    VARCHAR(63) NOT NULL,
+
+    -- Ownership; exactly one of the two is set
+    owner_developer_id      UUID REFERENCES developers(id) ON DELETE CASCADE,
+    owner_organization_id   UUID REFERENCES organizations(id) ON DELETE CASCADE,
+
+    -- Lifecycle
+    archived_at             TIMESTAMP WITH TIME ZONE,
+
+    -- Timestamps
+    created_at              TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at              TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT projects_single_owner CHECK (
+        (owner_developer_id IS NULL) <> (owner_organization_id IS NULL)
+    )
+);
+
+-- Slugs are unique per owner
+CREATE UNIQUE INDEX idx_projects_developer_slug ON projects (owner_developer_id, slug) WHERE owner_developer_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_projects_organization_slug ON projects (owner_organization_id, slug) WHERE owner_organization_id IS NOT NULL;
+
+CREATE TABLE project_environments (
+    id              UUID PRIMARY KEY DEFAULT uuidv7(),
+    project_id      UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name            VARCHAR(20) NOT NULL
+                    CHECK (name IN ('development', 'staging', 'production')),
+
+    -- Timestamps
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    UNIQUE (project_id, name)
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS service_client_secrets;
+DROP TABLE IF EXISTS service_clients;
\ No newline at end of file
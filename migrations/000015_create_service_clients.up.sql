@@ -0,0 +1,37 @@
+CREATE TABLE service_clients (
+    id              UUID PRIMARY KEY DEFAULT uuidv7(),
+    project_id      UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name            VARCHAR(255) NOT NULL,
+
+    -- Public identifier presented as client_id at the token endpoint
+    client_id       VARCHAR(64) NOT NULL UNIQUE,
+    scopes          TEXT[] NOT NULL DEFAULT '{}',
+    created_by      UUID REFERENCES developers(id) ON DELETE SET NULL,
+
+    -- Lifecycle
+    revoked_at      TIMESTAMP WITH TIME ZONE,
+
+    -- Usage
+    last_used_at    TIMESTAMP WITH TIME ZONE,
+
+    -- Timestamps
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_service_clients_project_id ON service_clients (project_id);
+
+-- A client holds several secrets while a rotation overlaps; only hashes are kept
+CREATE TABLE service_client_secrets (
+    id                  UUID PRIMARY KEY DEFAULT uuidv7(),
+    service_client_id   UUID NOT NULL REFERENCES service_clients(id) ON DELETE CASCADE,
+    prefix              VARCHAR(16) NOT NULL,
+    secret_hash         CHAR(64) NOT NULL UNIQUE,
+
+    -- Lifecycle; NULL until a newer secret replaces this one
+    expires_at          TIMESTAMP WITH TIME ZONE,
+
+    -- Timestamps
+    created_at          TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_service_client_secrets_client_id ON service_client_secrets (service_client_id);
@@ -0,0 +1,5 @@
+ALTER TABLE sessions DROP COLUMN IF EXISTS scopes;
+ALTER TABLE sessions DROP COLUMN IF EXISTS oauth_client_id;
+DROP TABLE IF EXISTS oauth_authorization_codes;
+DROP TABLE IF EXISTS oauth_consents;
+DROP TABLE IF EXISTS oauth_clients;
\ No newline at end of file
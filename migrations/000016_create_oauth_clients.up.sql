@@ -0,0 +1,58 @@
+-- Third-party applications that obtain developer tokens through the
+-- authorization code grant. Public clients (SPAs, editor plugins) hold no
+-- secret and rely on PKCE alone.
+CREATE TABLE oauth_clients (
+    id              UUID PRIMARY KEY DEFAULT uuidv7(),
+    client_id       VARCHAR(64) NOT NULL UNIQUE,
+    name            VARCHAR(255) NOT NULL,
+    secret_hash     CHAR(64),
+    redirect_uris   TEXT[] NOT NULL,
+    created_by      UUID REFERENCES developers(id) ON DELETE SET NULL,
+
+    -- Lifecycle
+    revoked_at      TIMESTAMP WITH TIME ZONE,
+
+    -- Timestamps
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_oauth_clients_created_by ON oauth_clients (created_by);
+
+-- Scopes a developer has approved for a client; later requests within them skip the prompt
+CREATE TABLE oauth_consents (
+    developer_id        UUID NOT NULL REFERENCES developers(id) ON DELETE CASCADE,
+    oauth_client_id     UUID NOT NULL REFERENCES oauth_clients(id) ON DELETE CASCADE,
+    scopes              TEXT[] NOT NULL,
+
+    -- Timestamps
+    created_at          TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (developer_id, oauth_client_id)
+);
+
+-- Single-use authorization codes; only hashes are kept
+CREATE TABLE oauth_authorization_codes (
+    id                      UUID PRIMARY KEY DEFAULT uuidv7(),
+    code_hash               CHAR(64) NOT NULL UNIQUE,
+    oauth_client_id         UUID NOT NULL REFERENCES oauth_clients(id) ON DELETE CASCADE,
+    developer_id            UUID NOT NULL REFERENCES developers(id) ON DELETE CASCADE,
+    redirect_uri            TEXT NOT NULL,
+    scopes                  TEXT[] NOT NULL,
+    code_challenge          VARCHAR(128) NOT NULL,
+    code_challenge_method   VARCHAR(10) NOT NULL,
+
+    -- Lifecycle; session_id records what the code was exchanged for
+    expires_at              TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at                 TIMESTAMP WITH TIME ZONE,
+    session_id              UUID REFERENCES sessions(id) ON DELETE SET NULL,
+
+    -- Timestamps
+    created_at              TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Sessions started by an OAuth client carry the consented scopes; NULL
+-- scopes mean everything the developer holds
+ALTER TABLE sessions
+    ADD COLUMN oauth_client_id UUID REFERENCES oauth_clients(id) ON DELETE CASCADE,
+    ADD COLUMN scopes TEXT[];
@@ -0,0 +1,2 @@
+-- OpenID Connect nonce of the authorization request, echoed in the ID token
+ALTER TABLE oauth_authorization_codes ADD COLUMN nonce VARCHAR(255);
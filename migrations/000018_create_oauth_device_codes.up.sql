@@ -0,0 +1,23 @@
+-- Device authorization grant (RFC 8628) for clients without a browser, such
+-- as the CLI on build boxes. The device polls with its device code while a
+-- signed-in developer approves the user code; only the device code's hash
+-- is kept.
+CREATE TABLE oauth_device_codes (
+    id                  UUID PRIMARY KEY DEFAULT uuidv7(),
+    device_code_hash    CHAR(64) NOT NULL UNIQUE,
+    user_code           VARCHAR(16) NOT NULL UNIQUE,
+    oauth_client_id     UUID NOT NULL REFERENCES oauth_clients(id) ON DELETE CASCADE,
+    scopes              TEXT[] NOT NULL,
+    interval_seconds    INTEGER NOT NULL,
+
+    -- Lifecycle; developer_id is whoever approved or denied the code
+    developer_id        UUID REFERENCES developers(id) ON DELETE CASCADE,
+    approved_at         TIMESTAMP WITH TIME ZONE,
+    denied_at           TIMESTAMP WITH TIME ZONE,
+    used_at             TIMESTAMP WITH TIME ZONE,
+    last_polled_at      TIMESTAMP WITH TIME ZONE,
+    expires_at          TIMESTAMP WITH TIME ZONE NOT NULL,
+
+    -- Timestamps
+    created_at          TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,6 @@
+DELETE FROM one_time_tokens WHERE purpose = 'federated_login';
+ALTER TABLE one_time_tokens DROP CONSTRAINT one_time_tokens_purpose_check;
+ALTER TABLE one_time_tokens ADD CONSTRAINT one_time_tokens_purpose_check
+    CHECK (purpose IN ('email_verification', 'password_reset'));
+DROP TABLE IF EXISTS federated_logins;
+DROP TABLE IF EXISTS external_identities;
\ No newline at end of file
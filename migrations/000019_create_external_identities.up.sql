@@ -0,0 +1,36 @@
+-- Identities at upstream OpenID Connect / OAuth providers ("Sign in with
+-- GitHub") linked to a developer. The subject is the provider's stable user
+-- id; each provider account maps to at most one developer.
+CREATE TABLE external_identities (
+    id              UUID PRIMARY KEY DEFAULT uuidv7(),
+    developer_id    UUID NOT NULL REFERENCES developers(id) ON DELETE CASCADE,
+    provider        VARCHAR(50) NOT NULL,
+    subject         VARCHAR(255) NOT NULL,
+    email           VARCHAR(255),
+
+    -- Timestamps
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_login_at   TIMESTAMP WITH TIME ZONE,
+
+    UNIQUE (provider, subject)
+);
+
+CREATE INDEX idx_external_identities_developer_id ON external_identities (developer_id);
+
+-- Short-lived state of an in-flight redirect to a provider; only the state's
+-- hash is kept. developer_id is set when linking to a signed-in developer.
+CREATE TABLE federated_logins (
+    id              UUID PRIMARY KEY DEFAULT uuidv7(),
+    state_hash      CHAR(64) NOT NULL UNIQUE,
+    provider        VARCHAR(50) NOT NULL,
+    nonce           VARCHAR(64) NOT NULL,
+    code_verifier   VARCHAR(128) NOT NULL,
+    developer_id    UUID REFERENCES developers(id) ON DELETE CASCADE,
+    expires_at      TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Single-use codes handing a finished federated login to the dashboard
+ALTER TABLE one_time_tokens DROP CONSTRAINT one_time_tokens_purpose_check;
+ALTER TABLE one_time_tokens ADD CONSTRAINT one_time_tokens_purpose_check
+    CHECK (purpose IN ('email_verification', 'password_reset', 'federated_login'));
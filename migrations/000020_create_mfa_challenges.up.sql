@@ -0,0 +1,15 @@
+-- Pending second-factor challenges, keyed by the jti of the MFA token. Each
+-- token is single use and allows a few attempts; guessing across many tokens
+-- is limited per developer by the lockout on developer_mfa.
+CREATE TABLE mfa_challenges (
+    id              UUID PRIMARY KEY,
+    developer_id    UUID NOT NULL REFERENCES developers(id) ON DELETE CASCADE,
+
+    -- Lifecycle
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    used_at         TIMESTAMP WITH TIME ZONE,
+    expires_at      TIMESTAMP WITH TIME ZONE NOT NULL,
+
+    -- Timestamps
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1 @@
+ALTER TABLE federated_logins DROP COLUMN IF EXISTS binding_hash;
\ No newline at end of file
@@ -0,0 +1,6 @@
+-- Hash of a cookie set on the browser that started the redirect. The callback
+-- must present it, so a provider URL passed to another browser cannot finish
+-- a login or link there. In-flight logins cannot carry it and are dropped.
+DELETE FROM federated_logins;
+
+ALTER TABLE federated_logins ADD COLUMN binding_hash CHAR(64) NOT NULL;
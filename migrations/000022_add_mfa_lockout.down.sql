@@ -0,0 +1,3 @@
+ALTER TABLE developer_mfa
+    DROP COLUMN IF EXISTS locked_until,
+    DROP COLUMN IF EXISTS failed_attempts;
\ No newline at end of file
@@ -0,0 +1,6 @@
+-- Consecutive wrong second-factor codes per developer. Once failed_attempts
+-- reaches the limit every code is refused until locked_until, so fresh
+-- challenges from repeated password logins do not reset the budget.
+ALTER TABLE developer_mfa
+    ADD COLUMN failed_attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN locked_until    TIMESTAMP WITH TIME ZONE;
@@ -10,13 +10,17 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/vivek-344/diagon/sigil/config"
 	"github.com/vivek-344/diagon/sigil/internal/handler"
+	"github.com/vivek-344/diagon/sigil/internal/mailer"
 	"github.com/vivek-344/diagon/sigil/internal/middleware"
 	"github.com/vivek-344/diagon/sigil/internal/repository"
 	"github.com/vivek-344/diagon/sigil/internal/service"
+	"github.com/vivek-344/diagon/sigil/utils"
 )
 
 func main() {
@@ -52,14 +56,104 @@ func run(cfg *config.Config) error {
 	defer dbPool.Close()
 
 	// Initialize Repositories, Services, and Handlers
-	authMiddleware := middleware.AuthMiddleware(cfg.JWTSecret)
+	signingKeys, err := loadSigningKeys(cfg)
+	if err != nil {
+		return err
+	}
+	jwtManager := utils.NewJWTManager(signingKeys, cfg.JWTIssuer, cfg.JWTAudience, cfg.OIDCIssuer)
 	developerRepo := repository.NewDeveloperRepository(dbPool)
-	developerSvc := service.NewDeveloperService(developerRepo)
-	authHandler := handler.NewAuthHandler(developerSvc, cfg.JWTSecret)
-	developerHandler := handler.NewDeveloperHandler(developerSvc)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(dbPool)
+	sessionRepo := repository.NewSessionRepository(dbPool)
+	oneTimeTokenRepo := repository.NewOneTimeTokenRepository(dbPool)
+	mfaRepo := repository.NewMFARepository(dbPool)
+	webauthnRepo := repository.NewWebAuthnRepository(dbPool)
+	apiKeyRepo := repository.NewAPIKeyRepository(dbPool)
+	orgRepo := repository.NewOrganizationRepository(dbPool)
+	projectRepo := repository.NewProjectRepository(dbPool)
+	serviceClientRepo := repository.NewServiceClientRepository(dbPool)
+	usageRepo := repository.NewUsageRepository(dbPool)
+	oauthRepo := repository.NewOAuthRepository(dbPool)
+	deviceCodeRepo := repository.NewDeviceCodeRepository(dbPool)
+	externalIdentityRepo := repository.NewExternalIdentityRepository(dbPool)
+	planCatalog, err := service.NewPlanCatalog(cfg.PlanCatalog)
+	if err != nil {
+		return err
+	}
+	planSvc := service.NewPlanService(planCatalog, developerRepo, orgRepo, usageRepo)
+	rateLimiter := service.NewRateLimiter(planSvc)
+	go rateLimiter.Run(ctx)
+	revocations := service.NewRevocationList(sessionRepo)
+	go revocations.Run(ctx)
+	apiKeySvc := service.NewAPIKeyService(apiKeyRepo, developerRepo, planSvc)
+	authMiddleware := chi.Chain(
+		middleware.AuthMiddleware(jwtManager, revocations, apiKeySvc),
+		middleware.RateLimit(rateLimiter),
+	).Handler
+	authSvc := service.NewAuthService(developerRepo, refreshTokenRepo, sessionRepo, orgRepo, oauthRepo, revocations, jwtManager)
+	developerSvc := service.NewDeveloperService(developerRepo, planSvc, authSvc)
+	if cfg.BootstrapSuperadminEmail != "" {
+		if err := developerSvc.BootstrapSuperadmin(ctx, cfg.BootstrapSuperadminEmail); err != nil {
+			return err
+		}
+	}
+	if len(cfg.MFAEncryptionKey) == 0 {
+		slog.Warn("no mfa encryption key configured, two-factor enrollment is disabled")
+	}
+	mfaSvc := service.NewMFAService(mfaRepo, developerRepo, authSvc, jwtManager, cfg.MFAEncryptionKey)
+	authHandler := handler.NewAuthHandler(developerSvc, authSvc, mfaSvc)
+	mfaHandler := handler.NewMFAHandler(developerSvc, mfaSvc)
+	relyingParty, err := webauthn.New(&webauthn.Config{
+		RPID:          cfg.WebAuthnRPID,
+		RPDisplayName: "DIAGON",
+		RPOrigins:     cfg.WebAuthnRPOrigins,
+	})
+	if err != nil {
+		return err
+	}
+	webauthnSvc := service.NewWebAuthnService(relyingParty, webauthnRepo, developerRepo, authSvc)
+	webauthnHandler := handler.NewWebAuthnHandler(developerSvc, webauthnSvc)
+	sessionHandler := handler.NewSessionHandler(authSvc)
+	mail := newMailer(cfg)
+	verificationSvc := service.NewVerificationService(developerRepo, oneTimeTokenRepo, mail, cfg.AppBaseURL)
+	passwordResetSvc := service.NewPasswordResetService(developerRepo, oneTimeTokenRepo, developerSvc, authSvc, mail, cfg.AppBaseURL)
+	developerHandler := handler.NewDeveloperHandler(developerSvc, authSvc, verificationSvc, passwordResetSvc)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeySvc)
+	orgSvc := service.NewOrganizationService(orgRepo, developerRepo, sessionRepo, planSvc, mail, cfg.AppBaseURL)
+	orgHandler := handler.NewOrganizationHandler(orgSvc)
+	projectSvc := service.NewProjectService(projectRepo, orgRepo, planSvc)
+	projectHandler := handler.NewProjectHandler(projectSvc)
+	serviceClientSvc := service.NewServiceClientService(serviceClientRepo, projectRepo, projectSvc, jwtManager)
+	serviceClientHandler := handler.NewServiceClientHandler(serviceClientSvc)
+	oauthSvc := service.NewOAuthService(oauthRepo, developerRepo, authSvc)
+	if !cfg.OIDCIssuerSet {
+		// ID tokens issued to clients would name a localhost issuer
+		hasClients, err := oauthSvc.HasClients(ctx)
+		if err != nil {
+			return err
+		}
+		if hasClients {
+			return errors.New("OIDC_ISSUER is required when oauth clients are registered")
+		}
+		slog.Warn("no oidc issuer configured, falling back to localhost", "issuer", cfg.OIDCIssuer)
+	}
+	deviceSvc := service.NewDeviceAuthorizationService(deviceCodeRepo, oauthRepo, developerRepo, oauthSvc, authSvc)
+	tokenSvc := service.NewTokenService(
+		jwtManager, developerRepo, sessionRepo, refreshTokenRepo, apiKeyRepo, serviceClientRepo, oauthRepo,
+		serviceClientSvc, oauthSvc, authSvc,
+	)
+	oauthHandler := handler.NewOAuthHandler(serviceClientSvc, oauthSvc, deviceSvc, tokenSvc, cfg.AppBaseURL)
+	oauthClientHandler := handler.NewOAuthClientHandler(oauthSvc)
+	planHandler := handler.NewPlanHandler(planSvc)
+	wellKnownHandler := handler.NewWellKnownHandler(jwtManager)
+	identityProviders, err := service.NewIdentityProviders(cfg.IdentityProviders, cfg.OIDCIssuer+"/auth/federated")
+	if err != nil {
+		return err
+	}
+	federationSvc := service.NewFederationService(identityProviders, externalIdentityRepo, developerRepo, oneTimeTokenRepo)
+	federationHandler := handler.NewFederationHandler(developerSvc, authSvc, mfaSvc, federationSvc, cfg.AppBaseURL)
 
 	// HTTP Router
-	router := setupRouter(authMiddleware, authHandler, developerHandler, dbPool)
+	router := setupRouter(authMiddleware, authHandler, sessionHandler, mfaHandler, webauthnHandler, developerHandler, apiKeyHandler, orgHandler, projectHandler, serviceClientHandler, oauthHandler, oauthClientHandler, federationHandler, planHandler, wellKnownHandler, dbPool)
 
 	// HTTP Server
 	server := &http.Server{
@@ -73,6 +167,28 @@ func run(cfg *config.Config) error {
 	return startServerWithGracefulShutdown(ctx, server)
 }
 
+func loadSigningKeys(cfg *config.Config) (*utils.KeySet, error) {
+	if len(cfg.JWTKeysPEM) == 0 {
+		slog.Warn("no asymmetric signing keys configured, falling back to HS256")
+		return utils.NewHMACKeySet(cfg.JWTSecret), nil
+	}
+
+	keys, err := utils.ParseKeySet(cfg.JWTKeysPEM, cfg.JWTActiveKeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("signing keys loaded", "active_kid", keys.Active().ID, "keys", len(keys.JWKS().Keys))
+	return keys, nil
+}
+
+func newMailer(cfg *config.Config) mailer.Mailer {
+	if cfg.MailDriver == "smtp" {
+		return mailer.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom)
+	}
+	return mailer.NewFileMailer(cfg.MailDir)
+}
+
 func initDB(ctx context.Context, databaseURL string) (*pgxpool.Pool, error) {
 	poolConfig, err := pgxpool.ParseConfig(databaseURL)
 	if err != nil {
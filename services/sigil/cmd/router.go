@@ -9,17 +9,40 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/vivek-344/diagon/sigil/internal/domain"
 	"github.com/vivek-344/diagon/sigil/internal/handler"
+	authmw "github.com/vivek-344/diagon/sigil/internal/middleware"
 )
 
 func setupRouter(
 	authMiddleware func(http.Handler) http.Handler,
 	authHandler *handler.AuthHandler,
+	sessionHandler *handler.SessionHandler,
+	mfaHandler *handler.MFAHandler,
+	webauthnHandler *handler.WebAuthnHandler,
 	developerHandler *handler.DeveloperHandler,
+	apiKeyHandler *handler.APIKeyHandler,
+	orgHandler *handler.OrganizationHandler,
+	projectHandler *handler.ProjectHandler,
+	serviceClientHandler *handler.ServiceClientHandler,
+	oauthHandler *handler.OAuthHandler,
+	oauthClientHandler *handler.OAuthClientHandler,
+	federationHandler *handler.FederationHandler,
+	planHandler *handler.PlanHandler,
+	wellKnownHandler *handler.WellKnownHandler,
 	dbPool *pgxpool.Pool,
 ) *chi.Mux {
 	r := chi.NewRouter()
 
+	// Per-route scope requirements
+	canRead := authmw.RequireScopes(domain.ScopeDevelopersRead)
+	canWrite := authmw.RequireScopes(domain.ScopeDevelopersWrite)
+	isAdmin := authmw.RequireScopes(domain.ScopeAdmin)
+	isOpenID := authmw.RequireScopes(domain.ScopeOpenID)
+	canReadProject := authmw.RequireScopes(domain.ScopeProjectRead)
+	canWriteProject := authmw.RequireScopes(domain.ScopeProjectWrite)
+	firstParty := authmw.RequireFirstPartySession
+
 	// Global middleware
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
@@ -44,24 +67,129 @@ func setupRouter(
 		})
 	})
 
+	// Public signing keys and OpenID Connect discovery
+	r.Get("/.well-known/jwks.json", wellKnownHandler.JWKS)
+	r.Get("/.well-known/openid-configuration", wellKnownHandler.OpenIDConfiguration)
+
+	// OAuth 2.0 authorization server
+	r.Route("/oauth", func(r chi.Router) {
+		r.Get("/authorize", oauthHandler.Authorize)
+		r.Post("/token", oauthHandler.Token)
+		r.Post("/device/code", oauthHandler.DeviceAuthorization)
+		r.Post("/introspect", oauthHandler.Introspect)
+		r.Post("/revoke", oauthHandler.Revoke)
+		r.Group(func(r chi.Router) {
+			r.Use(authMiddleware)
+			r.With(firstParty, canWrite).Get("/consent", oauthHandler.ConsentPrompt)
+			r.With(firstParty, canWrite).Post("/consent", oauthHandler.Consent)
+			r.With(firstParty, canWrite).Get("/device", oauthHandler.DeviceLookup)
+			r.With(firstParty, canWrite).Post("/device", oauthHandler.DeviceDecision)
+			r.With(canRead).Get("/clients", oauthClientHandler.List)
+			r.With(firstParty, canWrite).Post("/clients", oauthClientHandler.Create)
+			r.With(firstParty, canWrite).Delete("/clients/{id}", oauthClientHandler.Revoke)
+		})
+	})
+
 	// API routes
 	r.Route("/auth", func(r chi.Router) {
 		r.Post("/register", developerHandler.Create)
 		r.Post("/login", authHandler.Login)
 		r.Post("/refresh", authHandler.RefreshToken)
+		r.Post("/verify-email", developerHandler.VerifyEmail)
+		r.Post("/forgot-password", developerHandler.ForgotPassword)
+		r.Post("/reset-password", developerHandler.ResetPassword)
+		r.Post("/mfa/verify", mfaHandler.Verify)
+		r.Post("/webauthn/login/begin", webauthnHandler.BeginLogin)
+		r.Post("/webauthn/login/finish", webauthnHandler.FinishLogin)
+		r.Get("/federated/providers", federationHandler.Providers)
+		r.Get("/federated/{provider}/login", federationHandler.Login)
+		r.Get("/federated/{provider}/callback", federationHandler.Callback)
+		r.Post("/federated/exchange", federationHandler.Exchange)
 		r.Group(func(r chi.Router) {
 			r.Use(authMiddleware)
-			r.Get("/profile", authHandler.GetProfile)
+			r.With(canRead).Get("/profile", authHandler.GetProfile)
+			r.With(canWrite).Post("/verify-email/resend", developerHandler.ResendVerification)
+			r.With(firstParty, canWrite).Post("/logout", sessionHandler.Logout)
+			r.With(firstParty, canRead).Get("/sessions", sessionHandler.List)
+			r.With(firstParty, canWrite).Delete("/sessions", sessionHandler.RevokeOthers)
+			r.With(firstParty, canWrite).Delete("/sessions/{id}", sessionHandler.Revoke)
+			r.With(canWrite).Post("/switch-organization", sessionHandler.SwitchOrganization)
+			r.With(firstParty, canWrite).Post("/mfa/enroll", mfaHandler.Enroll)
+			r.With(firstParty, canWrite).Post("/mfa/confirm", mfaHandler.Confirm)
+			r.With(firstParty, canWrite).Post("/mfa/disable", mfaHandler.Disable)
+			r.With(firstParty, canWrite).Post("/webauthn/register/begin", webauthnHandler.BeginRegistration)
+			r.With(firstParty, canWrite).Post("/webauthn/register/finish", webauthnHandler.FinishRegistration)
+			r.With(firstParty, canRead).Get("/webauthn/credentials", webauthnHandler.ListCredentials)
+			r.With(firstParty, canWrite).Delete("/webauthn/credentials/{id}", webauthnHandler.DeleteCredential)
+			r.With(firstParty, canWrite).Post("/federated/{provider}/link", federationHandler.Link)
+			r.With(firstParty, canRead).Get("/identities", federationHandler.ListIdentities)
+			r.With(firstParty, canWrite).Delete("/identities/{id}", federationHandler.UnlinkIdentity)
 		})
 	})
 	r.Route("/developers", func(r chi.Router) {
 		r.Use(authMiddleware)
-		r.Get("/", developerHandler.GetAll)
-		r.Get("/{id}", developerHandler.GetByID)
-		r.Put("/{id}", developerHandler.Update)
-		r.Delete("/{id}", developerHandler.Delete)
-		r.Put("/{id}/password", developerHandler.UpdatePassword)
-		r.Post("/{id}/suspend", developerHandler.Suspend)
+		r.With(canRead).Get("/", developerHandler.GetAll)
+		r.With(canRead).Get("/{id}", developerHandler.GetByID)
+		r.With(canWrite).Put("/{id}", developerHandler.Update)
+		r.With(canWrite).Patch("/{id}", developerHandler.Update)
+		r.With(canWrite).Delete("/{id}", developerHandler.Delete)
+		r.With(canWrite).Put("/{id}/password", developerHandler.UpdatePassword)
+		r.With(isAdmin).Post("/{id}/suspend", developerHandler.Suspend)
+		r.With(isAdmin).Put("/{id}/role", developerHandler.SetRole)
+	})
+	r.Route("/api-keys", func(r chi.Router) {
+		r.Use(authMiddleware, firstParty)
+		r.With(canRead).Get("/", apiKeyHandler.List)
+		r.With(canWrite).Post("/", apiKeyHandler.Create)
+		r.With(canWrite).Delete("/{id}", apiKeyHandler.Revoke)
+	})
+	r.Route("/organizations", func(r chi.Router) {
+		r.Use(authMiddleware)
+		r.With(canRead).Get("/", orgHandler.List)
+		r.With(canWrite).Post("/", orgHandler.Create)
+		r.With(canWrite).Post("/invitations/accept", orgHandler.AcceptInvitation)
+		r.With(canRead).Get("/{orgID}", orgHandler.Get)
+		r.With(canWrite).Patch("/{orgID}", orgHandler.Rename)
+		r.With(canWrite).Delete("/{orgID}", orgHandler.Delete)
+		r.With(canWrite).Post("/{orgID}/transfer", orgHandler.TransferOwnership)
+		r.With(canRead).Get("/{orgID}/usage", planHandler.OrganizationUsage)
+		r.With(canRead).Get("/{orgID}/members", orgHandler.ListMembers)
+		r.With(canWrite).Put("/{orgID}/members/{developerID}/role", orgHandler.SetMemberRole)
+		r.With(canWrite).Delete("/{orgID}/members/{developerID}", orgHandler.RemoveMember)
+		r.With(canRead).Get("/{orgID}/invitations", orgHandler.ListInvitations)
+		r.With(canWrite).Post("/{orgID}/invitations", orgHandler.Invite)
+		r.With(canWrite).Delete("/{orgID}/invitations/{invitationID}", orgHandler.RevokeInvitation)
+	})
+	r.Route("/plans", func(r chi.Router) {
+		r.Use(authMiddleware)
+		r.Get("/", planHandler.List)
+	})
+	r.With(authMiddleware, canRead).Get("/usage", planHandler.Usage)
+	r.With(authMiddleware, isOpenID).Get("/userinfo", authHandler.UserInfo)
+	r.With(authMiddleware, isOpenID).Post("/userinfo", authHandler.UserInfo)
+	r.Route("/projects", func(r chi.Router) {
+		r.Use(authMiddleware)
+		r.With(canRead).Get("/", projectHandler.List)
+		r.With(canWrite).Post("/", projectHandler.Create)
+		r.With(canRead).Get("/{projectID}", projectHandler.Get)
+		r.With(canWrite).Patch("/{projectID}", projectHandler.Rename)
+		r.With(canWrite).Post("/{projectID}/archive", projectHandler.Archive)
+		r.With(canWrite).Post("/{projectID}/unarchive", projectHandler.Unarchive)
+		r.With(canRead).Get("/{projectID}/environments", projectHandler.ListEnvironments)
+		r.With(canWrite).Post("/{projectID}/environments", projectHandler.AddEnvironment)
+		r.With(canWrite).Delete("/{projectID}/environments/{envID}", projectHandler.RemoveEnvironment)
+		r.With(canRead).Get("/{projectID}/clients", serviceClientHandler.List)
+		r.With(canWrite).Post("/{projectID}/clients", serviceClientHandler.Create)
+		r.With(canWrite).Post("/{projectID}/clients/{clientID}/rotate", serviceClientHandler.RotateSecret)
+		r.With(canWrite).Delete("/{projectID}/clients/{clientID}", serviceClientHandler.Revoke)
+	})
+	// The calling service client's own project
+	r.Route("/project", func(r chi.Router) {
+		r.Use(authMiddleware)
+		r.With(canReadProject).Get("/", projectHandler.ClientGet)
+		r.With(canReadProject).Get("/environments", projectHandler.ClientListEnvironments)
+		r.With(canWriteProject).Post("/environments", projectHandler.ClientAddEnvironment)
+		r.With(canWriteProject).Delete("/environments/{envID}", projectHandler.ClientRemoveEnvironment)
 	})
 
 	return r
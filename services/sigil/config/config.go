@@ -1,9 +1,12 @@
 package config
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"log/slog"
+	"os"
+	"strings"
 
 	"github.com/spf13/viper"
 )
@@ -12,6 +15,45 @@ type Config struct {
 	Port        string
 	DatabaseURL string
 	JWTSecret   string
+	JWTIssuer   string
+	JWTAudience string
+
+	// PEM encoded signing keys; when empty tokens fall back to HS256 with JWTSecret
+	JWTKeysPEM     []byte
+	JWTActiveKeyID string
+
+	// Public URL of Sigil, the issuer of ID tokens and OpenID Connect discovery.
+	// OIDCIssuerSet is false when it fell back to localhost.
+	OIDCIssuer    string
+	OIDCIssuerSet bool
+
+	// AES-256 key for encrypting TOTP secrets at rest; MFA is unavailable without it
+	MFAEncryptionKey []byte
+
+	// Base URL of the dashboard, used to build links in emails
+	AppBaseURL string
+
+	// Registered email promoted to superadmin while none exists
+	BootstrapSuperadminEmail string
+
+	// WebAuthn relying party; origins default to AppBaseURL
+	WebAuthnRPID      string
+	WebAuthnRPOrigins []string
+
+	// JSON plan catalog keyed by tier; empty uses the built-in plans
+	PlanCatalog []byte
+
+	// JSON identity providers for federated login keyed by name; empty disables it
+	IdentityProviders []byte
+
+	// Mail delivery; MailDriver is "smtp" or "file"
+	MailDriver   string
+	MailFrom     string
+	MailDir      string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
 }
 
 func Load() (*Config, error) {
@@ -27,6 +69,62 @@ func Load() (*Config, error) {
 		DatabaseURL: viper.GetString("DATABASE_URL"),
 		Port:        viper.GetString("PORT"),
 		JWTSecret:   viper.GetString("JWT_SECRET"),
+		JWTIssuer:   viper.GetString("JWT_ISSUER"),
+		JWTAudience: viper.GetString("JWT_AUDIENCE"),
+
+		JWTActiveKeyID: viper.GetString("JWT_ACTIVE_KEY_ID"),
+
+		OIDCIssuer: strings.TrimSuffix(viper.GetString("OIDC_ISSUER"), "/"),
+
+		AppBaseURL: viper.GetString("APP_BASE_URL"),
+
+		WebAuthnRPID: viper.GetString("WEBAUTHN_RP_ID"),
+
+		BootstrapSuperadminEmail: viper.GetString("BOOTSTRAP_SUPERADMIN_EMAIL"),
+
+		MailDriver:   viper.GetString("MAIL_DRIVER"),
+		MailFrom:     viper.GetString("MAIL_FROM"),
+		MailDir:      viper.GetString("MAIL_DIR"),
+		SMTPHost:     viper.GetString("SMTP_HOST"),
+		SMTPPort:     viper.GetString("SMTP_PORT"),
+		SMTPUsername: viper.GetString("SMTP_USERNAME"),
+		SMTPPassword: viper.GetString("SMTP_PASSWORD"),
+	}
+
+	keys, err := loadKeysPEM(viper.GetString("JWT_KEY_FILES"), viper.GetString("JWT_KEYS"))
+	if err != nil {
+		return nil, err
+	}
+	cfg.JWTKeysPEM = keys
+
+	if path := viper.GetString("PLAN_CATALOG_FILE"); path != "" {
+		catalog, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read plan catalog %s: %w", path, err)
+		}
+		cfg.PlanCatalog = catalog
+	}
+
+	if path := viper.GetString("IDENTITY_PROVIDERS_FILE"); path != "" {
+		providers, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read identity providers %s: %w", path, err)
+		}
+		cfg.IdentityProviders = providers
+	}
+
+	for _, origin := range strings.Split(viper.GetString("WEBAUTHN_RP_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			cfg.WebAuthnRPOrigins = append(cfg.WebAuthnRPOrigins, origin)
+		}
+	}
+
+	if encoded := viper.GetString("MFA_ENCRYPTION_KEY"); encoded != "" {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("MFA_ENCRYPTION_KEY must be base64: %w", err)
+		}
+		cfg.MFAEncryptionKey = key
 	}
 
 	// Default port if not set
@@ -34,8 +132,37 @@ func Load() (*Config, error) {
 		cfg.Port = "8080"
 	}
 
-	if cfg.JWTSecret == "" {
-		return nil, errors.New("JWT_SECRET is required")
+	// Default token issuer and audience if not set
+	if cfg.JWTIssuer == "" {
+		cfg.JWTIssuer = "sigil"
+	}
+	if cfg.JWTAudience == "" {
+		cfg.JWTAudience = "diagon"
+	}
+	cfg.OIDCIssuerSet = cfg.OIDCIssuer != ""
+	if !cfg.OIDCIssuerSet {
+		cfg.OIDCIssuer = "http://localhost:" + cfg.Port
+	}
+
+	// Default to the development mailer
+	if cfg.MailDriver == "" {
+		cfg.MailDriver = "file"
+	}
+	if cfg.SMTPPort == "" {
+		cfg.SMTPPort = "587"
+	}
+	if cfg.AppBaseURL == "" {
+		cfg.AppBaseURL = "http://localhost:" + cfg.Port
+	}
+	if cfg.WebAuthnRPID == "" {
+		cfg.WebAuthnRPID = "localhost"
+	}
+	if len(cfg.WebAuthnRPOrigins) == 0 {
+		cfg.WebAuthnRPOrigins = []string{cfg.AppBaseURL}
+	}
+
+	if cfg.JWTSecret == "" && len(cfg.JWTKeysPEM) == 0 {
+		return nil, errors.New("JWT_SECRET or JWT_KEY_FILES/JWT_KEYS is required")
 	}
 
 	if err := cfg.validate(); err != nil {
@@ -49,5 +176,46 @@ func (c *Config) validate() error {
 	if c.DatabaseURL == "" {
 		return errors.New("DATABASE_URL is required")
 	}
+	// Providers redirect back to, and validate tokens against, the issuer URL
+	if len(c.IdentityProviders) != 0 && !c.OIDCIssuerSet {
+		return errors.New("OIDC_ISSUER is required when identity providers are configured")
+	}
+	if len(c.MFAEncryptionKey) != 0 && len(c.MFAEncryptionKey) != 32 {
+		return errors.New("MFA_ENCRYPTION_KEY must decode to 32 bytes")
+	}
+	switch c.MailDriver {
+	case "file":
+	case "smtp":
+		if c.SMTPHost == "" || c.MailFrom == "" {
+			return errors.New("SMTP_HOST and MAIL_FROM are required for the smtp mail driver")
+		}
+	default:
+		return fmt.Errorf("unknown MAIL_DRIVER %q", c.MailDriver)
+	}
 	return nil
 }
+
+// loadKeysPEM concatenates PEM blocks from a comma separated list of files
+// and an inline env value, which may use literal \n for newlines.
+func loadKeysPEM(files string, inline string) ([]byte, error) {
+	var keys []byte
+
+	for _, path := range strings.Split(files, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file %s: %w", path, err)
+		}
+		keys = append(keys, b...)
+		keys = append(keys, '\n')
+	}
+
+	if inline != "" {
+		keys = append(keys, strings.ReplaceAll(inline, `\n`, "\n")...)
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKeyPrefix marks sigil API keys so they can be told apart from JWTs and spotted by secret scanners
+const APIKeyPrefix = "sgl_"
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrInvalidAPIKey  = errors.New("invalid api key")
+	ErrExpiredAPIKey  = errors.New("api key has expired")
+)
+
+// APIKey is a long-lived credential for CI and other headless tooling.
+// Only the SHA-256 hash of the key is stored; the key itself is shown once.
+type APIKey struct {
+	ID          uuid.UUID
+	DeveloperID uuid.UUID
+	Name        string
+	Prefix      string
+	KeyHash     string
+	Scopes      []string
+	ExpiresAt   *time.Time
+	RevokedAt   *time.Time
+	LastUsedAt  *time.Time
+	LastUsedIP  *string
+	CreatedAt   time.Time
+}
+
+// Repository interface for APIKey entity
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *APIKey) error
+	GetByHash(ctx context.Context, keyHash string) (*APIKey, error)
+	ListActive(ctx context.Context, developerID uuid.UUID) ([]*APIKey, error)
+	Revoke(ctx context.Context, id uuid.UUID, developerID uuid.UUID) error
+	Touch(ctx context.Context, id uuid.UUID, ipAddress string) error
+}
+
+// APIKeyAuthenticator resolves a raw API key to the key and its owner
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, rawKey string, ipAddress string) (*APIKey, *Developer, error)
+}
@@ -18,17 +18,30 @@ const (
 	StatusDeleted   Status     = "deleted"
 	DeveloperIDKey  contextKey = "developer_id"
 	EmailKey        contextKey = "email"
+	SessionIDKey    contextKey = "session_id"
+	APIKeyIDKey     contextKey = "api_key_id"
+	ScopesKey       contextKey = "scopes"
+	RoleKey         contextKey = "role"
+	OrgIDKey        contextKey = "org_id"
+	OrgRoleKey      contextKey = "org_role"
+	PrincipalKey    contextKey = "principal"
+	ClientIDKey     contextKey = "client_id"
+	ProjectIDKey    contextKey = "project_id"
 )
 
 var (
-	ErrEmailExists     = errors.New("email already registered")
-	ErrInvalidPassword = errors.New("invalid password")
-	ErrInvalidEmail    = errors.New("invalid email format")
-	ErrShortPassword   = errors.New("password should be at least 8 characters long")
-	ErrWeakPassword    = errors.New("password should include a letter and a number/symbol")
-	ErrNotFound        = errors.New("developer not found")
-	ErrWrongPassword   = errors.New("wrong password")
-	ErrInvalidInput    = errors.New("invalid input")
+	ErrEmailExists        = errors.New("email already registered")
+	ErrInvalidPassword    = errors.New("invalid password")
+	ErrInvalidEmail       = errors.New("invalid email format")
+	ErrShortPassword      = errors.New("password should be at least 8 characters long")
+	ErrWeakPassword       = errors.New("password should include a letter and a number/symbol")
+	ErrNotFound           = errors.New("developer not found")
+	ErrWrongPassword      = errors.New("wrong password")
+	ErrInvalidInput       = errors.New("invalid input")
+	ErrAccountSuspended   = errors.New("account suspended")
+	ErrPreconditionFailed = errors.New("developer was modified since it was read")
+	ErrInvalidCursor      = errors.New("invalid cursor")
+	ErrSuspendViaUpdate   = errors.New("developers are suspended through the suspend endpoint")
 )
 
 type Developer struct {
@@ -38,10 +51,12 @@ type Developer struct {
 	FullName      *string
 	CompanyName   *string
 	Status        Status
+	Role          Role
 	EmailVerified bool
 	PlanTier      string
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
+	Version       int64
 	LastLoginAt   *time.Time
 	Metadata      map[string]any
 }
@@ -49,6 +64,59 @@ type Developer struct {
 type DeveloperFilter struct {
 	Status   *Status
 	PlanTier *string
+
+	// Case-insensitive substring of email, full name or company name
+	Query *string
+
+	EmailVerified   *bool
+	CreatedAfter    *time.Time
+	CreatedBefore   *time.Time
+	LastLoginAfter  *time.Time
+	LastLoginBefore *time.Time
+
+	// Metadata keys whose value, as text, must equal the given string
+	Metadata map[string]string
+}
+
+type DeveloperSort string
+
+const (
+	SortByCreatedAt   DeveloperSort = "created_at"
+	SortByUpdatedAt   DeveloperSort = "updated_at"
+	SortByLastLoginAt DeveloperSort = "last_login_at" // never-logged-in developers sort as oldest
+	SortByEmail       DeveloperSort = "email"
+)
+
+// Valid reports whether s is a supported sort field
+func (s DeveloperSort) Valid() bool {
+	switch s {
+	case SortByCreatedAt, SortByUpdatedAt, SortByLastLoginAt, SortByEmail:
+		return true
+	}
+	return false
+}
+
+// DeveloperCursor is the position after the last developer of a page: its
+// sort key and ID under the ordering the page was listed in
+type DeveloperCursor struct {
+	Sort      DeveloperSort
+	Ascending bool
+	Key       any // time.Time, or string when sorting by email
+	ID        uuid.UUID
+}
+
+type DeveloperPageRequest struct {
+	After        *DeveloperCursor // nil starts from the first developer
+	Limit        int
+	IncludeTotal bool
+	Sort         DeveloperSort // defaults to SortByCreatedAt
+	Ascending    bool
+}
+
+type DeveloperPage struct {
+	Developers []*Developer
+	NextCursor *DeveloperCursor // nil on the last page
+	Total      *int             // only when requested
 }
 
 // Repository interface for Developer entity
@@ -57,15 +125,18 @@ type DeveloperRepository interface {
 	VerifyEmail(ctx context.Context, id uuid.UUID) error
 	GetByID(ctx context.Context, id uuid.UUID) (*Developer, error)
 	GetByEmail(ctx context.Context, email string) (*Developer, error)
-	GetAll(ctx context.Context, filter DeveloperFilter, page int, pageSize int) ([]*Developer, error) // with filters (ie. status and plan tier)
+	GetAll(ctx context.Context, filter DeveloperFilter, page DeveloperPageRequest) (*DeveloperPage, error)
 	UpdatePassword(ctx context.Context, id uuid.UUID, oldPasswordHash string, newPasswordHash string) error
 	Update(ctx context.Context, id uuid.UUID, input *UpdateDeveloperInput) error
 	UpdateLastLogin(ctx context.Context, id uuid.UUID, loginTime time.Time) error
 	ResetPassword(ctx context.Context, id uuid.UUID, newPasswordHash string) error
 	AddMetadata(ctx context.Context, id uuid.UUID, key string, value any) error
-	Delete(ctx context.Context, id uuid.UUID) error
-	SoftDelete(ctx context.Context, id uuid.UUID) error
-	Suspend(ctx context.Context, id uuid.UUID) error
+	// The ifVersion arguments make a write conditional; ErrPreconditionFailed when stale
+	Delete(ctx context.Context, id uuid.UUID, ifVersion *int64) error
+	SoftDelete(ctx context.Context, id uuid.UUID, ifVersion *int64) error
+	Suspend(ctx context.Context, id uuid.UUID, ifVersion *int64) error
+	SetRole(ctx context.Context, id uuid.UUID, role Role, ifVersion *int64) error
+	CountByRole(ctx context.Context, role Role) (int, error)
 }
 
 // Input DTOs
@@ -76,9 +147,32 @@ type CreateDeveloperInput struct {
 	CompanyName *string
 }
 
+// Optional distinguishes a field left out of an update from one set to null
+type Optional[T any] struct {
+	Set   bool
+	Value *T
+}
+
+// UpdateDeveloperInput is a partial update; only fields that are Set are written
 type UpdateDeveloperInput struct {
-	FullName    *string
-	CompanyName *string
-	Status      *Status
-	PlanTier    *string
+	// Self-editable
+	FullName    Optional[string]
+	CompanyName Optional[string]
+
+	// Privileged, admins only
+	Status   Optional[Status]
+	PlanTier Optional[string]
+
+	// When set, the update only applies to this version of the row
+	IfVersion *int64
+}
+
+// Privileged reports whether the update touches admin-only fields
+func (in *UpdateDeveloperInput) Privileged() bool {
+	return in.Status.Set || in.PlanTier.Set
+}
+
+// Empty reports whether the update changes nothing
+func (in *UpdateDeveloperInput) Empty() bool {
+	return !in.FullName.Set && !in.CompanyName.Set && !in.Privileged()
 }
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeviceCodeGrantType is the grant_type of device code polling (RFC 8628 3.4)
+const DeviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+var (
+	ErrDeviceCodeNotFound   = errors.New("device code not found")
+	ErrUserCodeTaken        = errors.New("user code already in use")
+	ErrAuthorizationPending = errors.New("authorization pending")
+	ErrSlowDown             = errors.New("polling too frequently")
+	ErrExpiredDeviceCode    = errors.New("device code has expired")
+	ErrAccessDenied         = errors.New("authorization denied")
+)
+
+// DeviceCode is a pending device authorization. The device polls with the
+// device code, whose hash is kept; the developer enters the user code.
+type DeviceCode struct {
+	ID             uuid.UUID
+	DeviceCodeHash string
+	UserCode       string // normalized, without the separator
+	OAuthClientID  uuid.UUID
+	Scopes         []string
+	Interval       time.Duration // minimum time between polls
+	DeveloperID    *uuid.UUID    // who approved or denied the code
+	ApprovedAt     *time.Time
+	DeniedAt       *time.Time
+	UsedAt         *time.Time
+	LastPolledAt   *time.Time
+	ExpiresAt      time.Time
+	CreatedAt      time.Time
+}
+
+// Pending reports whether the developer has not decided yet
+func (c *DeviceCode) Pending() bool {
+	return c.ApprovedAt == nil && c.DeniedAt == nil
+}
+
+// FormatUserCode splits a normalized user code as XXXX-XXXX for display
+func FormatUserCode(code string) string {
+	if len(code) != 8 {
+		return code
+	}
+	return code[:4] + "-" + code[4:]
+}
+
+// NormalizeUserCode accepts user codes typed in any case, with or without
+// the separator
+func NormalizeUserCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// Repository interface for DeviceCode entity
+type DeviceCodeRepository interface {
+	Create(ctx context.Context, code *DeviceCode) error // ErrUserCodeTaken on collision
+	GetByUserCode(ctx context.Context, userCode string) (*DeviceCode, error)
+	Decide(ctx context.Context, id uuid.UUID, developerID uuid.UUID, approved bool) error // pending, unexpired codes only
+
+	// Poll records a poll and returns the code with LastPolledAt as it was before
+	Poll(ctx context.Context, deviceCodeHash string) (*DeviceCode, error)
+	SlowDown(ctx context.Context, id uuid.UUID, interval time.Duration) error
+	Consume(ctx context.Context, id uuid.UUID) error // ErrDeviceCodeNotFound when already used
+}
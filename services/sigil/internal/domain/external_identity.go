@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	IdentityProviderOIDC   = "oidc"
+	IdentityProviderGitHub = "github"
+)
+
+var (
+	ErrUnknownIdentityProvider  = errors.New("unknown identity provider")
+	ErrFederatedLoginNotFound   = errors.New("federated login not found or expired")
+	ErrFederatedLoginFailed     = errors.New("identity provider login failed")
+	ErrExternalEmailUnverified  = errors.New("identity provider did not return a verified email")
+	ErrExternalEmailConflict    = errors.New("email belongs to an unverified account; sign in and link the identity instead")
+	ErrExternalIdentityNotFound = errors.New("external identity not found")
+	ErrExternalIdentityLinked   = errors.New("external identity is linked to another developer")
+	ErrExternalAccountDeleted   = errors.New("the account linked to this identity has been deleted")
+)
+
+// ExternalIdentity links a developer to an account at an upstream identity
+// provider. Subject is the provider's stable user id.
+type ExternalIdentity struct {
+	ID          uuid.UUID
+	DeveloperID uuid.UUID
+	Provider    string
+	Subject     string
+	Email       *string
+	CreatedAt   time.Time
+	LastLoginAt *time.Time
+}
+
+// ExternalProfile is what an identity provider asserts about the signed-in user
+type ExternalProfile struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// FederatedLogin is the server side state of an in-flight redirect to an
+// identity provider, looked up by the hash of the state parameter. The
+// binding is a cookie tying it to the browser that started it.
+type FederatedLogin struct {
+	ID           uuid.UUID
+	StateHash    string
+	BindingHash  string
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	DeveloperID  *uuid.UUID // set when linking to a signed-in developer
+	ExpiresAt    time.Time
+	CreatedAt    time.Time
+}
+
+// Repository interface for ExternalIdentity entity
+type ExternalIdentityRepository interface {
+	Create(ctx context.Context, identity *ExternalIdentity) error // ErrExternalIdentityLinked when the subject is taken
+	GetBySubject(ctx context.Context, provider string, subject string) (*ExternalIdentity, error)
+	ListByDeveloper(ctx context.Context, developerID uuid.UUID) ([]*ExternalIdentity, error)
+	RecordLogin(ctx context.Context, id uuid.UUID, email string) error
+	Delete(ctx context.Context, id uuid.UUID, developerID uuid.UUID) error
+
+	SaveLogin(ctx context.Context, login *FederatedLogin) error
+	ConsumeLogin(ctx context.Context, stateHash string, provider string) (*FederatedLogin, error) // single use, ErrFederatedLoginNotFound once expired
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrMFANotEnrolled      = errors.New("two-factor authentication is not enrolled")
+	ErrMFAAlreadyEnabled   = errors.New("two-factor authentication is already enabled")
+	ErrInvalidMFACode      = errors.New("invalid authentication code")
+	ErrInvalidMFAChallenge = errors.New("invalid or expired mfa token")
+	ErrMFAUnavailable      = errors.New("two-factor authentication is not configured")
+	ErrMFALocked           = errors.New("too many failed authentication codes, try again later")
+)
+
+// MFA is a developer's TOTP enrollment. It is pending until EnabledAt is set.
+type MFA struct {
+	DeveloperID     uuid.UUID
+	SecretEncrypted string
+	EnabledAt       *time.Time
+	LastUsedStep    *int64
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func (m *MFA) Enabled() bool {
+	return m.EnabledAt != nil
+}
+
+// MFAChallenge is the server side state of an MFA token, whose jti is the ID
+type MFAChallenge struct {
+	ID          uuid.UUID
+	DeveloperID uuid.UUID
+	Attempts    int
+	UsedAt      *time.Time
+	ExpiresAt   time.Time
+	CreatedAt   time.Time
+}
+
+// Repository interface for MFA entity
+type MFARepository interface {
+	SavePending(ctx context.Context, developerID uuid.UUID, secretEncrypted string) error // fails with ErrMFAAlreadyEnabled once confirmed
+	Get(ctx context.Context, developerID uuid.UUID) (*MFA, error)
+	Enable(ctx context.Context, developerID uuid.UUID, step int64, recoveryCodeHashes []string) error
+	UseStep(ctx context.Context, developerID uuid.UUID, step int64) error // rejects steps at or before the last used one
+	UseRecoveryCode(ctx context.Context, developerID uuid.UUID, codeHash string) error
+	Delete(ctx context.Context, developerID uuid.UUID) error
+	// AttemptCode counts an attempt against the developer's failure budget and
+	// locks further codes for lockout once maxAttempts is reached; ErrMFALocked while locked
+	AttemptCode(ctx context.Context, developerID uuid.UUID, maxAttempts int, lockout time.Duration) error
+	ResetAttempts(ctx context.Context, developerID uuid.UUID) error
+
+	CreateChallenge(ctx context.Context, challenge *MFAChallenge) error
+	// AttemptChallenge counts an attempt against an open challenge; ErrInvalidMFAChallenge once used, expired or out of attempts
+	AttemptChallenge(ctx context.Context, id uuid.UUID, maxAttempts int) (*MFAChallenge, error)
+	ConsumeChallenge(ctx context.Context, id uuid.UUID) error // single use, ErrInvalidMFAChallenge when already used
+}
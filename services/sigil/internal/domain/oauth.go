@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OAuth client identifiers and secrets are recognisable by their prefixes
+const (
+	OAuthClientIDPrefix     = "sgo_"
+	OAuthClientSecretPrefix = "sgos_"
+)
+
+// CodeChallengeS256 is the only PKCE method accepted (RFC 7636 4.2)
+const CodeChallengeS256 = "S256"
+
+var (
+	ErrOAuthClientNotFound   = errors.New("oauth client not found")
+	ErrInvalidRedirectURI    = errors.New("invalid redirect uri")
+	ErrUnsupportedResponse   = errors.New("unsupported response type")
+	ErrInvalidCodeChallenge  = errors.New("a S256 code challenge is required")
+	ErrInvalidGrant          = errors.New("invalid grant")
+	ErrAuthorizationCodeUsed = errors.New("authorization code already used")
+	ErrInvalidNonce          = errors.New("nonce is too long")
+)
+
+// OAuthClient is a third-party application registered by a developer.
+// Public clients such as SPAs and editor plugins have no secret.
+type OAuthClient struct {
+	ID           uuid.UUID
+	ClientID     string
+	Name         string
+	SecretHash   *string
+	RedirectURIs []string
+	CreatedBy    *uuid.UUID
+	RevokedAt    *time.Time
+	CreatedAt    time.Time
+}
+
+// Confidential reports whether the client must authenticate with a secret
+func (c *OAuthClient) Confidential() bool {
+	return c.SecretHash != nil
+}
+
+// AllowsRedirect compares against the registered URIs exactly (RFC 9700 4.1.3)
+func (c *OAuthClient) AllowsRedirect(uri string) bool {
+	return slices.Contains(c.RedirectURIs, uri)
+}
+
+// ValidRedirectURI accepts absolute URIs without a fragment. Plain http is
+// limited to loopback hosts; custom schemes are allowed for native apps.
+func ValidRedirectURI(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" || u.Fragment != "" || len(raw) > 2048 {
+		return false
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "https":
+		return u.Host != ""
+	case "http":
+		host := u.Hostname()
+		return host == "localhost" || host == "127.0.0.1" || host == "::1"
+	case "javascript", "data", "file", "vbscript":
+		return false
+	}
+	return true
+}
+
+// AuthorizationRequest holds the parameters of /oauth/authorize
+type AuthorizationRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scopes              []string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	Nonce               string // OpenID Connect; echoed in the ID token
+}
+
+// AuthorizationCode is a hashed, single-use code bound to its client,
+// redirect URI and PKCE challenge
+type AuthorizationCode struct {
+	ID                  uuid.UUID
+	CodeHash            string
+	OAuthClientID       uuid.UUID
+	DeveloperID         uuid.UUID
+	RedirectURI         string
+	Scopes              []string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	Nonce               *string
+	ExpiresAt           time.Time
+	UsedAt              *time.Time
+	SessionID           *uuid.UUID // session the code was exchanged for
+	CreatedAt           time.Time
+}
+
+// Repository interface for OAuthClient entity, consents and authorization codes
+type OAuthRepository interface {
+	CreateClient(ctx context.Context, client *OAuthClient) error
+	GetClientByClientID(ctx context.Context, clientID string) (*OAuthClient, error)
+	GetClientByID(ctx context.Context, id uuid.UUID) (*OAuthClient, error)
+	ListClientsByDeveloper(ctx context.Context, developerID uuid.UUID) ([]*OAuthClient, error) // active clients
+	RevokeClient(ctx context.Context, id uuid.UUID, developerID uuid.UUID) error
+	CountActiveClients(ctx context.Context) (int, error)
+
+	GetConsent(ctx context.Context, developerID uuid.UUID, clientID uuid.UUID) ([]string, error)       // nil without consent
+	SaveConsent(ctx context.Context, developerID uuid.UUID, clientID uuid.UUID, scopes []string) error // adds to earlier consent
+
+	CreateCode(ctx context.Context, code *AuthorizationCode) error
+	ConsumeCode(ctx context.Context, codeHash string) (*AuthorizationCode, error) // ErrAuthorizationCodeUsed, with the code, when already used
+	SetCodeSession(ctx context.Context, id uuid.UUID, sessionID uuid.UUID) error
+}
@@ -0,0 +1,27 @@
+package domain
+
+import "github.com/google/uuid"
+
+// UserInfo holds the standard claims a developer releases to a client
+// (OIDC Core 5.1). Empty fields were not covered by the granted scopes.
+type UserInfo struct {
+	Subject       uuid.UUID
+	Email         string
+	EmailVerified *bool
+	Name          string
+}
+
+// NewUserInfo derives the claims of dev released under scopes: email and
+// email_verified need the email scope, name needs profile
+func NewUserInfo(dev *Developer, scopes []string) *UserInfo {
+	info := &UserInfo{Subject: dev.ID}
+	if HasScope(scopes, ScopeEmail) {
+		verified := dev.EmailVerified
+		info.Email = dev.Email
+		info.EmailVerified = &verified
+	}
+	if HasScope(scopes, ScopeProfile) && dev.FullName != nil {
+		info.Name = *dev.FullName
+	}
+	return info
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TokenPurpose string
+
+const (
+	PurposeEmailVerification TokenPurpose = "email_verification"
+	PurposePasswordReset     TokenPurpose = "password_reset"
+	PurposeFederatedLogin    TokenPurpose = "federated_login"
+)
+
+var (
+	ErrInvalidOneTimeToken  = errors.New("invalid or expired token")
+	ErrTooManyRequests      = errors.New("please wait before requesting another email")
+	ErrEmailAlreadyVerified = errors.New("email already verified")
+)
+
+// OneTimeToken is a hashed, expiring, single-use token delivered by email
+type OneTimeToken struct {
+	ID          uuid.UUID
+	DeveloperID uuid.UUID
+	Purpose     TokenPurpose
+	TokenHash   string
+	ExpiresAt   time.Time
+	UsedAt      *time.Time
+	CreatedAt   time.Time
+}
+
+// Repository interface for OneTimeToken entity
+type OneTimeTokenRepository interface {
+	Create(ctx context.Context, token *OneTimeToken) error
+	Consume(ctx context.Context, tokenHash string, purpose TokenPurpose) (*OneTimeToken, error) // marks a valid token used, ErrInvalidOneTimeToken otherwise
+	LatestCreatedAt(ctx context.Context, developerID uuid.UUID, purpose TokenPurpose) (*time.Time, error)
+	InvalidateAll(ctx context.Context, developerID uuid.UUID, purpose TokenPurpose) error
+}
@@ -0,0 +1,142 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OrgRole string
+
+const (
+	OrgRoleOwner   OrgRole = "owner"
+	OrgRoleAdmin   OrgRole = "admin"
+	OrgRoleMember  OrgRole = "member"
+	OrgRoleBilling OrgRole = "billing"
+)
+
+var (
+	ErrOrganizationNotFound  = errors.New("organization not found")
+	ErrOrganizationSlugTaken = errors.New("organization slug already taken")
+	ErrInvalidOrganization   = errors.New("organization name and a slug of lowercase letters, digits and dashes are required")
+	ErrInvalidOrgRole        = errors.New("invalid organization role")
+	ErrNotOrganizationMember = errors.New("not a member of this organization")
+	ErrMemberNotFound        = errors.New("member not found")
+	ErrAlreadyMember         = errors.New("developer is already a member of this organization")
+	ErrOwnerCannotLeave      = errors.New("the owner must transfer ownership before leaving")
+	ErrInvitationNotFound    = errors.New("invitation not found")
+	ErrInvalidInvitation     = errors.New("invalid or expired invitation")
+	ErrInvitationMismatch    = errors.New("invitation was sent to a different email address")
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// ValidSlug reports whether slug is usable in URLs and as an identifier
+func ValidSlug(slug string) bool {
+	return slugPattern.MatchString(slug)
+}
+
+// Billing members see plans and invoices but cannot manage other members
+var orgRoleRanks = map[OrgRole]int{
+	OrgRoleMember:  0,
+	OrgRoleBilling: 1,
+	OrgRoleAdmin:   2,
+	OrgRoleOwner:   3,
+}
+
+// Valid reports whether r is a known organization role
+func (r OrgRole) Valid() bool {
+	_, ok := orgRoleRanks[r]
+	return ok
+}
+
+// AtLeast reports whether r ranks the same as or above other
+func (r OrgRole) AtLeast(other OrgRole) bool {
+	return orgRoleRanks[r] >= orgRoleRanks[other]
+}
+
+// CanManageMembers reports whether r may invite, remove and re-role members
+func (r OrgRole) CanManageMembers() bool {
+	return r.AtLeast(OrgRoleAdmin)
+}
+
+// CanManageProjects reports whether r may create and change the organization's projects
+func (r OrgRole) CanManageProjects() bool {
+	return r.AtLeast(OrgRoleAdmin)
+}
+
+// CanManageBilling reports whether r may change the organization's plan
+func (r OrgRole) CanManageBilling() bool {
+	return r == OrgRoleOwner || r == OrgRoleBilling
+}
+
+// CanManage reports whether r may change or remove a member holding target.
+// The owner manages everyone; admins only manage lower roles.
+func (r OrgRole) CanManage(target OrgRole) bool {
+	if !r.CanManageMembers() {
+		return false
+	}
+	return r == OrgRoleOwner || orgRoleRanks[r] > orgRoleRanks[target]
+}
+
+type Organization struct {
+	ID        uuid.UUID
+	Name      string
+	Slug      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// OrganizationMembership is an organization as seen by one of its members
+type OrganizationMembership struct {
+	Organization *Organization
+	Role         OrgRole
+	JoinedAt     time.Time
+}
+
+type OrganizationMember struct {
+	OrganizationID uuid.UUID
+	DeveloperID    uuid.UUID
+	Email          string
+	FullName       *string
+	Role           OrgRole
+	JoinedAt       time.Time
+}
+
+// OrganizationInvitation is a hashed, expiring, single-use invitation sent by email
+type OrganizationInvitation struct {
+	ID             uuid.UUID
+	OrganizationID uuid.UUID
+	Email          string
+	Role           OrgRole
+	TokenHash      string
+	InvitedBy      *uuid.UUID
+	ExpiresAt      time.Time
+	AcceptedAt     *time.Time
+	RevokedAt      *time.Time
+	CreatedAt      time.Time
+}
+
+// Repository interface for Organization entity, its members and invitations
+type OrganizationRepository interface {
+	Create(ctx context.Context, org *Organization, ownerID uuid.UUID) error // the creator becomes the owner
+	GetByID(ctx context.Context, id uuid.UUID) (*Organization, error)
+	ListForDeveloper(ctx context.Context, developerID uuid.UUID) ([]*OrganizationMembership, error)
+	Rename(ctx context.Context, id uuid.UUID, name string) error
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	GetMemberRole(ctx context.Context, orgID uuid.UUID, developerID uuid.UUID) (OrgRole, error) // ErrNotOrganizationMember when absent
+	ListMembers(ctx context.Context, orgID uuid.UUID) ([]*OrganizationMember, error)
+	SetMemberRole(ctx context.Context, orgID uuid.UUID, developerID uuid.UUID, role OrgRole) error
+	RemoveMember(ctx context.Context, orgID uuid.UUID, developerID uuid.UUID) error
+	TransferOwnership(ctx context.Context, orgID uuid.UUID, fromID uuid.UUID, toID uuid.UUID) error // the previous owner becomes an admin
+
+	CreateInvitation(ctx context.Context, invitation *OrganizationInvitation) error // revokes earlier pending invitations for the same email
+	ListPendingInvitations(ctx context.Context, orgID uuid.UUID) ([]*OrganizationInvitation, error)
+	RevokeInvitation(ctx context.Context, id uuid.UUID, orgID uuid.UUID) error
+	GetPendingInvitation(ctx context.Context, tokenHash string) (*OrganizationInvitation, error) // ErrInvalidInvitation once used, revoked or expired
+	AcceptInvitation(ctx context.Context, id uuid.UUID, developerID uuid.UUID) error             // marks it accepted and adds the member atomically
+}
@@ -0,0 +1,150 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Built-in plan tiers; the catalog may define others
+const (
+	PlanFree       = "free"
+	PlanPro        = "pro"
+	PlanEnterprise = "enterprise"
+)
+
+// Unlimited marks an entitlement without a limit
+const Unlimited = -1
+
+type Entitlement string
+
+// Entitlements a plan can limit
+const (
+	EntitlementProjects          Entitlement = "max_projects"
+	EntitlementAPIKeys           Entitlement = "max_api_keys"
+	EntitlementOrgMembers        Entitlement = "max_org_members"
+	EntitlementRequestsPerMinute Entitlement = "requests_per_minute"
+)
+
+var (
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	ErrUnknownPlan   = errors.New("unknown plan tier")
+)
+
+// QuotaError reports which entitlement an operation would exceed.
+// It matches ErrQuotaExceeded with errors.Is.
+type QuotaError struct {
+	Plan        string
+	Entitlement Entitlement
+	Limit       int
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s: %s plan allows %d for %s", ErrQuotaExceeded, e.Plan, e.Limit, e.Entitlement)
+}
+
+func (e *QuotaError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// Plan is a tier of the catalog and what it entitles to
+type Plan struct {
+	Tier              string
+	Name              string
+	MaxProjects       int // personal projects, or an organization's projects
+	MaxAPIKeys        int
+	MaxOrgMembers     int // members plus pending invitations
+	RequestsPerMinute int
+}
+
+// Limit returns the plan's limit for an entitlement
+func (p Plan) Limit(entitlement Entitlement) int {
+	switch entitlement {
+	case EntitlementProjects:
+		return p.MaxProjects
+	case EntitlementAPIKeys:
+		return p.MaxAPIKeys
+	case EntitlementOrgMembers:
+		return p.MaxOrgMembers
+	case EntitlementRequestsPerMinute:
+		return p.RequestsPerMinute
+	}
+	return 0
+}
+
+// Allows reports whether usage may grow by one without exceeding the entitlement
+func (p Plan) Allows(entitlement Entitlement, used int) bool {
+	limit := p.Limit(entitlement)
+	return limit == Unlimited || used < limit
+}
+
+// PlanCatalog maps plan tiers to their plans
+type PlanCatalog map[string]Plan
+
+// DefaultPlanCatalog applies unless PLAN_CATALOG_FILE provides another
+var DefaultPlanCatalog = PlanCatalog{
+	PlanFree: {
+		Tier:              PlanFree,
+		Name:              "Free",
+		MaxProjects:       3,
+		MaxAPIKeys:        2,
+		MaxOrgMembers:     5,
+		RequestsPerMinute: 60,
+	},
+	PlanPro: {
+		Tier:              PlanPro,
+		Name:              "Pro",
+		MaxProjects:       25,
+		MaxAPIKeys:        20,
+		MaxOrgMembers:     25,
+		RequestsPerMinute: 600,
+	},
+	PlanEnterprise: {
+		Tier:              PlanEnterprise,
+		Name:              "Enterprise",
+		MaxProjects:       Unlimited,
+		MaxAPIKeys:        Unlimited,
+		MaxOrgMembers:     Unlimited,
+		RequestsPerMinute: 6000,
+	},
+}
+
+// Lookup returns the plan for a tier. Tiers missing from the catalog get
+// the free plan, so stale values never grant more than it.
+func (c PlanCatalog) Lookup(tier string) Plan {
+	if plan, ok := c[tier]; ok {
+		return plan
+	}
+	return c[PlanFree]
+}
+
+func (c PlanCatalog) Has(tier string) bool {
+	_, ok := c[tier]
+	return ok
+}
+
+// Usage counts what a developer or organization holds against its plan
+type Usage struct {
+	Plan       Plan
+	Projects   int
+	APIKeys    int
+	OrgMembers int
+}
+
+// Repository interface for usage counters
+type UsageRepository interface {
+	CountProjectsByDeveloper(ctx context.Context, developerID uuid.UUID) (int, error) // personal, unarchived projects
+	CountProjectsByOrganization(ctx context.Context, orgID uuid.UUID) (int, error)    // unarchived projects
+	CountActiveAPIKeys(ctx context.Context, developerID uuid.UUID) (int, error)
+	CountOrgSeats(ctx context.Context, orgID uuid.UUID, exceptEmail string) (int, error) // members plus pending invitations not addressed to exceptEmail
+	GetOrganizationPlanTier(ctx context.Context, orgID uuid.UUID) (string, error)        // the owner's plan tier
+}
+
+// RequestLimiter decides whether a developer may make another request now.
+// When not, it returns how long until the limit resets.
+type RequestLimiter interface {
+	Allow(ctx context.Context, developerID uuid.UUID) (bool, time.Duration)
+}
@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EnvironmentName string
+
+const (
+	EnvironmentDevelopment EnvironmentName = "development"
+	EnvironmentStaging     EnvironmentName = "staging"
+	EnvironmentProduction  EnvironmentName = "production"
+)
+
+// DefaultEnvironments are created with every new project
+var DefaultEnvironments = []EnvironmentName{EnvironmentDevelopment, EnvironmentStaging, EnvironmentProduction}
+
+// Valid reports whether n is a known environment
+func (n EnvironmentName) Valid() bool {
+	switch n {
+	case EnvironmentDevelopment, EnvironmentStaging, EnvironmentProduction:
+		return true
+	}
+	return false
+}
+
+var (
+	ErrProjectNotFound     = errors.New("project not found")
+	ErrProjectSlugTaken    = errors.New("project slug already taken")
+	ErrInvalidProject      = errors.New("project name and a slug of lowercase letters, digits and dashes are required")
+	ErrProjectArchived     = errors.New("project is archived")
+	ErrEnvironmentNotFound = errors.New("environment not found")
+	ErrEnvironmentExists   = errors.New("environment already exists")
+	ErrInvalidEnvironment  = errors.New("environment must be development, staging or production")
+)
+
+// Project is a game built on DIAGON. It belongs to either a developer or an
+// organization, never both, and anchors credentials and game resources.
+type Project struct {
+	ID                  uuid.UUID
+	Name                string
+	Slug                string
+	OwnerDeveloperID    *uuid.UUID
+	OwnerOrganizationID *uuid.UUID
+	ArchivedAt          *time.Time
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+// Environment is one deployment stage of a project
+type Environment struct {
+	ID        uuid.UUID
+	ProjectID uuid.UUID
+	Name      EnvironmentName
+	CreatedAt time.Time
+}
+
+type CreateProjectInput struct {
+	Name           string
+	Slug           string
+	OrganizationID *uuid.UUID // nil for a personal project
+}
+
+// Repository interface for Project entity and its environments
+type ProjectRepository interface {
+	Create(ctx context.Context, project *Project, environments []EnvironmentName) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Project, error)
+	ListByDeveloper(ctx context.Context, developerID uuid.UUID, includeArchived bool) ([]*Project, error)
+	ListByOrganization(ctx context.Context, orgID uuid.UUID, includeArchived bool) ([]*Project, error)
+	Rename(ctx context.Context, id uuid.UUID, name string) error
+	SetArchived(ctx context.Context, id uuid.UUID, archived bool) error
+
+	ListEnvironments(ctx context.Context, projectID uuid.UUID) ([]*Environment, error)
+	CreateEnvironment(ctx context.Context, env *Environment) error
+	DeleteEnvironment(ctx context.Context, id uuid.UUID, projectID uuid.UUID) error
+}
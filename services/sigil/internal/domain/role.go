@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+type Role string
+
+const (
+	RoleDeveloper  Role = "developer"
+	RoleSupport    Role = "support"
+	RoleAdmin      Role = "admin"
+	RoleSuperadmin Role = "superadmin"
+)
+
+var (
+	ErrForbidden   = errors.New("forbidden")
+	ErrInvalidRole = errors.New("invalid role")
+)
+
+var roleRanks = map[Role]int{
+	RoleDeveloper:  0,
+	RoleSupport:    1,
+	RoleAdmin:      2,
+	RoleSuperadmin: 3,
+}
+
+// Valid reports whether r is a known role
+func (r Role) Valid() bool {
+	_, ok := roleRanks[r]
+	return ok
+}
+
+// AtLeast reports whether r ranks the same as or above other
+func (r Role) AtLeast(other Role) bool {
+	return roleRanks[r] >= roleRanks[other]
+}
+
+// IsStaff reports whether r is one of the operator roles
+func (r Role) IsStaff() bool {
+	return r.AtLeast(RoleSupport)
+}
+
+// CanTrustClients reports whether r may create and manage service clients
+// holding TrustedClientScopes
+func (r Role) CanTrustClients() bool {
+	return r == RoleSuperadmin
+}
+
+// Actor is the authenticated developer an operation is performed on behalf of
+type Actor struct {
+	ID   uuid.UUID
+	Role Role
+}
+
+// IsSelf reports whether the actor is the developer with the given ID
+func (a Actor) IsSelf(id uuid.UUID) bool {
+	return a.ID == id
+}
+
+// CanManage reports whether the actor holds at least minRole and outranks a
+// developer with the target role. Superadmins may manage each other.
+func (a Actor) CanManage(target Role, minRole Role) bool {
+	if !a.Role.AtLeast(minRole) {
+		return false
+	}
+	return a.Role == RoleSuperadmin || roleRanks[a.Role] > roleRanks[target]
+}
@@ -0,0 +1,96 @@
+package domain
+
+import "errors"
+
+// Scopes carried by access tokens and API keys
+const (
+	ScopeDevelopersRead  = "developers:read"
+	ScopeDevelopersWrite = "developers:write"
+	ScopeAdmin           = "admin"
+)
+
+// Scopes carried by service client tokens; they apply to the client's project
+const (
+	ScopeProjectRead  = "project:read"
+	ScopeProjectWrite = "project:write"
+)
+
+// Scopes letting a service client introspect and revoke any Sigil credential;
+// only superadmins may grant them
+const (
+	ScopeTokenIntrospect = "token:introspect"
+	ScopeTokenRevoke     = "token:revoke"
+)
+
+// OpenID Connect scopes; they select identity claims rather than grant API access
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+)
+
+var ErrInvalidScope = errors.New("invalid scope")
+
+// KnownScopes is the full scope vocabulary
+var KnownScopes = []string{ScopeDevelopersRead, ScopeDevelopersWrite, ScopeAdmin}
+
+// OIDCScopes may be requested by OAuth clients alongside KnownScopes
+var OIDCScopes = []string{ScopeOpenID, ScopeProfile, ScopeEmail}
+
+// DefaultScopes are granted to interactive developer logins
+var DefaultScopes = []string{ScopeDevelopersRead, ScopeDevelopersWrite}
+
+// ClientScopes is the vocabulary service clients may be granted
+var ClientScopes = []string{ScopeProjectRead, ScopeProjectWrite}
+
+// TrustedClientScopes may additionally be granted to service clients by a
+// superadmin. They reach superadmins' credentials too, which no other role
+// may manage.
+var TrustedClientScopes = []string{ScopeTokenIntrospect, ScopeTokenRevoke}
+
+// DefaultClientScopes are granted to service clients created without scopes
+var DefaultClientScopes = []string{ScopeProjectRead}
+
+// IsKnownScope reports whether scope is part of the vocabulary
+func IsKnownScope(scope string) bool {
+	for _, known := range KnownScopes {
+		if scope == known {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOIDCScope reports whether scope is an OpenID Connect scope
+func IsOIDCScope(scope string) bool {
+	return HasScope(OIDCScopes, scope)
+}
+
+// HasTrustedScope reports whether granted includes any TrustedClientScopes
+func HasTrustedScope(granted []string) bool {
+	for _, scope := range TrustedClientScopes {
+		if HasScope(granted, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScope reports whether granted includes scope
+func HasScope(granted []string, scope string) bool {
+	for _, s := range granted {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// GrantedScopes is everything a developer's credentials may carry; staff
+// roles additionally hold the admin scope
+func GrantedScopes(dev *Developer) []string {
+	if dev.Role.IsStaff() {
+		return append([]string{ScopeAdmin}, DefaultScopes...)
+	}
+	return DefaultScopes
+}
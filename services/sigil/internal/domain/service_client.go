@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Service client identifiers and secrets are recognisable by their prefixes
+const (
+	ServiceClientIDPrefix     = "sgc_"
+	ServiceClientSecretPrefix = "sgs_"
+)
+
+type PrincipalType string
+
+// Kinds of caller AuthMiddleware can authenticate
+const (
+	PrincipalDeveloper     PrincipalType = "developer"
+	PrincipalServiceClient PrincipalType = "service_client"
+)
+
+var (
+	ErrServiceClientNotFound    = errors.New("service client not found")
+	ErrInvalidClientCredentials = errors.New("invalid client credentials")
+)
+
+// ServiceClient is a machine identity of a project, such as a game server
+// or matchmaker, that obtains tokens with the client_credentials grant
+type ServiceClient struct {
+	ID         uuid.UUID
+	ProjectID  uuid.UUID
+	Name       string
+	ClientID   string
+	Scopes     []string
+	CreatedBy  *uuid.UUID
+	RevokedAt  *time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+	Secrets    []*ServiceClientSecret // active secrets, newest first
+}
+
+// ServiceClientSecret is one hashed secret of a client. ExpiresAt is set once
+// a rotation replaces it, leaving an overlap for deployments to catch up.
+type ServiceClientSecret struct {
+	ID              uuid.UUID
+	ServiceClientID uuid.UUID
+	Prefix          string
+	SecretHash      string
+	ExpiresAt       *time.Time
+	CreatedAt       time.Time
+}
+
+// Repository interface for ServiceClient entity and its secrets
+type ServiceClientRepository interface {
+	Create(ctx context.Context, client *ServiceClient, secret *ServiceClientSecret) error
+	ListByProject(ctx context.Context, projectID uuid.UUID) ([]*ServiceClient, error) // active clients with their active secrets
+	GetByID(ctx context.Context, id uuid.UUID, projectID uuid.UUID) (*ServiceClient, error)
+	GetByClientID(ctx context.Context, clientID string) (*ServiceClient, error)
+	Revoke(ctx context.Context, id uuid.UUID, projectID uuid.UUID) error
+	RotateSecret(ctx context.Context, id uuid.UUID, projectID uuid.UUID, secret *ServiceClientSecret, retireAt time.Time) error // current secrets expire at retireAt
+	MatchSecret(ctx context.Context, id uuid.UUID, secretHash string) (bool, error)                                             // only active secrets match
+	Touch(ctx context.Context, id uuid.UUID) error
+}
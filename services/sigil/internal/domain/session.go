@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionRevoked  = errors.New("session has been revoked")
+)
+
+// Session is a single signed-in device. Its refresh tokens share the session
+// ID and access tokens carry it as the sid claim.
+type Session struct {
+	ID             uuid.UUID
+	DeveloperID    uuid.UUID
+	OrganizationID *uuid.UUID // active organization context, nil for personal
+	OAuthClientID  *uuid.UUID // client the session was authorized for, nil for first-party logins
+	Scopes         []string   // consented scopes of OAuth sessions, nil for all the developer holds
+	DeviceName     *string
+	IPAddress      string
+	UserAgent      string
+	ExpiresAt      time.Time
+	RevokedAt      *time.Time
+	CreatedAt      time.Time
+	LastUsedAt     time.Time
+}
+
+// ClientInfo describes the device a session is created or used from
+type ClientInfo struct {
+	DeviceName *string
+	IPAddress  string
+	UserAgent  string
+}
+
+// Repository interface for Session entity
+type SessionRepository interface {
+	Create(ctx context.Context, session *Session) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Session, error)
+	ListActive(ctx context.Context, developerID uuid.UUID) ([]*Session, error)
+	Touch(ctx context.Context, id uuid.UUID, ipAddress string, expiresAt time.Time) error
+	Revoke(ctx context.Context, id uuid.UUID, developerID uuid.UUID) error
+	SetOrganization(ctx context.Context, id uuid.UUID, developerID uuid.UUID, orgID *uuid.UUID) error
+	ClearOrganization(ctx context.Context, developerID uuid.UUID, orgID uuid.UUID) error               // drops the context from the developer's sessions in orgID
+	RevokeAllExcept(ctx context.Context, developerID uuid.UUID, keepID uuid.UUID) ([]uuid.UUID, error) // uuid.Nil keeps none
+	ListRevokedSince(ctx context.Context, since time.Time) ([]uuid.UUID, error)
+}
+
+// SessionRevocationChecker answers whether access tokens of a session must be rejected
+type SessionRevocationChecker interface {
+	IsRevoked(sessionID uuid.UUID) bool
+}
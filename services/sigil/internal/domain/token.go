@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrExpiredRefreshToken = errors.New("refresh token has expired")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+
+	ErrUnsupportedTokenType = errors.New("token type cannot be revoked")
+)
+
+// Kinds of token /oauth/introspect and /oauth/revoke accept; they double as
+// token_type_hint values (RFC 7009 2.1)
+const (
+	TokenKindAccess  = "access_token"
+	TokenKindRefresh = "refresh_token"
+	TokenKindAPIKey  = "api_key"
+)
+
+// TokenIntrospection describes a presented token (RFC 7662 2.2). Inactive
+// tokens carry nothing else.
+type TokenIntrospection struct {
+	Active        bool
+	Kind          string
+	PrincipalType PrincipalType
+	Subject       string
+	ClientID      string // OAuth client or service client the token was issued to
+	Username      string
+	Scopes        []string
+	SessionID     *uuid.UUID
+	OrgID         *uuid.UUID
+	ProjectID     *uuid.UUID
+	ExpiresAt     *time.Time
+	IssuedAt      *time.Time
+}
+
+// RefreshToken is a persisted, single-use refresh token. Every token issued
+// from the same login shares a SessionID so that reuse of a consumed token can
+// revoke the whole chain.
+type RefreshToken struct {
+	ID          uuid.UUID
+	DeveloperID uuid.UUID
+	SessionID   uuid.UUID
+	TokenHash   string
+	ExpiresAt   time.Time
+	ConsumedAt  *time.Time
+	RevokedAt   *time.Time
+	CreatedAt   time.Time
+}
+
+// Repository interface for RefreshToken entity
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *RefreshToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*RefreshToken, error)
+	Rotate(ctx context.Context, consumedID uuid.UUID, next *RefreshToken) error // consumes the old token and stores the next one atomically
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	CeremonyRegistration = "registration"
+	CeremonyLogin        = "login"
+)
+
+var (
+	ErrCredentialNotFound = errors.New("credential not found")
+	ErrCredentialExists   = errors.New("credential already registered")
+	ErrChallengeNotFound  = errors.New("webauthn challenge not found or expired")
+	ErrCredentialCloned   = errors.New("authenticator sign count did not increase")
+	ErrWebAuthnFailed     = errors.New("webauthn verification failed")
+)
+
+// WebAuthnCredential is a registered passkey or security key. Data holds the
+// verified credential (public key, flags, sign count) as stored by the library.
+type WebAuthnCredential struct {
+	ID           uuid.UUID
+	DeveloperID  uuid.UUID
+	Name         string
+	CredentialID []byte
+	Data         []byte
+	CreatedAt    time.Time
+	LastUsedAt   *time.Time
+}
+
+// WebAuthnChallenge is the server side state of an in-flight ceremony
+type WebAuthnChallenge struct {
+	ID          uuid.UUID
+	DeveloperID *uuid.UUID
+	Ceremony    string
+	SessionData []byte
+	ExpiresAt   time.Time
+}
+
+// Repository interface for WebAuthn credentials and ceremonies
+type WebAuthnRepository interface {
+	CreateCredential(ctx context.Context, credential *WebAuthnCredential) error
+	ListCredentials(ctx context.Context, developerID uuid.UUID) ([]*WebAuthnCredential, error)
+	GetCredential(ctx context.Context, credentialID []byte) (*WebAuthnCredential, error)
+	UpdateCredentialData(ctx context.Context, id uuid.UUID, data []byte) error
+	DeleteCredential(ctx context.Context, id uuid.UUID, developerID uuid.UUID) error
+	SaveChallenge(ctx context.Context, challenge *WebAuthnChallenge) error
+	ConsumeChallenge(ctx context.Context, id uuid.UUID, ceremony string) (*WebAuthnChallenge, error) // single use, ErrChallengeNotFound once expired
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+	"github.com/vivek-344/diagon/sigil/internal/middleware"
+	"github.com/vivek-344/diagon/sigil/internal/service"
+	"github.com/vivek-344/diagon/sigil/utils"
+)
+
+type APIKeyHandler struct {
+	apiKeySvc *service.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeySvc *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{apiKeySvc: apiKeySvc}
+}
+
+type createAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	Scopes    []string   `json:"scopes"`
+}
+
+type apiKeyResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP *string    `json:"last_used_ip,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type createAPIKeyResponse struct {
+	apiKeyResponse
+	Key string `json:"key"`
+}
+
+func newAPIKeyResponse(key *domain.APIKey) apiKeyResponse {
+	return apiKeyResponse{
+		ID:         key.ID.String(),
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		LastUsedIP: key.LastUsedIP,
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+// Create mints an API key; the key is only ever returned in this response
+func (h *APIKeyHandler) Create(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := h.interactiveDeveloper(w, r)
+	if !ok {
+		return
+	}
+
+	var req createAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	callerScopes := middleware.GetScopesFromContext(r.Context())
+	rawKey, key, err := h.apiKeySvc.Create(r.Context(), developerID, req.Name, req.ExpiresAt, req.Scopes, callerScopes)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidInput):
+			utils.RespondError(w, "name is required and expires_at must be in the future", http.StatusBadRequest)
+			return
+		case errors.Is(err, domain.ErrInvalidScope):
+			utils.RespondError(w, "unknown scope or scope not held by your credential", http.StatusBadRequest)
+			return
+		case errors.Is(err, domain.ErrNotFound):
+			utils.RespondError(w, "developer not found", http.StatusNotFound)
+			return
+		case respondQuotaError(w, err):
+			return
+		}
+		slog.Error("failed to create api key", "error", err)
+		utils.RespondError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	utils.RespondSuccess(w, createAPIKeyResponse{
+		apiKeyResponse: newAPIKeyResponse(key),
+		Key:            rawKey,
+	}, http.StatusCreated)
+}
+
+// List returns the developer's active API keys without their secrets
+func (h *APIKeyHandler) List(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := h.interactiveDeveloper(w, r)
+	if !ok {
+		return
+	}
+
+	keys, err := h.apiKeySvc.List(r.Context(), developerID)
+	if err != nil {
+		slog.Error("failed to list api keys", "error", err)
+		utils.RespondError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]apiKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		resp = append(resp, newAPIKeyResponse(key))
+	}
+
+	utils.RespondSuccess(w, resp, http.StatusOK)
+}
+
+// Revoke disables an API key immediately
+func (h *APIKeyHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := h.interactiveDeveloper(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondError(w, "invalid api key id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.apiKeySvc.Revoke(r.Context(), developerID, id); err != nil {
+		if errors.Is(err, domain.ErrAPIKeyNotFound) {
+			utils.RespondError(w, "api key not found", http.StatusNotFound)
+			return
+		}
+		slog.Error("failed to revoke api key", "error", err)
+		utils.RespondError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// interactiveDeveloper only admits first-party sessions, so a leaked key or
+// delegated token cannot be used to mint longer-lived keys
+func (h *APIKeyHandler) interactiveDeveloper(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return uuid.Nil, false
+	}
+	if !middleware.IsFirstPartySession(r.Context()) {
+		utils.RespondError(w, "api keys are managed from a first-party session", http.StatusForbidden)
+		return uuid.Nil, false
+	}
+	return developerID, true
+}
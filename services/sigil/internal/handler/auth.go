@@ -14,19 +14,22 @@ import (
 
 type AuthHandler struct {
 	developerSvc *service.DeveloperService
-	jwtSecret    string
+	authSvc      *service.AuthService
+	mfaSvc       *service.MFAService
 }
 
-func NewAuthHandler(developerSvc *service.DeveloperService, jwtSecret string) *AuthHandler {
+func NewAuthHandler(developerSvc *service.DeveloperService, authSvc *service.AuthService, mfaSvc *service.MFAService) *AuthHandler {
 	return &AuthHandler{
 		developerSvc: developerSvc,
-		jwtSecret:    jwtSecret,
+		authSvc:      authSvc,
+		mfaSvc:       mfaSvc,
 	}
 }
 
 type loginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email      string  `json:"email"`
+	Password   string  `json:"password"`
+	DeviceName *string `json:"device_name,omitempty"`
 }
 
 type loginResponse struct {
@@ -38,6 +41,29 @@ type loginResponse struct {
 	} `json:"developer"`
 }
 
+// userInfoResponse holds the standard claims of OIDC Core 5.3.2
+type userInfoResponse struct {
+	Sub           string `json:"sub"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+	Name          string `json:"name,omitempty"`
+}
+
+type mfaChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+}
+
+func newLoginResponse(dev *domain.Developer, tokens *utils.TokenPair) loginResponse {
+	resp := loginResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}
+	resp.Developer.ID = dev.ID.String()
+	resp.Developer.Email = dev.Email
+	return resp
+}
+
 // Login authenticates a developer and returns JWT tokens
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var req loginRequest
@@ -73,8 +99,29 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Require the second factor before issuing tokens
+	mfaEnabled, err := h.mfaSvc.IsEnabled(r.Context(), dev.ID)
+	if err != nil {
+		slog.Error("failed to check mfa", "error", err)
+		utils.RespondError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if mfaEnabled {
+		mfaToken, err := h.mfaSvc.StartChallenge(r.Context(), dev)
+		if err != nil {
+			slog.Error("failed to start mfa challenge", "error", err)
+			utils.RespondError(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		utils.RespondSuccess(w, mfaChallengeResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		}, http.StatusOK)
+		return
+	}
+
 	// Generate JWT tokens
-	tokens, err := utils.GenerateTokenPair(dev.ID, dev.Email, h.jwtSecret)
+	tokens, err := h.authSvc.IssueTokens(r.Context(), dev, clientInfo(r, req.DeviceName))
 	if err != nil {
 		slog.Error("failed to generate tokens", "error", err)
 		utils.RespondError(w, "internal server error", http.StatusInternalServerError)
@@ -86,15 +133,7 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		slog.Warn("failed to update last login", "error", err)
 	}
 
-	// Prepare response
-	resp := loginResponse{
-		AccessToken:  tokens.AccessToken,
-		RefreshToken: tokens.RefreshToken,
-	}
-	resp.Developer.ID = dev.ID.String()
-	resp.Developer.Email = dev.Email
-
-	utils.RespondSuccess(w, resp, http.StatusOK)
+	utils.RespondSuccess(w, newLoginResponse(dev, tokens), http.StatusOK)
 }
 
 type refreshRequest struct {
@@ -115,25 +154,49 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate refresh token
-	claims, err := utils.ValidateToken(req.RefreshToken, h.jwtSecret)
+	// Rotate refresh token
+	tokens, err := h.authSvc.Refresh(r.Context(), req.RefreshToken, clientInfo(r, nil))
 	if err != nil {
-		if errors.Is(err, utils.ErrExpiredToken) {
+		switch {
+		case errors.Is(err, domain.ErrExpiredRefreshToken):
 			slog.Debug("refresh token expired", "error", err)
 			utils.RespondError(w, "refresh token expired", http.StatusUnauthorized)
-			return
+		case errors.Is(err, domain.ErrRefreshTokenReused):
+			utils.RespondError(w, "refresh token has been revoked", http.StatusUnauthorized)
+		case errors.Is(err, domain.ErrInvalidRefreshToken):
+			slog.Debug("invalid refresh token", "error", err)
+			utils.RespondError(w, "invalid refresh token", http.StatusUnauthorized)
+		case errors.Is(err, domain.ErrNotFound):
+			utils.RespondError(w, "developer not found", http.StatusUnauthorized)
+		case errors.Is(err, domain.ErrAccountSuspended):
+			utils.RespondError(w, "account suspended", http.StatusForbidden)
+		default:
+			slog.Error("failed to refresh tokens", "error", err)
+			utils.RespondError(w, "internal server error", http.StatusInternalServerError)
 		}
-		slog.Debug("invalid refresh token", "error", err)
-		utils.RespondError(w, "invalid refresh token", http.StatusUnauthorized)
 		return
 	}
 
-	// Verify developer still exists and is active
-	dev, err := h.developerSvc.GetByID(r.Context(), claims.DeveloperID)
+	utils.RespondSuccess(w, refreshResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}, http.StatusOK)
+}
+
+// GetProfile returns the authenticated developer's profile
+func (h *AuthHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	// This would be called on a protected route
+	// Developer ID is extracted from context by middleware
+	actor, ok := middleware.GetActorFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	dev, err := h.developerSvc.GetByID(r.Context(), actor, actor.ID)
 	if err != nil {
 		if errors.Is(err, domain.ErrNotFound) {
-			slog.Debug("developer not found", "developer_id", claims.DeveloperID)
-			utils.RespondError(w, "developer not found", http.StatusUnauthorized)
+			utils.RespondError(w, "developer not found", http.StatusNotFound)
 			return
 		}
 		slog.Error("failed to fetch developer", "error", err)
@@ -141,36 +204,22 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if dev.Status == domain.StatusSuspended {
-		utils.RespondError(w, "account suspended", http.StatusForbidden)
+	if notModified(w, r, dev.Version) {
 		return
 	}
-
-	// Generate new token pair
-	tokens, err := utils.GenerateTokenPair(dev.ID, dev.Email, h.jwtSecret)
-	if err != nil {
-		slog.Error("failed to generate tokens", "error", err)
-		utils.RespondError(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	utils.RespondSuccess(w, refreshResponse{
-		AccessToken:  tokens.AccessToken,
-		RefreshToken: tokens.RefreshToken,
-	}, http.StatusOK)
+	utils.RespondSuccess(w, newDeveloperResponse(dev), http.StatusOK)
 }
 
-// GetProfile returns the authenticated developer's profile
-func (h *AuthHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
-	// This would be called on a protected route
-	// Developer ID is extracted from context by middleware
-	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+// UserInfo is the OpenID Connect userinfo endpoint. It returns the profile
+// as standard claims, limited by the token's email and profile scopes.
+func (h *AuthHandler) UserInfo(w http.ResponseWriter, r *http.Request) {
+	actor, ok := middleware.GetActorFromContext(r.Context())
 	if !ok {
 		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	dev, err := h.developerSvc.GetByID(r.Context(), developerID)
+	dev, err := h.developerSvc.GetByID(r.Context(), actor, actor.ID)
 	if err != nil {
 		if errors.Is(err, domain.ErrNotFound) {
 			utils.RespondError(w, "developer not found", http.StatusNotFound)
@@ -181,8 +230,12 @@ func (h *AuthHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Don't send password hash
-	dev.PasswordHash = ""
-
-	utils.RespondSuccess(w, dev, http.StatusOK)
+	info := domain.NewUserInfo(dev, middleware.GetScopesFromContext(r.Context()))
+	w.Header().Set("Cache-Control", "no-store")
+	utils.RespondSuccess(w, userInfoResponse{
+		Sub:           info.Subject.String(),
+		Email:         info.Email,
+		EmailVerified: info.EmailVerified,
+		Name:          info.Name,
+	}, http.StatusOK)
 }
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+)
+
+// Cursors are opaque to clients: base64url of a small JSON document holding
+// the sort order and the sort key and ID of the last developer on the page
+type developerCursorPayload struct {
+	Sort      domain.DeveloperSort `json:"s"`
+	Ascending bool                 `json:"a,omitempty"`
+	Key       string               `json:"k"`
+	ID        uuid.UUID            `json:"i"`
+}
+
+func encodeDeveloperCursor(cursor *domain.DeveloperCursor) string {
+	payload := developerCursorPayload{
+		Sort:      cursor.Sort,
+		Ascending: cursor.Ascending,
+		ID:        cursor.ID,
+	}
+	switch key := cursor.Key.(type) {
+	case time.Time:
+		payload.Key = key.UTC().Format(time.RFC3339Nano)
+	case string:
+		payload.Key = key
+	}
+
+	raw, _ := json.Marshal(payload)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeDeveloperCursor(encoded string) (*domain.DeveloperCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, domain.ErrInvalidCursor
+	}
+
+	var payload developerCursorPayload
+	if err := json.Unmarshal(raw, &payload); err != nil || !payload.Sort.Valid() {
+		return nil, domain.ErrInvalidCursor
+	}
+
+	cursor := &domain.DeveloperCursor{
+		Sort:      payload.Sort,
+		Ascending: payload.Ascending,
+		ID:        payload.ID,
+	}
+	if payload.Sort == domain.SortByEmail {
+		cursor.Key = payload.Key
+	} else {
+		key, err := time.Parse(time.RFC3339Nano, payload.Key)
+		if err != nil {
+			return nil, domain.ErrInvalidCursor
+		}
+		cursor.Key = key
+	}
+
+	return cursor, nil
+}
@@ -3,20 +3,88 @@ package handler
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 
 	"github.com/vivek-344/diagon/sigil/internal/domain"
+	"github.com/vivek-344/diagon/sigil/internal/middleware"
 	"github.com/vivek-344/diagon/sigil/internal/service"
 	"github.com/vivek-344/diagon/sigil/utils"
 )
 
 type DeveloperHandler struct {
-	svc *service.DeveloperService
+	svc              *service.DeveloperService
+	authSvc          *service.AuthService
+	verificationSvc  *service.VerificationService
+	passwordResetSvc *service.PasswordResetService
+}
+
+func NewDeveloperHandler(
+	svc *service.DeveloperService,
+	authSvc *service.AuthService,
+	verificationSvc *service.VerificationService,
+	passwordResetSvc *service.PasswordResetService,
+) *DeveloperHandler {
+	return &DeveloperHandler{
+		svc:              svc,
+		authSvc:          authSvc,
+		verificationSvc:  verificationSvc,
+		passwordResetSvc: passwordResetSvc,
+	}
 }
 
-func NewDeveloperHandler(svc *service.DeveloperService) *DeveloperHandler {
-	return &DeveloperHandler{svc: svc}
+const maxPageSize = 100
+
+// developerResponse is the public shape of a developer; it never includes the password hash
+type developerResponse struct {
+	ID            string         `json:"id"`
+	Email         string         `json:"email"`
+	FullName      *string        `json:"full_name"`
+	CompanyName   *string        `json:"company_name"`
+	Status        domain.Status  `json:"status"`
+	Role          domain.Role    `json:"role"`
+	EmailVerified bool           `json:"email_verified"`
+	PlanTier      string         `json:"plan_tier"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	LastLoginAt   *time.Time     `json:"last_login_at"`
+	Metadata      map[string]any `json:"metadata"`
+}
+
+func newDeveloperResponse(dev *domain.Developer) developerResponse {
+	return developerResponse{
+		ID:            dev.ID.String(),
+		Email:         dev.Email,
+		FullName:      dev.FullName,
+		CompanyName:   dev.CompanyName,
+		Status:        dev.Status,
+		Role:          dev.Role,
+		EmailVerified: dev.EmailVerified,
+		PlanTier:      dev.PlanTier,
+		CreatedAt:     dev.CreatedAt,
+		UpdatedAt:     dev.UpdatedAt,
+		LastLoginAt:   dev.LastLoginAt,
+		Metadata:      dev.Metadata,
+	}
+}
+
+type developerListResponse struct {
+	Developers []developerResponse `json:"developers"`
+	NextCursor *string             `json:"next_cursor"`
+	Total      *int                `json:"total,omitempty"`
+}
+
+type updatePasswordRequest struct {
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
 }
 
 type createRequest struct {
@@ -59,56 +127,543 @@ func (h *DeveloperHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The account is usable without verification, so a mail failure is not fatal
+	if err := h.verificationSvc.SendVerification(r.Context(), dev); err != nil {
+		slog.Warn("failed to send verification email", "developer_id", dev.ID, "error", err)
+	}
+
 	utils.RespondSuccess(w, createResponse{
 		ID:    dev.ID.String(),
 		Email: dev.Email,
 	}, http.StatusCreated)
 }
 
+type verifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
 func (h *DeveloperHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
-	utils.RespondError(w, "not implemented", http.StatusNotImplemented)
+	var req verifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		utils.RespondError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.verificationSvc.Verify(r.Context(), req.Token); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidOneTimeToken) || errors.Is(err, domain.ErrNotFound):
+			utils.RespondError(w, "invalid or expired token", http.StatusBadRequest)
+		default:
+			slog.Error("failed to verify email", "error", err)
+			utils.RespondError(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	utils.RespondSuccess(w, map[string]string{
+		"message": "email verified",
+	}, http.StatusOK)
 }
 
+func (h *DeveloperHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.verificationSvc.Resend(r.Context(), developerID); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrEmailAlreadyVerified):
+			utils.RespondError(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, domain.ErrTooManyRequests):
+			utils.RespondError(w, err.Error(), http.StatusTooManyRequests)
+		case errors.Is(err, domain.ErrNotFound):
+			utils.RespondError(w, "developer not found", http.StatusNotFound)
+		default:
+			slog.Error("failed to resend verification email", "error", err)
+			utils.RespondError(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	utils.RespondSuccess(w, map[string]string{
+		"message": "verification email sent",
+	}, http.StatusAccepted)
+}
+
+// GetByID returns a developer; non-staff may only fetch themselves
 func (h *DeveloperHandler) GetByID(w http.ResponseWriter, r *http.Request) {
-	utils.RespondError(w, "not implemented", http.StatusNotImplemented)
+	actor, id, ok := h.actorAndTarget(w, r)
+	if !ok {
+		return
+	}
+
+	dev, err := h.svc.GetByID(r.Context(), actor, id)
+	if err != nil {
+		h.respondDeveloperError(w, err)
+		return
+	}
+
+	if notModified(w, r, dev.Version) {
+		return
+	}
+	utils.RespondSuccess(w, newDeveloperResponse(dev), http.StatusOK)
 }
 
 func (h *DeveloperHandler) GetByEmail(w http.ResponseWriter, r *http.Request) {
 	utils.RespondError(w, "not implemented", http.StatusNotImplemented)
 }
 
+// GetAll lists developers for staff. Filters: ?status=, ?plan_tier=,
+// ?q= (substring of email, name or company), ?email_verified=,
+// ?created_after=/?created_before=, ?last_login_after=/?last_login_before=
+// (RFC 3339) and ?metadata.<key>=<value>. ?sort= takes created_at,
+// updated_at, last_login_at or email, with a leading "-" for descending;
+// the default is -created_at. Pages hold ?limit= entries; pass the previous
+// next_cursor as ?cursor= for the following page and ?include_total=true for
+// a total count.
 func (h *DeveloperHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	utils.RespondError(w, "not implemented", http.StatusNotImplemented)
+	actor, ok := middleware.GetActorFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	filter, err := developerFilterFromQuery(query)
+	if err != nil {
+		utils.RespondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sort, ascending := domain.SortByCreatedAt, false
+	if value := query.Get("sort"); value != "" {
+		ascending = !strings.HasPrefix(value, "-")
+		sort = domain.DeveloperSort(strings.TrimPrefix(value, "-"))
+		if !sort.Valid() {
+			utils.RespondError(w, "invalid sort field", http.StatusBadRequest)
+			return
+		}
+	}
+
+	limit, err := intQueryParam(query.Get("limit"), 20)
+	if err != nil || limit < 1 || limit > maxPageSize {
+		utils.RespondError(w, "limit must be between 1 and 100", http.StatusBadRequest)
+		return
+	}
+	pageRequest := domain.DeveloperPageRequest{
+		Limit:        limit,
+		IncludeTotal: query.Get("include_total") == "true",
+		Sort:         sort,
+		Ascending:    ascending,
+	}
+	if cursor := query.Get("cursor"); cursor != "" {
+		after, err := decodeDeveloperCursor(cursor)
+		if err != nil {
+			h.respondDeveloperError(w, err)
+			return
+		}
+		pageRequest.After = after
+	}
+
+	page, err := h.svc.GetAll(r.Context(), actor, filter, pageRequest)
+	if err != nil {
+		h.respondDeveloperError(w, err)
+		return
+	}
+
+	resp := developerListResponse{
+		Developers: make([]developerResponse, 0, len(page.Developers)),
+		Total:      page.Total,
+	}
+	for _, dev := range page.Developers {
+		resp.Developers = append(resp.Developers, newDeveloperResponse(dev))
+	}
+	if page.NextCursor != nil {
+		next := encodeDeveloperCursor(page.NextCursor)
+		resp.NextCursor = &next
+	}
+
+	utils.RespondSuccess(w, resp, http.StatusOK)
 }
 
+// UpdatePassword changes the caller's own password and signs out their other sessions
 func (h *DeveloperHandler) UpdatePassword(w http.ResponseWriter, r *http.Request) {
-	utils.RespondError(w, "not implemented", http.StatusNotImplemented)
+	actor, id, ok := h.actorAndTarget(w, r)
+	if !ok {
+		return
+	}
+
+	var req updatePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.svc.UpdatePassword(r.Context(), actor, id, req.OldPassword, req.NewPassword); err != nil {
+		h.respondDeveloperError(w, err)
+		return
+	}
+
+	currentID, _ := middleware.GetSessionIDFromContext(r.Context())
+	if err := h.authSvc.RevokeOtherSessions(r.Context(), id, currentID); err != nil {
+		slog.Warn("failed to revoke sessions after password change", "developer_id", id, "error", err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
+// Update applies a JSON Merge Patch (RFC 7396) to a developer: omitted fields
+// are left alone and null clears a field. Status and plan tier need an admin.
 func (h *DeveloperHandler) Update(w http.ResponseWriter, r *http.Request) {
-	utils.RespondError(w, "not implemented", http.StatusNotImplemented)
+	actor, id, ok := h.actorAndTarget(w, r)
+	if !ok {
+		return
+	}
+
+	input, err := decodeDeveloperPatch(r)
+	if err != nil {
+		utils.RespondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+	input.IfVersion = version
+
+	if err := h.svc.Update(r.Context(), actor, id, input); err != nil {
+		h.respondDeveloperError(w, err)
+		return
+	}
+
+	dev, err := h.svc.GetByID(r.Context(), actor, id)
+	if err != nil {
+		h.respondDeveloperError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(dev.Version))
+	utils.RespondSuccess(w, newDeveloperResponse(dev), http.StatusOK)
 }
 
 func (h *DeveloperHandler) UpdateLastLogin(w http.ResponseWriter, r *http.Request) {
 	utils.RespondError(w, "not implemented", http.StatusNotImplemented)
 }
 
+type forgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// ForgotPassword responds identically whether or not the email is registered
+func (h *DeveloperHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req forgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if !utils.IsValidEmail(req.Email) {
+		utils.RespondError(w, domain.ErrInvalidEmail.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.passwordResetSvc.RequestReset(r.Context(), req.Email); err != nil {
+		slog.Error("failed to request password reset", "error", err)
+	}
+
+	utils.RespondSuccess(w, map[string]string{
+		"message": "if an account exists for this email, a reset link has been sent",
+	}, http.StatusAccepted)
+}
+
+type resetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
 func (h *DeveloperHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
-	utils.RespondError(w, "not implemented", http.StatusNotImplemented)
+	var req resetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		utils.RespondError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.passwordResetSvc.ResetPassword(r.Context(), req.Token, req.NewPassword); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrWeakPassword) || errors.Is(err, domain.ErrShortPassword):
+			utils.RespondError(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, domain.ErrInvalidOneTimeToken) || errors.Is(err, domain.ErrNotFound):
+			utils.RespondError(w, "invalid or expired token", http.StatusBadRequest)
+		default:
+			slog.Error("failed to reset password", "error", err)
+			utils.RespondError(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	utils.RespondSuccess(w, map[string]string{
+		"message": "password has been reset",
+	}, http.StatusOK)
 }
 
 func (h *DeveloperHandler) AddMetadata(w http.ResponseWriter, r *http.Request) {
 	utils.RespondError(w, "not implemented", http.StatusNotImplemented)
 }
 
+// Delete signs out all of a developer's sessions, then removes the developer
+// and everything they own. Pass ?soft=true to only mark the account deleted.
 func (h *DeveloperHandler) Delete(w http.ResponseWriter, r *http.Request) {
-	utils.RespondError(w, "not implemented", http.StatusNotImplemented)
+	if r.URL.Query().Get("soft") == "true" {
+		h.SoftDelete(w, r)
+		return
+	}
+
+	actor, id, ok := h.actorAndTarget(w, r)
+	if !ok {
+		return
+	}
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Delete(r.Context(), actor, id, version); err != nil {
+		h.respondDeveloperError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
+// SoftDelete marks a developer deleted and signs out all their sessions
 func (h *DeveloperHandler) SoftDelete(w http.ResponseWriter, r *http.Request) {
-	utils.RespondError(w, "not implemented", http.StatusNotImplemented)
+	actor, id, ok := h.actorAndTarget(w, r)
+	if !ok {
+		return
+	}
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.SoftDelete(r.Context(), actor, id, version); err != nil {
+		h.respondDeveloperError(w, err)
+		return
+	}
+
+	if err := h.authSvc.RevokeOtherSessions(r.Context(), id, uuid.Nil); err != nil {
+		slog.Warn("failed to revoke sessions of deleted developer", "developer_id", id, "error", err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
+// Suspend blocks a developer and signs out all their sessions; staff only
 func (h *DeveloperHandler) Suspend(w http.ResponseWriter, r *http.Request) {
-	utils.RespondError(w, "not implemented", http.StatusNotImplemented)
+	actor, id, ok := h.actorAndTarget(w, r)
+	if !ok {
+		return
+	}
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Suspend(r.Context(), actor, id, version); err != nil {
+		h.respondDeveloperError(w, err)
+		return
+	}
+
+	if err := h.authSvc.RevokeOtherSessions(r.Context(), id, uuid.Nil); err != nil {
+		slog.Warn("failed to revoke sessions of suspended developer", "developer_id", id, "error", err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// actorAndTarget reads the caller and the {id} path parameter
+func (h *DeveloperHandler) actorAndTarget(w http.ResponseWriter, r *http.Request) (domain.Actor, uuid.UUID, bool) {
+	actor, ok := middleware.GetActorFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return domain.Actor{}, uuid.Nil, false
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondError(w, "invalid developer id", http.StatusBadRequest)
+		return domain.Actor{}, uuid.Nil, false
+	}
+
+	return actor, id, true
+}
+
+func (h *DeveloperHandler) respondDeveloperError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrForbidden):
+		utils.RespondError(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, domain.ErrNotFound):
+		utils.RespondError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidPassword):
+		utils.RespondError(w, "current password is incorrect", http.StatusBadRequest)
+	case errors.Is(err, domain.ErrWrongPassword):
+		utils.RespondError(w, "password was changed concurrently, try again", http.StatusConflict)
+	case errors.Is(err, domain.ErrWeakPassword) || errors.Is(err, domain.ErrShortPassword):
+		utils.RespondError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrInvalidInput), errors.Is(err, domain.ErrUnknownPlan):
+		utils.RespondError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrSuspendViaUpdate):
+		utils.RespondError(w, "use POST /developers/{id}/suspend to suspend a developer", http.StatusBadRequest)
+	case errors.Is(err, domain.ErrPreconditionFailed):
+		utils.RespondError(w, err.Error(), http.StatusPreconditionFailed)
+	case errors.Is(err, domain.ErrInvalidCursor):
+		utils.RespondError(w, err.Error(), http.StatusBadRequest)
+	default:
+		slog.Error("developer operation failed", "error", err)
+		utils.RespondError(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+// developerFilterFromQuery reads the listing filters from the query string
+func developerFilterFromQuery(query url.Values) (domain.DeveloperFilter, error) {
+	var filter domain.DeveloperFilter
+	if status := query.Get("status"); status != "" {
+		s := domain.Status(status)
+		switch s {
+		case domain.StatusPending, domain.StatusActive, domain.StatusSuspended, domain.StatusDeleted:
+			filter.Status = &s
+		default:
+			return filter, errors.New("invalid status filter")
+		}
+	}
+	if planTier := query.Get("plan_tier"); planTier != "" {
+		filter.PlanTier = &planTier
+	}
+	if q := strings.TrimSpace(query.Get("q")); q != "" {
+		filter.Query = &q
+	}
+	if value := query.Get("email_verified"); value != "" {
+		verified, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, errors.New("invalid email_verified filter")
+		}
+		filter.EmailVerified = &verified
+	}
+
+	timeFilters := map[string]**time.Time{
+		"created_after":     &filter.CreatedAfter,
+		"created_before":    &filter.CreatedBefore,
+		"last_login_after":  &filter.LastLoginAfter,
+		"last_login_before": &filter.LastLoginBefore,
+	}
+	for name, target := range timeFilters {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+		}
+		*target = &t
+	}
+
+	for name, values := range query {
+		key, ok := strings.CutPrefix(name, "metadata.")
+		if !ok {
+			continue
+		}
+		if key == "" || len(values) != 1 {
+			return filter, fmt.Errorf("invalid metadata filter %q", name)
+		}
+		if filter.Metadata == nil {
+			filter.Metadata = make(map[string]string)
+		}
+		filter.Metadata[key] = values[0]
+	}
+
+	return filter, nil
+}
+
+func intQueryParam(value string, fallback int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(value)
+}
+
+type setRoleRequest struct {
+	Role domain.Role `json:"role"`
+}
+
+// SetRole assigns a role to a developer; superadmins only
+func (h *DeveloperHandler) SetRole(w http.ResponseWriter, r *http.Request) {
+	actor, id, ok := h.actorAndTarget(w, r)
+	if !ok {
+		return
+	}
+
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var req setRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.svc.SetRole(r.Context(), actor, id, req.Role, version); err != nil {
+		if errors.Is(err, domain.ErrInvalidRole) {
+			utils.RespondError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.respondDeveloperError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeDeveloperPatch reads a merge patch body into a partial update
+func decodeDeveloperPatch(r *http.Request) (*domain.UpdateDeveloperInput, error) {
+	var patch map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		return nil, errors.New("invalid request body")
+	}
+
+	input := &domain.UpdateDeveloperInput{}
+	for field, raw := range patch {
+		var err error
+		switch field {
+		case "full_name":
+			input.FullName, err = optionalField[string](raw)
+		case "company_name":
+			input.CompanyName, err = optionalField[string](raw)
+		case "status":
+			input.Status, err = optionalField[domain.Status](raw)
+		case "plan_tier":
+			input.PlanTier, err = optionalField[string](raw)
+		default:
+			return nil, fmt.Errorf("field %q cannot be updated", field)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %q", field)
+		}
+	}
+	return input, nil
+}
+
+func optionalField[T any](raw json.RawMessage) (domain.Optional[T], error) {
+	if string(raw) == "null" {
+		return domain.Optional[T]{Set: true}, nil
+	}
+	var value T
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return domain.Optional[T]{}, err
+	}
+	return domain.Optional[T]{Set: true, Value: &value}, nil
 }
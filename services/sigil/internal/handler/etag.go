@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+	"github.com/vivek-344/diagon/sigil/utils"
+)
+
+// etag renders a developer's row version as a strong ETag. Mutating
+// endpoints accept it in If-Match and answer 412 when the row has moved on;
+// without If-Match the write is unconditional.
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ifMatch reads the version an If-Match header pins the write to. It returns
+// nil when the header is absent or "*". A malformed or weak tag can never
+// match, so it writes a 412 and returns false.
+func ifMatch(w http.ResponseWriter, r *http.Request) (*int64, bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return nil, true
+	}
+
+	if len(header) >= 2 && header[0] == '"' && header[len(header)-1] == '"' {
+		if version, err := strconv.ParseInt(header[1:len(header)-1], 10, 64); err == nil {
+			return &version, true
+		}
+	}
+
+	utils.RespondError(w, domain.ErrPreconditionFailed.Error(), http.StatusPreconditionFailed)
+	return nil, false
+}
+
+// notModified sets the ETag and answers 304 when If-None-Match already holds it
+func notModified(w http.ResponseWriter, r *http.Request, version int64) bool {
+	tag := etag(version)
+	w.Header().Set("ETag", tag)
+
+	for _, candidate := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == tag || candidate == "*" {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
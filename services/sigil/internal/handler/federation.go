@@ -0,0 +1,324 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+	"github.com/vivek-344/diagon/sigil/internal/middleware"
+	"github.com/vivek-344/diagon/sigil/internal/service"
+	"github.com/vivek-344/diagon/sigil/utils"
+)
+
+// federatedBindingCookie ties an in-flight federated login to the browser
+// that started it; the callback refuses to finish a login without it
+const federatedBindingCookie = "sigil_federated_binding"
+
+type FederationHandler struct {
+	developerSvc  *service.DeveloperService
+	authSvc       *service.AuthService
+	mfaSvc        *service.MFAService
+	federationSvc *service.FederationService
+	appBaseURL    string
+}
+
+func NewFederationHandler(
+	developerSvc *service.DeveloperService,
+	authSvc *service.AuthService,
+	mfaSvc *service.MFAService,
+	federationSvc *service.FederationService,
+	appBaseURL string,
+) *FederationHandler {
+	return &FederationHandler{
+		developerSvc:  developerSvc,
+		authSvc:       authSvc,
+		mfaSvc:        mfaSvc,
+		federationSvc: federationSvc,
+		appBaseURL:    appBaseURL,
+	}
+}
+
+type identityProviderResponse struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+type federatedLinkResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+type federatedExchangeRequest struct {
+	Code       string  `json:"code"`
+	DeviceName *string `json:"device_name,omitempty"`
+}
+
+type externalIdentityResponse struct {
+	ID          string     `json:"id"`
+	Provider    string     `json:"provider"`
+	Subject     string     `json:"subject"`
+	Email       *string    `json:"email,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
+
+func newExternalIdentityResponse(identity *domain.ExternalIdentity) externalIdentityResponse {
+	return externalIdentityResponse{
+		ID:          identity.ID.String(),
+		Provider:    identity.Provider,
+		Subject:     identity.Subject,
+		Email:       identity.Email,
+		CreatedAt:   identity.CreatedAt,
+		LastLoginAt: identity.LastLoginAt,
+	}
+}
+
+// Providers lists the identity providers offered on the login page
+func (h *FederationHandler) Providers(w http.ResponseWriter, r *http.Request) {
+	providers := h.federationSvc.Providers()
+
+	resp := make([]identityProviderResponse, 0, len(providers))
+	for _, provider := range providers {
+		resp = append(resp, identityProviderResponse{
+			Name:        provider.Name(),
+			DisplayName: provider.DisplayName(),
+		})
+	}
+
+	utils.RespondSuccess(w, resp, http.StatusOK)
+}
+
+// Login redirects the browser to the identity provider
+func (h *FederationHandler) Login(w http.ResponseWriter, r *http.Request) {
+	start, err := h.federationSvc.Begin(r.Context(), chi.URLParam(r, "provider"), nil)
+	if err != nil {
+		h.respondFederationError(w, err)
+		return
+	}
+
+	setFederatedBinding(w, start)
+	http.Redirect(w, r, start.AuthorizationURL, http.StatusFound)
+}
+
+// Link starts linking a provider account to the signed-in developer. The
+// dashboard calls it with credentials so the binding cookie is kept, then
+// navigates to the returned URL; the callback finishes the link.
+func (h *FederationHandler) Link(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	start, err := h.federationSvc.Begin(r.Context(), chi.URLParam(r, "provider"), &developerID)
+	if err != nil {
+		h.respondFederationError(w, err)
+		return
+	}
+
+	setFederatedBinding(w, start)
+	utils.RespondSuccess(w, federatedLinkResponse{AuthorizationURL: start.AuthorizationURL}, http.StatusOK)
+}
+
+// Callback receives the provider redirect and hands the result to the
+// dashboard: a single-use login code, the linked provider, or an error code
+func (h *FederationHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	provider := chi.URLParam(r, "provider")
+
+	var binding string
+	if cookie, err := r.Cookie(federatedBindingCookie); err == nil {
+		binding = cookie.Value
+	}
+	clearFederatedBinding(w)
+
+	if providerErr := query.Get("error"); providerErr != "" {
+		slog.Debug("identity provider returned an error", "provider", provider, "error", providerErr)
+		h.completeRedirect(w, r, url.Values{"error": {"access_denied"}})
+		return
+	}
+
+	result, err := h.federationSvc.Complete(r.Context(), provider, query.Get("state"), binding, query.Get("code"))
+	if err != nil {
+		h.completeRedirect(w, r, url.Values{"error": {federationErrorCode(err)}})
+		return
+	}
+
+	if result.LoginCode == "" {
+		h.completeRedirect(w, r, url.Values{"linked": {result.Provider}})
+		return
+	}
+	h.completeRedirect(w, r, url.Values{"code": {result.LoginCode}})
+}
+
+// Exchange trades a login code from the callback for tokens, returning the
+// same payload as Login, including the MFA challenge when enabled
+func (h *FederationHandler) Exchange(w http.ResponseWriter, r *http.Request) {
+	var req federatedExchangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		utils.RespondError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	dev, err := h.federationSvc.Exchange(r.Context(), req.Code)
+	if err != nil {
+		h.respondFederationError(w, err)
+		return
+	}
+
+	// The provider stands in for the password, not for the second factor
+	mfaEnabled, err := h.mfaSvc.IsEnabled(r.Context(), dev.ID)
+	if err != nil {
+		slog.Error("failed to check mfa", "error", err)
+		utils.RespondError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if mfaEnabled {
+		mfaToken, err := h.mfaSvc.StartChallenge(r.Context(), dev)
+		if err != nil {
+			slog.Error("failed to start mfa challenge", "error", err)
+			utils.RespondError(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		utils.RespondSuccess(w, mfaChallengeResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		}, http.StatusOK)
+		return
+	}
+
+	tokens, err := h.authSvc.IssueTokens(r.Context(), dev, clientInfo(r, req.DeviceName))
+	if err != nil {
+		slog.Error("failed to generate tokens", "error", err)
+		utils.RespondError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.developerSvc.UpdateLastLogin(r.Context(), dev.ID); err != nil {
+		slog.Warn("failed to update last login", "error", err)
+	}
+
+	utils.RespondSuccess(w, newLoginResponse(dev, tokens), http.StatusOK)
+}
+
+// ListIdentities returns the provider accounts linked to the developer
+func (h *FederationHandler) ListIdentities(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	identities, err := h.federationSvc.ListIdentities(r.Context(), developerID)
+	if err != nil {
+		h.respondFederationError(w, err)
+		return
+	}
+
+	resp := make([]externalIdentityResponse, 0, len(identities))
+	for _, identity := range identities {
+		resp = append(resp, newExternalIdentityResponse(identity))
+	}
+
+	utils.RespondSuccess(w, resp, http.StatusOK)
+}
+
+// UnlinkIdentity removes one of the developer's linked provider accounts
+func (h *FederationHandler) UnlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondError(w, "invalid identity id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.federationSvc.Unlink(r.Context(), developerID, id); err != nil {
+		h.respondFederationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func setFederatedBinding(w http.ResponseWriter, start *service.FederatedStart) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     federatedBindingCookie,
+		Value:    start.Binding,
+		Path:     "/auth/federated",
+		Expires:  start.ExpiresAt,
+		HttpOnly: true,
+		Secure:   true,
+		// Lax still sends it on the provider's top-level redirect back
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func clearFederatedBinding(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     federatedBindingCookie,
+		Path:     "/auth/federated",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// completeRedirect sends the browser to the dashboard page that finishes
+// federated logins
+func (h *FederationHandler) completeRedirect(w http.ResponseWriter, r *http.Request, params url.Values) {
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, h.appBaseURL+"/auth/federated/complete?"+params.Encode(), http.StatusFound)
+}
+
+// federationErrorCode is the error passed to the dashboard after a failed callback
+func federationErrorCode(err error) string {
+	switch {
+	case errors.Is(err, domain.ErrUnknownIdentityProvider):
+		return "unknown_provider"
+	case errors.Is(err, domain.ErrFederatedLoginNotFound):
+		return "invalid_state"
+	case errors.Is(err, domain.ErrFederatedLoginFailed):
+		return "login_failed"
+	case errors.Is(err, domain.ErrExternalEmailUnverified):
+		return "email_unverified"
+	case errors.Is(err, domain.ErrExternalEmailConflict):
+		return "email_conflict"
+	case errors.Is(err, domain.ErrExternalIdentityLinked):
+		return "identity_linked"
+	case errors.Is(err, domain.ErrAccountSuspended):
+		return "account_suspended"
+	case errors.Is(err, domain.ErrExternalAccountDeleted):
+		return "account_deleted"
+	default:
+		slog.Error("federated login failed", "error", err)
+		return "server_error"
+	}
+}
+
+func (h *FederationHandler) respondFederationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrUnknownIdentityProvider), errors.Is(err, domain.ErrExternalIdentityNotFound):
+		utils.RespondError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidOneTimeToken):
+		utils.RespondError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrAccountSuspended):
+		utils.RespondError(w, "account suspended", http.StatusForbidden)
+	case errors.Is(err, domain.ErrFederatedLoginFailed):
+		utils.RespondError(w, err.Error(), http.StatusBadGateway)
+	default:
+		slog.Error("federation operation failed", "error", err)
+		utils.RespondError(w, "internal server error", http.StatusInternalServerError)
+	}
+}
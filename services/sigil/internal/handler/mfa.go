@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+	"github.com/vivek-344/diagon/sigil/internal/middleware"
+	"github.com/vivek-344/diagon/sigil/internal/service"
+	"github.com/vivek-344/diagon/sigil/utils"
+)
+
+type MFAHandler struct {
+	developerSvc *service.DeveloperService
+	mfaSvc       *service.MFAService
+}
+
+func NewMFAHandler(developerSvc *service.DeveloperService, mfaSvc *service.MFAService) *MFAHandler {
+	return &MFAHandler{
+		developerSvc: developerSvc,
+		mfaSvc:       mfaSvc,
+	}
+}
+
+type mfaEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type mfaCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type mfaConfirmResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type mfaVerifyRequest struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+// Enroll starts TOTP enrollment and returns the secret for the authenticator app
+func (h *MFAHandler) Enroll(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	enrollment, err := h.mfaSvc.Enroll(r.Context(), developerID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrMFAAlreadyEnabled):
+			utils.RespondError(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, domain.ErrNotFound):
+			utils.RespondError(w, "developer not found", http.StatusNotFound)
+		case errors.Is(err, domain.ErrMFAUnavailable):
+			utils.RespondError(w, err.Error(), http.StatusServiceUnavailable)
+		default:
+			slog.Error("failed to enroll mfa", "error", err)
+			utils.RespondError(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	utils.RespondSuccess(w, mfaEnrollResponse{
+		Secret:     enrollment.Secret,
+		OTPAuthURI: enrollment.OTPAuthURI,
+	}, http.StatusOK)
+}
+
+// Confirm activates MFA with a first valid code and returns recovery codes once
+func (h *MFAHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req mfaCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	codes, err := h.mfaSvc.Confirm(r.Context(), developerID, req.Code)
+	if err != nil {
+		h.respondMFAError(w, err)
+		return
+	}
+
+	utils.RespondSuccess(w, mfaConfirmResponse{RecoveryCodes: codes}, http.StatusOK)
+}
+
+// Disable turns MFA off after checking a current code
+func (h *MFAHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req mfaCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.mfaSvc.Disable(r.Context(), developerID, req.Code); err != nil {
+		h.respondMFAError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Verify completes a two-step login and returns the same payload as Login
+func (h *MFAHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	var req mfaVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	dev, tokens, err := h.mfaSvc.CompleteChallenge(r.Context(), req.MFAToken, req.Code, clientInfo(r, nil))
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidMFAChallenge) || errors.Is(err, domain.ErrNotFound):
+			utils.RespondError(w, "invalid or expired mfa token", http.StatusUnauthorized)
+		case errors.Is(err, domain.ErrAccountSuspended):
+			utils.RespondError(w, "account suspended", http.StatusForbidden)
+		default:
+			h.respondMFAError(w, err)
+		}
+		return
+	}
+
+	if err := h.developerSvc.UpdateLastLogin(r.Context(), dev.ID); err != nil {
+		slog.Warn("failed to update last login", "error", err)
+	}
+
+	utils.RespondSuccess(w, newLoginResponse(dev, tokens), http.StatusOK)
+}
+
+func (h *MFAHandler) respondMFAError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidMFACode):
+		utils.RespondError(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, domain.ErrMFANotEnrolled):
+		utils.RespondError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrMFAAlreadyEnabled):
+		utils.RespondError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, domain.ErrMFAUnavailable):
+		utils.RespondError(w, err.Error(), http.StatusServiceUnavailable)
+	case errors.Is(err, domain.ErrMFALocked):
+		utils.RespondError(w, err.Error(), http.StatusTooManyRequests)
+	default:
+		slog.Error("mfa operation failed", "error", err)
+		utils.RespondError(w, "internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,689 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+	"github.com/vivek-344/diagon/sigil/internal/middleware"
+	"github.com/vivek-344/diagon/sigil/internal/service"
+	"github.com/vivek-344/diagon/sigil/utils"
+)
+
+// OAuthHandler serves the OAuth 2.0 authorization and token endpoints (RFC 6749)
+type OAuthHandler struct {
+	serviceClientSvc *service.ServiceClientService
+	oauthSvc         *service.OAuthService
+	deviceSvc        *service.DeviceAuthorizationService
+	tokenSvc         *service.TokenService
+	appBaseURL       string
+}
+
+func NewOAuthHandler(
+	serviceClientSvc *service.ServiceClientService,
+	oauthSvc *service.OAuthService,
+	deviceSvc *service.DeviceAuthorizationService,
+	tokenSvc *service.TokenService,
+	appBaseURL string,
+) *OAuthHandler {
+	return &OAuthHandler{
+		serviceClientSvc: serviceClientSvc,
+		oauthSvc:         oauthSvc,
+		deviceSvc:        deviceSvc,
+		tokenSvc:         tokenSvc,
+		appBaseURL:       appBaseURL,
+	}
+}
+
+// Error codes of RFC 6749 sections 4.1.2.1 and 5.2, RFC 8628 3.5 and RFC 7009 2.2.1
+const (
+	oauthInvalidRequest          = "invalid_request"
+	oauthInvalidClient           = "invalid_client"
+	oauthInvalidGrant            = "invalid_grant"
+	oauthInvalidScope            = "invalid_scope"
+	oauthAccessDenied            = "access_denied"
+	oauthUnsupportedGrantType    = "unsupported_grant_type"
+	oauthUnsupportedResponseType = "unsupported_response_type"
+	oauthServerError             = "server_error"
+	oauthAuthorizationPending    = "authorization_pending"
+	oauthSlowDown                = "slow_down"
+	oauthExpiredToken            = "expired_token"
+	oauthUnauthorizedClient      = "unauthorized_client"
+	oauthUnsupportedTokenType    = "unsupported_token_type"
+)
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+type oauthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+type consentRequest struct {
+	ResponseType        string `json:"response_type"`
+	ClientID            string `json:"client_id"`
+	RedirectURI         string `json:"redirect_uri"`
+	Scope               string `json:"scope"`
+	State               string `json:"state"`
+	CodeChallenge       string `json:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method"`
+	Nonce               string `json:"nonce"`
+	Approved            bool   `json:"approved"`
+}
+
+type consentPromptResponse struct {
+	ClientID    string   `json:"client_id"`
+	ClientName  string   `json:"client_name"`
+	RedirectURI string   `json:"redirect_uri"`
+	Scopes      []string `json:"scopes"`
+	Consented   bool     `json:"consented"` // every scope was approved before
+}
+
+type consentResponse struct {
+	RedirectTo string `json:"redirect_to"`
+}
+
+// deviceAuthorizationResponse is the answer of RFC 8628 3.2
+type deviceAuthorizationResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+type deviceLookupResponse struct {
+	UserCode   string    `json:"user_code"`
+	ClientID   string    `json:"client_id"`
+	ClientName string    `json:"client_name"`
+	Scopes     []string  `json:"scopes"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// introspectionResponse is the answer of RFC 7662 2.2; inactive tokens
+// carry only active
+type introspectionResponse struct {
+	Active        bool   `json:"active"`
+	TokenType     string `json:"token_type,omitempty"`
+	PrincipalType string `json:"principal_type,omitempty"`
+	Sub           string `json:"sub,omitempty"`
+	ClientID      string `json:"client_id,omitempty"`
+	Username      string `json:"username,omitempty"`
+	Scope         string `json:"scope,omitempty"`
+	SessionID     string `json:"sid,omitempty"`
+	OrgID         string `json:"org_id,omitempty"`
+	ProjectID     string `json:"project_id,omitempty"`
+	Exp           int64  `json:"exp,omitempty"`
+	Iat           int64  `json:"iat,omitempty"`
+}
+
+func newIntrospectionResponse(info *domain.TokenIntrospection) introspectionResponse {
+	resp := introspectionResponse{
+		Active:        info.Active,
+		TokenType:     info.Kind,
+		PrincipalType: string(info.PrincipalType),
+		Sub:           info.Subject,
+		ClientID:      info.ClientID,
+		Username:      info.Username,
+		Scope:         strings.Join(info.Scopes, " "),
+	}
+	if info.SessionID != nil {
+		resp.SessionID = info.SessionID.String()
+	}
+	if info.OrgID != nil {
+		resp.OrgID = info.OrgID.String()
+	}
+	if info.ProjectID != nil {
+		resp.ProjectID = info.ProjectID.String()
+	}
+	if info.ExpiresAt != nil {
+		resp.Exp = info.ExpiresAt.Unix()
+	}
+	if info.IssuedAt != nil {
+		resp.Iat = info.IssuedAt.Unix()
+	}
+	return resp
+}
+
+type deviceDecisionRequest struct {
+	UserCode string `json:"user_code"`
+	Approved bool   `json:"approved"`
+}
+
+// Authorize is where clients send the browser. Valid requests continue to
+// the dashboard's consent screen with the same parameters.
+func (h *OAuthHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	req := authorizationRequestFromValues(r.URL.Query())
+
+	if _, err := h.oauthSvc.ValidateAuthorization(r.Context(), req); err != nil {
+		if redirectTo, ok := authorizationErrorRedirect(req, err); ok {
+			http.Redirect(w, r, redirectTo, http.StatusFound)
+			return
+		}
+		h.respondAuthorizationError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, h.appBaseURL+"/oauth/consent?"+r.URL.RawQuery, http.StatusFound)
+}
+
+// ConsentPrompt describes an authorization request for the consent screen
+func (h *OAuthHandler) ConsentPrompt(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := h.firstPartyCaller(w, r)
+	if !ok {
+		return
+	}
+
+	req := authorizationRequestFromValues(r.URL.Query())
+	client, consented, err := h.oauthSvc.ConsentPrompt(r.Context(), developerID, req)
+	if err != nil {
+		h.respondAuthorizationError(w, err)
+		return
+	}
+
+	utils.RespondSuccess(w, consentPromptResponse{
+		ClientID:    client.ClientID,
+		ClientName:  client.Name,
+		RedirectURI: req.RedirectURI,
+		Scopes:      req.Scopes,
+		Consented:   consented,
+	}, http.StatusOK)
+}
+
+// Consent records the developer's decision and returns where to send the
+// browser: the client's redirect URI with a code, or with an error
+func (h *OAuthHandler) Consent(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := h.firstPartyCaller(w, r)
+	if !ok {
+		return
+	}
+
+	var body consentRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		utils.RespondError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	req := &domain.AuthorizationRequest{
+		ResponseType:        body.ResponseType,
+		ClientID:            body.ClientID,
+		RedirectURI:         body.RedirectURI,
+		Scopes:              strings.Fields(body.Scope),
+		State:               body.State,
+		CodeChallenge:       body.CodeChallenge,
+		CodeChallengeMethod: body.CodeChallengeMethod,
+		Nonce:               body.Nonce,
+	}
+
+	if !body.Approved {
+		if _, err := h.oauthSvc.ValidateAuthorization(r.Context(), req); err != nil {
+			if redirectTo, ok := authorizationErrorRedirect(req, err); ok {
+				utils.RespondSuccess(w, consentResponse{RedirectTo: redirectTo}, http.StatusOK)
+				return
+			}
+			h.respondAuthorizationError(w, err)
+			return
+		}
+		redirectTo, _ := authorizationErrorRedirect(req, domain.ErrAccessDenied)
+		utils.RespondSuccess(w, consentResponse{RedirectTo: redirectTo}, http.StatusOK)
+		return
+	}
+
+	code, err := h.oauthSvc.Authorize(r.Context(), developerID, req)
+	if err != nil {
+		if redirectTo, ok := authorizationErrorRedirect(req, err); ok {
+			utils.RespondSuccess(w, consentResponse{RedirectTo: redirectTo}, http.StatusOK)
+			return
+		}
+		h.respondAuthorizationError(w, err)
+		return
+	}
+
+	params := url.Values{"code": {code}}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	utils.RespondSuccess(w, consentResponse{RedirectTo: withQuery(req.RedirectURI, params)}, http.StatusOK)
+}
+
+// Token exchanges a grant for an access token. The request is form encoded;
+// clients authenticate with HTTP Basic or client_id/client_secret fields.
+func (h *OAuthHandler) Token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		respondOAuthError(w, oauthInvalidRequest, "malformed form body", http.StatusBadRequest)
+		return
+	}
+
+	switch r.PostForm.Get("grant_type") {
+	case "client_credentials":
+		h.clientCredentials(w, r)
+	case "authorization_code":
+		h.authorizationCode(w, r)
+	case "refresh_token":
+		h.refreshToken(w, r)
+	case domain.DeviceCodeGrantType:
+		h.deviceCode(w, r)
+	case "":
+		respondOAuthError(w, oauthInvalidRequest, "grant_type is required", http.StatusBadRequest)
+	default:
+		respondOAuthError(w, oauthUnsupportedGrantType, "", http.StatusBadRequest)
+	}
+}
+
+func (h *OAuthHandler) clientCredentials(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, usedBasic, ok := clientAuthFromRequest(r)
+	if !ok {
+		respondOAuthError(w, oauthInvalidRequest, "use exactly one client authentication method", http.StatusBadRequest)
+		return
+	}
+
+	token, scopes, err := h.serviceClientSvc.IssueToken(r.Context(), clientID, clientSecret, strings.Fields(r.PostForm.Get("scope")))
+	if err != nil {
+		respondTokenError(w, err, usedBasic)
+		return
+	}
+
+	respondToken(w, tokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(utils.AccessTokenTTL.Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	})
+}
+
+func (h *OAuthHandler) authorizationCode(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, usedBasic, ok := clientAuthFromRequest(r)
+	if !ok {
+		respondOAuthError(w, oauthInvalidRequest, "use exactly one client authentication method", http.StatusBadRequest)
+		return
+	}
+
+	code, verifier := r.PostForm.Get("code"), r.PostForm.Get("code_verifier")
+	if code == "" || verifier == "" {
+		respondOAuthError(w, oauthInvalidRequest, "code and code_verifier are required", http.StatusBadRequest)
+		return
+	}
+
+	pair, scopes, err := h.oauthSvc.ExchangeCode(
+		r.Context(), clientID, clientSecret, code, r.PostForm.Get("redirect_uri"), verifier, clientInfo(r, nil),
+	)
+	if err != nil {
+		respondTokenError(w, err, usedBasic)
+		return
+	}
+
+	respondTokenPair(w, pair, scopes)
+}
+
+func (h *OAuthHandler) refreshToken(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, usedBasic, ok := clientAuthFromRequest(r)
+	if !ok {
+		respondOAuthError(w, oauthInvalidRequest, "use exactly one client authentication method", http.StatusBadRequest)
+		return
+	}
+
+	refreshToken := r.PostForm.Get("refresh_token")
+	if refreshToken == "" {
+		respondOAuthError(w, oauthInvalidRequest, "refresh_token is required", http.StatusBadRequest)
+		return
+	}
+
+	pair, scopes, err := h.oauthSvc.Refresh(r.Context(), clientID, clientSecret, refreshToken, clientInfo(r, nil))
+	if err != nil {
+		respondTokenError(w, err, usedBasic)
+		return
+	}
+
+	respondTokenPair(w, pair, scopes)
+}
+
+func (h *OAuthHandler) deviceCode(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, usedBasic, ok := clientAuthFromRequest(r)
+	if !ok {
+		respondOAuthError(w, oauthInvalidRequest, "use exactly one client authentication method", http.StatusBadRequest)
+		return
+	}
+
+	deviceCode := r.PostForm.Get("device_code")
+	if deviceCode == "" {
+		respondOAuthError(w, oauthInvalidRequest, "device_code is required", http.StatusBadRequest)
+		return
+	}
+
+	pair, scopes, err := h.deviceSvc.Exchange(r.Context(), clientID, clientSecret, deviceCode, clientInfo(r, nil))
+	if err != nil {
+		respondTokenError(w, err, usedBasic)
+		return
+	}
+
+	respondTokenPair(w, pair, scopes)
+}
+
+// DeviceAuthorization starts the device flow (RFC 8628 3.1). The device
+// shows the user code and verification URI, then polls /oauth/token.
+func (h *OAuthHandler) DeviceAuthorization(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		respondOAuthError(w, oauthInvalidRequest, "malformed form body", http.StatusBadRequest)
+		return
+	}
+
+	clientID, clientSecret, usedBasic, ok := clientAuthFromRequest(r)
+	if !ok {
+		respondOAuthError(w, oauthInvalidRequest, "use exactly one client authentication method", http.StatusBadRequest)
+		return
+	}
+
+	deviceCode, code, err := h.deviceSvc.Start(r.Context(), clientID, clientSecret, strings.Fields(r.PostForm.Get("scope")))
+	if err != nil {
+		respondTokenError(w, err, usedBasic)
+		return
+	}
+
+	userCode := domain.FormatUserCode(code.UserCode)
+	verificationURI := h.appBaseURL + "/device"
+	respondToken(w, deviceAuthorizationResponse{
+		DeviceCode:              deviceCode,
+		UserCode:                userCode,
+		VerificationURI:         verificationURI,
+		VerificationURIComplete: withQuery(verificationURI, url.Values{"user_code": {userCode}}),
+		ExpiresIn:               int(time.Until(code.ExpiresAt).Seconds()),
+		Interval:                int(code.Interval.Seconds()),
+	})
+}
+
+// DeviceLookup describes a pending user code for the approval page
+func (h *OAuthHandler) DeviceLookup(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.firstPartyCaller(w, r); !ok {
+		return
+	}
+
+	code, client, err := h.deviceSvc.Lookup(r.Context(), r.URL.Query().Get("user_code"))
+	if err != nil {
+		respondDeviceError(w, err)
+		return
+	}
+
+	utils.RespondSuccess(w, deviceLookupResponse{
+		UserCode:   domain.FormatUserCode(code.UserCode),
+		ClientID:   client.ClientID,
+		ClientName: client.Name,
+		Scopes:     code.Scopes,
+		ExpiresAt:  code.ExpiresAt,
+	}, http.StatusOK)
+}
+
+// DeviceDecision approves or denies a user code; the device learns the
+// outcome on its next poll
+func (h *OAuthHandler) DeviceDecision(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := h.firstPartyCaller(w, r)
+	if !ok {
+		return
+	}
+
+	var req deviceDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.deviceSvc.Decide(r.Context(), developerID, req.UserCode, req.Approved); err != nil {
+		respondDeviceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Introspect reports whether a token, refresh token or API key is active
+// (RFC 7662). token_type_hint is accepted but the token's format decides.
+func (h *OAuthHandler) Introspect(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, usedBasic, token, ok := tokenRequest(w, r)
+	if !ok {
+		return
+	}
+
+	info, err := h.tokenSvc.Introspect(r.Context(), clientID, clientSecret, token)
+	if err != nil {
+		respondTokenError(w, err, usedBasic)
+		return
+	}
+
+	respondToken(w, newIntrospectionResponse(info))
+}
+
+// Revoke invalidates a token, refresh token or API key (RFC 7009). Unknown
+// tokens succeed so callers learn nothing from the answer.
+func (h *OAuthHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, usedBasic, token, ok := tokenRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.tokenSvc.Revoke(r.Context(), clientID, clientSecret, token); err != nil {
+		respondTokenError(w, err, usedBasic)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+}
+
+// tokenRequest reads the client credentials and token of an introspection
+// or revocation request
+func tokenRequest(w http.ResponseWriter, r *http.Request) (string, string, bool, string, bool) {
+	if err := r.ParseForm(); err != nil {
+		respondOAuthError(w, oauthInvalidRequest, "malformed form body", http.StatusBadRequest)
+		return "", "", false, "", false
+	}
+
+	clientID, clientSecret, usedBasic, ok := clientAuthFromRequest(r)
+	if !ok {
+		respondOAuthError(w, oauthInvalidRequest, "use exactly one client authentication method", http.StatusBadRequest)
+		return "", "", false, "", false
+	}
+
+	token := r.PostForm.Get("token")
+	if token == "" {
+		respondOAuthError(w, oauthInvalidRequest, "token is required", http.StatusBadRequest)
+		return "", "", false, "", false
+	}
+
+	return clientID, clientSecret, usedBasic, token, true
+}
+
+// firstPartyCaller reads the developer and admits only first-party session
+// tokens; API keys and tokens issued to OAuth clients must never approve
+// consent themselves
+func (h *OAuthHandler) firstPartyCaller(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return developerID, false
+	}
+	if !middleware.IsFirstPartySession(r.Context()) {
+		utils.RespondError(w, "consent requires a first-party session", http.StatusForbidden)
+		return developerID, false
+	}
+	return developerID, true
+}
+
+// respondAuthorizationError answers errors that cannot go to the redirect URI
+func (h *OAuthHandler) respondAuthorizationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrOAuthClientNotFound):
+		respondOAuthError(w, oauthInvalidRequest, "unknown client_id", http.StatusBadRequest)
+	case errors.Is(err, domain.ErrInvalidRedirectURI):
+		respondOAuthError(w, oauthInvalidRequest, "redirect_uri is not registered for the client", http.StatusBadRequest)
+	case errors.Is(err, domain.ErrUnsupportedResponse):
+		respondOAuthError(w, oauthUnsupportedResponseType, "", http.StatusBadRequest)
+	case errors.Is(err, domain.ErrInvalidCodeChallenge), errors.Is(err, domain.ErrInvalidNonce):
+		respondOAuthError(w, oauthInvalidRequest, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrInvalidScope):
+		respondOAuthError(w, oauthInvalidScope, "", http.StatusBadRequest)
+	default:
+		slog.Error("oauth authorization failed", "error", err)
+		respondOAuthError(w, oauthServerError, "", http.StatusInternalServerError)
+	}
+}
+
+// authorizationErrorRedirect builds the redirect carrying an authorization
+// error. It is false when the client or redirect URI itself is invalid.
+func authorizationErrorRedirect(req *domain.AuthorizationRequest, err error) (string, bool) {
+	var code string
+	switch {
+	case errors.Is(err, domain.ErrOAuthClientNotFound), errors.Is(err, domain.ErrInvalidRedirectURI):
+		return "", false
+	case errors.Is(err, domain.ErrAccessDenied):
+		code = oauthAccessDenied
+	case errors.Is(err, domain.ErrUnsupportedResponse):
+		code = oauthUnsupportedResponseType
+	case errors.Is(err, domain.ErrInvalidCodeChallenge), errors.Is(err, domain.ErrInvalidNonce):
+		code = oauthInvalidRequest
+	case errors.Is(err, domain.ErrInvalidScope):
+		code = oauthInvalidScope
+	default:
+		slog.Error("oauth authorization failed", "error", err)
+		code = oauthServerError
+	}
+
+	params := url.Values{"error": {code}}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	return withQuery(req.RedirectURI, params), true
+}
+
+func authorizationRequestFromValues(values url.Values) *domain.AuthorizationRequest {
+	return &domain.AuthorizationRequest{
+		ResponseType:        values.Get("response_type"),
+		ClientID:            values.Get("client_id"),
+		RedirectURI:         values.Get("redirect_uri"),
+		Scopes:              strings.Fields(values.Get("scope")),
+		State:               values.Get("state"),
+		CodeChallenge:       values.Get("code_challenge"),
+		CodeChallengeMethod: values.Get("code_challenge_method"),
+		Nonce:               values.Get("nonce"),
+	}
+}
+
+// withQuery adds params to a registered redirect URI, keeping its own query
+func withQuery(rawURL string, params url.Values) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// clientAuthFromRequest reads client_secret_basic or client_secret_post
+// credentials; public clients send only client_id. Basic credentials are
+// form-urlencoded before base64 (RFC 6749 2.3.1).
+func clientAuthFromRequest(r *http.Request) (string, string, bool, bool) {
+	formID, formSecret := r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+
+	basicID, basicSecret, hasBasic := r.BasicAuth()
+	if hasBasic {
+		if formSecret != "" {
+			return "", "", false, false
+		}
+		id, err := url.QueryUnescape(basicID)
+		if err != nil {
+			return "", "", false, false
+		}
+		secret, err := url.QueryUnescape(basicSecret)
+		if err != nil {
+			return "", "", false, false
+		}
+		return id, secret, true, true
+	}
+
+	if formID == "" {
+		return "", "", false, false
+	}
+	return formID, formSecret, false, true
+}
+
+func respondTokenError(w http.ResponseWriter, err error, usedBasic bool) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidClientCredentials):
+		if usedBasic {
+			w.Header().Set("WWW-Authenticate", `Basic realm="sigil"`)
+		}
+		respondOAuthError(w, oauthInvalidClient, "", http.StatusUnauthorized)
+	case errors.Is(err, domain.ErrInvalidGrant):
+		respondOAuthError(w, oauthInvalidGrant, "", http.StatusBadRequest)
+	case errors.Is(err, domain.ErrInvalidScope):
+		respondOAuthError(w, oauthInvalidScope, "", http.StatusBadRequest)
+	case errors.Is(err, domain.ErrAuthorizationPending):
+		respondOAuthError(w, oauthAuthorizationPending, "", http.StatusBadRequest)
+	case errors.Is(err, domain.ErrSlowDown):
+		respondOAuthError(w, oauthSlowDown, "", http.StatusBadRequest)
+	case errors.Is(err, domain.ErrExpiredDeviceCode):
+		respondOAuthError(w, oauthExpiredToken, "", http.StatusBadRequest)
+	case errors.Is(err, domain.ErrAccessDenied):
+		respondOAuthError(w, oauthAccessDenied, "", http.StatusBadRequest)
+	case errors.Is(err, domain.ErrForbidden):
+		respondOAuthError(w, oauthUnauthorizedClient, "the client may not act on this token", http.StatusForbidden)
+	case errors.Is(err, domain.ErrUnsupportedTokenType):
+		respondOAuthError(w, oauthUnsupportedTokenType, err.Error(), http.StatusBadRequest)
+	default:
+		slog.Error("failed to issue oauth token", "error", err)
+		respondOAuthError(w, oauthServerError, "", http.StatusInternalServerError)
+	}
+}
+
+func respondDeviceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrDeviceCodeNotFound):
+		utils.RespondError(w, "unknown or expired user code", http.StatusNotFound)
+	default:
+		slog.Error("device authorization failed", "error", err)
+		utils.RespondError(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func respondTokenPair(w http.ResponseWriter, pair *utils.TokenPair, scopes []string) {
+	respondToken(w, tokenResponse{
+		AccessToken:  pair.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(utils.AccessTokenTTL.Seconds()),
+		RefreshToken: pair.RefreshToken,
+		IDToken:      pair.IDToken,
+		Scope:        strings.Join(scopes, " "),
+	})
+}
+
+func respondToken(w http.ResponseWriter, resp any) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	utils.RespondSuccess(w, resp, http.StatusOK)
+}
+
+func respondOAuthError(w http.ResponseWriter, code string, description string, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(oauthErrorResponse{
+		Error:            code,
+		ErrorDescription: description,
+	})
+}
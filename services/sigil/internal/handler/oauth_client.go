@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+	"github.com/vivek-344/diagon/sigil/internal/middleware"
+	"github.com/vivek-344/diagon/sigil/internal/service"
+	"github.com/vivek-344/diagon/sigil/utils"
+)
+
+type OAuthClientHandler struct {
+	oauthSvc *service.OAuthService
+}
+
+func NewOAuthClientHandler(oauthSvc *service.OAuthService) *OAuthClientHandler {
+	return &OAuthClientHandler{oauthSvc: oauthSvc}
+}
+
+type createOAuthClientRequest struct {
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirect_uris"`
+	Confidential bool     `json:"confidential"`
+}
+
+type oauthClientResponse struct {
+	ID           string    `json:"id"`
+	ClientID     string    `json:"client_id"`
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Confidential bool      `json:"confidential"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type createOAuthClientResponse struct {
+	oauthClientResponse
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+func newOAuthClientResponse(client *domain.OAuthClient) oauthClientResponse {
+	return oauthClientResponse{
+		ID:           client.ID.String(),
+		ClientID:     client.ClientID,
+		Name:         client.Name,
+		RedirectURIs: client.RedirectURIs,
+		Confidential: client.Confidential(),
+		CreatedAt:    client.CreatedAt,
+	}
+}
+
+// Create registers a third-party application. Confidential clients receive
+// a secret, returned only here.
+func (h *OAuthClientHandler) Create(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req createOAuthClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	secret, client, err := h.oauthSvc.RegisterClient(r.Context(), developerID, req.Name, req.RedirectURIs, req.Confidential)
+	if err != nil {
+		h.respondOAuthClientError(w, err)
+		return
+	}
+
+	utils.RespondSuccess(w, createOAuthClientResponse{
+		oauthClientResponse: newOAuthClientResponse(client),
+		ClientSecret:        secret,
+	}, http.StatusCreated)
+}
+
+func (h *OAuthClientHandler) List(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	clients, err := h.oauthSvc.ListClients(r.Context(), developerID)
+	if err != nil {
+		h.respondOAuthClientError(w, err)
+		return
+	}
+
+	resp := make([]oauthClientResponse, 0, len(clients))
+	for _, client := range clients {
+		resp = append(resp, newOAuthClientResponse(client))
+	}
+
+	utils.RespondSuccess(w, resp, http.StatusOK)
+}
+
+func (h *OAuthClientHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondError(w, "invalid client id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.oauthSvc.RevokeClient(r.Context(), developerID, id); err != nil {
+		h.respondOAuthClientError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *OAuthClientHandler) respondOAuthClientError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrOAuthClientNotFound):
+		utils.RespondError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidInput), errors.Is(err, domain.ErrInvalidRedirectURI):
+		utils.RespondError(w, err.Error(), http.StatusBadRequest)
+	default:
+		slog.Error("oauth client operation failed", "error", err)
+		utils.RespondError(w, "internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,424 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+	"github.com/vivek-344/diagon/sigil/internal/middleware"
+	"github.com/vivek-344/diagon/sigil/internal/service"
+	"github.com/vivek-344/diagon/sigil/utils"
+)
+
+type OrganizationHandler struct {
+	orgSvc *service.OrganizationService
+}
+
+func NewOrganizationHandler(orgSvc *service.OrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{orgSvc: orgSvc}
+}
+
+type createOrganizationRequest struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+type renameOrganizationRequest struct {
+	Name string `json:"name"`
+}
+
+type organizationResponse struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	Slug      string         `json:"slug"`
+	Role      domain.OrgRole `json:"role"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+func newOrganizationResponse(membership *domain.OrganizationMembership) organizationResponse {
+	return organizationResponse{
+		ID:        membership.Organization.ID.String(),
+		Name:      membership.Organization.Name,
+		Slug:      membership.Organization.Slug,
+		Role:      membership.Role,
+		CreatedAt: membership.Organization.CreatedAt,
+	}
+}
+
+type organizationMemberResponse struct {
+	DeveloperID string         `json:"developer_id"`
+	Email       string         `json:"email"`
+	FullName    *string        `json:"full_name"`
+	Role        domain.OrgRole `json:"role"`
+	JoinedAt    time.Time      `json:"joined_at"`
+}
+
+type setOrgRoleRequest struct {
+	Role domain.OrgRole `json:"role"`
+}
+
+type transferOwnershipRequest struct {
+	DeveloperID uuid.UUID `json:"developer_id"`
+}
+
+type inviteRequest struct {
+	Email string         `json:"email"`
+	Role  domain.OrgRole `json:"role"`
+}
+
+type invitationResponse struct {
+	ID        string         `json:"id"`
+	Email     string         `json:"email"`
+	Role      domain.OrgRole `json:"role"`
+	ExpiresAt time.Time      `json:"expires_at"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+func newInvitationResponse(invitation *domain.OrganizationInvitation) invitationResponse {
+	return invitationResponse{
+		ID:        invitation.ID.String(),
+		Email:     invitation.Email,
+		Role:      invitation.Role,
+		ExpiresAt: invitation.ExpiresAt,
+		CreatedAt: invitation.CreatedAt,
+	}
+}
+
+type acceptInvitationRequest struct {
+	Token string `json:"token"`
+}
+
+// Create starts an organization with the caller as its owner
+func (h *OrganizationHandler) Create(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req createOrganizationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	org, err := h.orgSvc.Create(r.Context(), developerID, req.Name, req.Slug)
+	if err != nil {
+		h.respondOrganizationError(w, err)
+		return
+	}
+
+	utils.RespondSuccess(w, newOrganizationResponse(&domain.OrganizationMembership{
+		Organization: org,
+		Role:         domain.OrgRoleOwner,
+	}), http.StatusCreated)
+}
+
+// List returns the caller's organizations with their role in each
+func (h *OrganizationHandler) List(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	memberships, err := h.orgSvc.List(r.Context(), developerID)
+	if err != nil {
+		h.respondOrganizationError(w, err)
+		return
+	}
+
+	resp := make([]organizationResponse, 0, len(memberships))
+	for _, membership := range memberships {
+		resp = append(resp, newOrganizationResponse(membership))
+	}
+
+	utils.RespondSuccess(w, resp, http.StatusOK)
+}
+
+func (h *OrganizationHandler) Get(w http.ResponseWriter, r *http.Request) {
+	developerID, orgID, ok := h.callerAndOrg(w, r)
+	if !ok {
+		return
+	}
+
+	membership, err := h.orgSvc.Get(r.Context(), developerID, orgID)
+	if err != nil {
+		h.respondOrganizationError(w, err)
+		return
+	}
+
+	utils.RespondSuccess(w, newOrganizationResponse(membership), http.StatusOK)
+}
+
+// Rename changes the organization's display name; the slug is permanent
+func (h *OrganizationHandler) Rename(w http.ResponseWriter, r *http.Request) {
+	developerID, orgID, ok := h.callerAndOrg(w, r)
+	if !ok {
+		return
+	}
+
+	var req renameOrganizationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.orgSvc.Rename(r.Context(), developerID, orgID, req.Name); err != nil {
+		h.respondOrganizationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *OrganizationHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	developerID, orgID, ok := h.callerAndOrg(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.orgSvc.Delete(r.Context(), developerID, orgID); err != nil {
+		h.respondOrganizationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *OrganizationHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	developerID, orgID, ok := h.callerAndOrg(w, r)
+	if !ok {
+		return
+	}
+
+	members, err := h.orgSvc.ListMembers(r.Context(), developerID, orgID)
+	if err != nil {
+		h.respondOrganizationError(w, err)
+		return
+	}
+
+	resp := make([]organizationMemberResponse, 0, len(members))
+	for _, member := range members {
+		resp = append(resp, organizationMemberResponse{
+			DeveloperID: member.DeveloperID.String(),
+			Email:       member.Email,
+			FullName:    member.FullName,
+			Role:        member.Role,
+			JoinedAt:    member.JoinedAt,
+		})
+	}
+
+	utils.RespondSuccess(w, resp, http.StatusOK)
+}
+
+func (h *OrganizationHandler) SetMemberRole(w http.ResponseWriter, r *http.Request) {
+	developerID, orgID, ok := h.callerAndOrg(w, r)
+	if !ok {
+		return
+	}
+	memberID, ok := h.memberParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req setOrgRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.orgSvc.SetMemberRole(r.Context(), developerID, orgID, memberID, req.Role); err != nil {
+		h.respondOrganizationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveMember removes a member; members may also remove themselves to leave
+func (h *OrganizationHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	developerID, orgID, ok := h.callerAndOrg(w, r)
+	if !ok {
+		return
+	}
+	memberID, ok := h.memberParam(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.orgSvc.RemoveMember(r.Context(), developerID, orgID, memberID); err != nil {
+		h.respondOrganizationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *OrganizationHandler) TransferOwnership(w http.ResponseWriter, r *http.Request) {
+	developerID, orgID, ok := h.callerAndOrg(w, r)
+	if !ok {
+		return
+	}
+
+	var req transferOwnershipRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.DeveloperID == uuid.Nil {
+		utils.RespondError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.orgSvc.TransferOwnership(r.Context(), developerID, orgID, req.DeveloperID); err != nil {
+		h.respondOrganizationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *OrganizationHandler) Invite(w http.ResponseWriter, r *http.Request) {
+	developerID, orgID, ok := h.callerAndOrg(w, r)
+	if !ok {
+		return
+	}
+
+	var req inviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Role == "" {
+		req.Role = domain.OrgRoleMember
+	}
+
+	invitation, err := h.orgSvc.Invite(r.Context(), developerID, orgID, req.Email, req.Role)
+	if err != nil {
+		h.respondOrganizationError(w, err)
+		return
+	}
+
+	utils.RespondSuccess(w, newInvitationResponse(invitation), http.StatusCreated)
+}
+
+func (h *OrganizationHandler) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	developerID, orgID, ok := h.callerAndOrg(w, r)
+	if !ok {
+		return
+	}
+
+	invitations, err := h.orgSvc.ListInvitations(r.Context(), developerID, orgID)
+	if err != nil {
+		h.respondOrganizationError(w, err)
+		return
+	}
+
+	resp := make([]invitationResponse, 0, len(invitations))
+	for _, invitation := range invitations {
+		resp = append(resp, newInvitationResponse(invitation))
+	}
+
+	utils.RespondSuccess(w, resp, http.StatusOK)
+}
+
+func (h *OrganizationHandler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	developerID, orgID, ok := h.callerAndOrg(w, r)
+	if !ok {
+		return
+	}
+
+	invitationID, err := uuid.Parse(chi.URLParam(r, "invitationID"))
+	if err != nil {
+		utils.RespondError(w, "invalid invitation id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.orgSvc.RevokeInvitation(r.Context(), developerID, orgID, invitationID); err != nil {
+		h.respondOrganizationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AcceptInvitation joins the organization named by an emailed invitation token
+func (h *OrganizationHandler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req acceptInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		utils.RespondError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	membership, err := h.orgSvc.AcceptInvitation(r.Context(), developerID, req.Token)
+	if err != nil {
+		h.respondOrganizationError(w, err)
+		return
+	}
+
+	utils.RespondSuccess(w, newOrganizationResponse(membership), http.StatusOK)
+}
+
+// callerAndOrg reads the caller and the {orgID} path parameter
+func (h *OrganizationHandler) callerAndOrg(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		utils.RespondError(w, "invalid organization id", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return developerID, orgID, true
+}
+
+func (h *OrganizationHandler) memberParam(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	memberID, err := uuid.Parse(chi.URLParam(r, "developerID"))
+	if err != nil {
+		utils.RespondError(w, "invalid developer id", http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+	return memberID, true
+}
+
+func (h *OrganizationHandler) respondOrganizationError(w http.ResponseWriter, err error) {
+	if respondQuotaError(w, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, domain.ErrForbidden):
+		utils.RespondError(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, domain.ErrOrganizationNotFound), errors.Is(err, domain.ErrNotOrganizationMember):
+		// Non-members cannot tell whether an organization exists
+		utils.RespondError(w, domain.ErrOrganizationNotFound.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrMemberNotFound), errors.Is(err, domain.ErrInvitationNotFound):
+		utils.RespondError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrOrganizationSlugTaken), errors.Is(err, domain.ErrAlreadyMember), errors.Is(err, domain.ErrOwnerCannotLeave):
+		utils.RespondError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, domain.ErrInvalidOrganization), errors.Is(err, domain.ErrInvalidOrgRole), errors.Is(err, domain.ErrInvalidEmail):
+		utils.RespondError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrInvalidInvitation):
+		utils.RespondError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrInvitationMismatch):
+		utils.RespondError(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, domain.ErrNotFound):
+		utils.RespondError(w, "developer not found", http.StatusNotFound)
+	default:
+		slog.Error("organization operation failed", "error", err)
+		utils.RespondError(w, "internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+	"github.com/vivek-344/diagon/sigil/internal/middleware"
+	"github.com/vivek-344/diagon/sigil/internal/service"
+	"github.com/vivek-344/diagon/sigil/utils"
+)
+
+type PlanHandler struct {
+	planSvc *service.PlanService
+}
+
+func NewPlanHandler(planSvc *service.PlanService) *PlanHandler {
+	return &PlanHandler{planSvc: planSvc}
+}
+
+// Limits of -1 are unlimited
+type planResponse struct {
+	Tier              string `json:"tier"`
+	Name              string `json:"name"`
+	MaxProjects       int    `json:"max_projects"`
+	MaxAPIKeys        int    `json:"max_api_keys"`
+	MaxOrgMembers     int    `json:"max_org_members"`
+	RequestsPerMinute int    `json:"requests_per_minute"`
+}
+
+func newPlanResponse(plan domain.Plan) planResponse {
+	return planResponse{
+		Tier:              plan.Tier,
+		Name:              plan.Name,
+		MaxProjects:       plan.MaxProjects,
+		MaxAPIKeys:        plan.MaxAPIKeys,
+		MaxOrgMembers:     plan.MaxOrgMembers,
+		RequestsPerMinute: plan.RequestsPerMinute,
+	}
+}
+
+type quotaUsage struct {
+	Used  int `json:"used"`
+	Limit int `json:"limit"`
+}
+
+type usageResponse struct {
+	Plan       planResponse `json:"plan"`
+	Projects   quotaUsage   `json:"projects"`
+	APIKeys    *quotaUsage  `json:"api_keys,omitempty"`
+	OrgMembers *quotaUsage  `json:"org_members,omitempty"`
+}
+
+type quotaErrorResponse struct {
+	Error       string             `json:"error"`
+	Code        string             `json:"code"`
+	Plan        string             `json:"plan"`
+	Entitlement domain.Entitlement `json:"entitlement"`
+	Limit       int                `json:"limit"`
+}
+
+// List returns the plan catalog
+func (h *PlanHandler) List(w http.ResponseWriter, r *http.Request) {
+	plans := h.planSvc.Plans()
+
+	resp := make([]planResponse, 0, len(plans))
+	for _, plan := range plans {
+		resp = append(resp, newPlanResponse(plan))
+	}
+
+	utils.RespondSuccess(w, resp, http.StatusOK)
+}
+
+// Usage reports the caller's personal usage against their plan
+func (h *PlanHandler) Usage(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	usage, err := h.planSvc.Usage(r.Context(), developerID)
+	if err != nil {
+		h.respondPlanError(w, err)
+		return
+	}
+
+	utils.RespondSuccess(w, usageResponse{
+		Plan:     newPlanResponse(usage.Plan),
+		Projects: quotaUsage{Used: usage.Projects, Limit: usage.Plan.MaxProjects},
+		APIKeys:  &quotaUsage{Used: usage.APIKeys, Limit: usage.Plan.MaxAPIKeys},
+	}, http.StatusOK)
+}
+
+// OrganizationUsage reports an organization's usage against its owner's plan
+func (h *PlanHandler) OrganizationUsage(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		utils.RespondError(w, "invalid organization id", http.StatusBadRequest)
+		return
+	}
+
+	usage, err := h.planSvc.OrganizationUsage(r.Context(), developerID, orgID)
+	if err != nil {
+		h.respondPlanError(w, err)
+		return
+	}
+
+	utils.RespondSuccess(w, usageResponse{
+		Plan:       newPlanResponse(usage.Plan),
+		Projects:   quotaUsage{Used: usage.Projects, Limit: usage.Plan.MaxProjects},
+		OrgMembers: &quotaUsage{Used: usage.OrgMembers, Limit: usage.Plan.MaxOrgMembers},
+	}, http.StatusOK)
+}
+
+func (h *PlanHandler) respondPlanError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrForbidden):
+		utils.RespondError(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, domain.ErrOrganizationNotFound), errors.Is(err, domain.ErrNotOrganizationMember):
+		utils.RespondError(w, domain.ErrOrganizationNotFound.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrNotFound):
+		utils.RespondError(w, "developer not found", http.StatusNotFound)
+	default:
+		slog.Error("plan operation failed", "error", err)
+		utils.RespondError(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+// respondQuotaError answers 402 when err is a quota error and reports
+// whether it did, for use ahead of a handler's own error mapping
+func respondQuotaError(w http.ResponseWriter, err error) bool {
+	var quotaErr *domain.QuotaError
+	if !errors.As(err, &quotaErr) {
+		return false
+	}
+
+	utils.RespondSuccess(w, quotaErrorResponse{
+		Error:       quotaErr.Error(),
+		Code:        "quota_exceeded",
+		Plan:        quotaErr.Plan,
+		Entitlement: quotaErr.Entitlement,
+		Limit:       quotaErr.Limit,
+	}, http.StatusPaymentRequired)
+	return true
+}
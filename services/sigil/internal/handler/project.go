@@ -0,0 +1,397 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+	"github.com/vivek-344/diagon/sigil/internal/middleware"
+	"github.com/vivek-344/diagon/sigil/internal/service"
+	"github.com/vivek-344/diagon/sigil/utils"
+)
+
+type ProjectHandler struct {
+	projectSvc *service.ProjectService
+}
+
+func NewProjectHandler(projectSvc *service.ProjectService) *ProjectHandler {
+	return &ProjectHandler{projectSvc: projectSvc}
+}
+
+type createProjectRequest struct {
+	Name           string     `json:"name"`
+	Slug           string     `json:"slug"`
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty"`
+}
+
+type renameProjectRequest struct {
+	Name string `json:"name"`
+}
+
+type projectResponse struct {
+	ID             string     `json:"id"`
+	Name           string     `json:"name"`
+	Slug           string     `json:"slug"`
+	OwnerID        *uuid.UUID `json:"owner_id,omitempty"`
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty"`
+	Archived       bool       `json:"archived"`
+	ArchivedAt     *time.Time `json:"archived_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func newProjectResponse(project *domain.Project) projectResponse {
+	return projectResponse{
+		ID:             project.ID.String(),
+		Name:           project.Name,
+		Slug:           project.Slug,
+		OwnerID:        project.OwnerDeveloperID,
+		OrganizationID: project.OwnerOrganizationID,
+		Archived:       project.ArchivedAt != nil,
+		ArchivedAt:     project.ArchivedAt,
+		CreatedAt:      project.CreatedAt,
+		UpdatedAt:      project.UpdatedAt,
+	}
+}
+
+type environmentRequest struct {
+	Name domain.EnvironmentName `json:"name"`
+}
+
+type environmentResponse struct {
+	ID        string                 `json:"id"`
+	Name      domain.EnvironmentName `json:"name"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+func newEnvironmentResponse(env *domain.Environment) environmentResponse {
+	return environmentResponse{
+		ID:        env.ID.String(),
+		Name:      env.Name,
+		CreatedAt: env.CreatedAt,
+	}
+}
+
+// Create adds a project. Without organization_id it belongs to the token's
+// active organization, or to the caller when there is none.
+func (h *ProjectHandler) Create(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req createProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.OrganizationID == nil {
+		if orgID, _, ok := middleware.GetOrgFromContext(r.Context()); ok {
+			req.OrganizationID = &orgID
+		}
+	}
+
+	project, err := h.projectSvc.Create(r.Context(), developerID, domain.CreateProjectInput{
+		Name:           req.Name,
+		Slug:           req.Slug,
+		OrganizationID: req.OrganizationID,
+	})
+	if err != nil {
+		h.respondProjectError(w, err)
+		return
+	}
+
+	utils.RespondSuccess(w, newProjectResponse(project), http.StatusCreated)
+}
+
+// List returns the projects of ?organization_id=, defaulting like Create.
+// Archived projects are left out unless ?include_archived=true.
+func (h *ProjectHandler) List(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var orgID *uuid.UUID
+	if value := r.URL.Query().Get("organization_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			utils.RespondError(w, "invalid organization id", http.StatusBadRequest)
+			return
+		}
+		orgID = &id
+	} else if id, _, ok := middleware.GetOrgFromContext(r.Context()); ok {
+		orgID = &id
+	}
+
+	projects, err := h.projectSvc.List(r.Context(), developerID, orgID, r.URL.Query().Get("include_archived") == "true")
+	if err != nil {
+		h.respondProjectError(w, err)
+		return
+	}
+
+	resp := make([]projectResponse, 0, len(projects))
+	for _, project := range projects {
+		resp = append(resp, newProjectResponse(project))
+	}
+
+	utils.RespondSuccess(w, resp, http.StatusOK)
+}
+
+func (h *ProjectHandler) Get(w http.ResponseWriter, r *http.Request) {
+	developerID, projectID, ok := h.callerAndProject(w, r)
+	if !ok {
+		return
+	}
+
+	project, err := h.projectSvc.Get(r.Context(), developerID, projectID)
+	if err != nil {
+		h.respondProjectError(w, err)
+		return
+	}
+
+	utils.RespondSuccess(w, newProjectResponse(project), http.StatusOK)
+}
+
+// Rename changes the project's display name; the slug is permanent
+func (h *ProjectHandler) Rename(w http.ResponseWriter, r *http.Request) {
+	developerID, projectID, ok := h.callerAndProject(w, r)
+	if !ok {
+		return
+	}
+
+	var req renameProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.projectSvc.Rename(r.Context(), developerID, projectID, req.Name); err != nil {
+		h.respondProjectError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ProjectHandler) Archive(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, true)
+}
+
+func (h *ProjectHandler) Unarchive(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, false)
+}
+
+func (h *ProjectHandler) setArchived(w http.ResponseWriter, r *http.Request, archived bool) {
+	developerID, projectID, ok := h.callerAndProject(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.projectSvc.SetArchived(r.Context(), developerID, projectID, archived); err != nil {
+		h.respondProjectError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ProjectHandler) ListEnvironments(w http.ResponseWriter, r *http.Request) {
+	developerID, projectID, ok := h.callerAndProject(w, r)
+	if !ok {
+		return
+	}
+
+	environments, err := h.projectSvc.ListEnvironments(r.Context(), developerID, projectID)
+	if err != nil {
+		h.respondProjectError(w, err)
+		return
+	}
+
+	resp := make([]environmentResponse, 0, len(environments))
+	for _, env := range environments {
+		resp = append(resp, newEnvironmentResponse(env))
+	}
+
+	utils.RespondSuccess(w, resp, http.StatusOK)
+}
+
+func (h *ProjectHandler) AddEnvironment(w http.ResponseWriter, r *http.Request) {
+	developerID, projectID, ok := h.callerAndProject(w, r)
+	if !ok {
+		return
+	}
+
+	var req environmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	env, err := h.projectSvc.AddEnvironment(r.Context(), developerID, projectID, req.Name)
+	if err != nil {
+		h.respondProjectError(w, err)
+		return
+	}
+
+	utils.RespondSuccess(w, newEnvironmentResponse(env), http.StatusCreated)
+}
+
+func (h *ProjectHandler) RemoveEnvironment(w http.ResponseWriter, r *http.Request) {
+	developerID, projectID, ok := h.callerAndProject(w, r)
+	if !ok {
+		return
+	}
+
+	envID, err := uuid.Parse(chi.URLParam(r, "envID"))
+	if err != nil {
+		utils.RespondError(w, "invalid environment id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.projectSvc.RemoveEnvironment(r.Context(), developerID, projectID, envID); err != nil {
+		h.respondProjectError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ClientGet returns the project of the calling service client
+func (h *ProjectHandler) ClientGet(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := h.clientProject(w, r)
+	if !ok {
+		return
+	}
+
+	project, err := h.projectSvc.ClientProject(r.Context(), projectID)
+	if err != nil {
+		h.respondProjectError(w, err)
+		return
+	}
+
+	utils.RespondSuccess(w, newProjectResponse(project), http.StatusOK)
+}
+
+func (h *ProjectHandler) ClientListEnvironments(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := h.clientProject(w, r)
+	if !ok {
+		return
+	}
+
+	environments, err := h.projectSvc.ClientListEnvironments(r.Context(), projectID)
+	if err != nil {
+		h.respondProjectError(w, err)
+		return
+	}
+
+	resp := make([]environmentResponse, 0, len(environments))
+	for _, env := range environments {
+		resp = append(resp, newEnvironmentResponse(env))
+	}
+
+	utils.RespondSuccess(w, resp, http.StatusOK)
+}
+
+func (h *ProjectHandler) ClientAddEnvironment(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := h.clientProject(w, r)
+	if !ok {
+		return
+	}
+
+	var req environmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	env, err := h.projectSvc.ClientAddEnvironment(r.Context(), projectID, req.Name)
+	if err != nil {
+		h.respondProjectError(w, err)
+		return
+	}
+
+	utils.RespondSuccess(w, newEnvironmentResponse(env), http.StatusCreated)
+}
+
+func (h *ProjectHandler) ClientRemoveEnvironment(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := h.clientProject(w, r)
+	if !ok {
+		return
+	}
+
+	envID, err := uuid.Parse(chi.URLParam(r, "envID"))
+	if err != nil {
+		utils.RespondError(w, "invalid environment id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.projectSvc.ClientRemoveEnvironment(r.Context(), projectID, envID); err != nil {
+		h.respondProjectError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// clientProject reads the project a service client token is bound to;
+// developer credentials are refused
+func (h *ProjectHandler) clientProject(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	if principal, _ := middleware.GetPrincipalFromContext(r.Context()); principal != domain.PrincipalServiceClient {
+		utils.RespondError(w, "service client token required", http.StatusForbidden)
+		return uuid.Nil, false
+	}
+	_, projectID, ok := middleware.GetServiceClientFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "token is not bound to a project", http.StatusForbidden)
+		return uuid.Nil, false
+	}
+	return projectID, true
+}
+
+// callerAndProject reads the caller and the {projectID} path parameter
+func (h *ProjectHandler) callerAndProject(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	projectID, err := uuid.Parse(chi.URLParam(r, "projectID"))
+	if err != nil {
+		utils.RespondError(w, "invalid project id", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return developerID, projectID, true
+}
+
+func (h *ProjectHandler) respondProjectError(w http.ResponseWriter, err error) {
+	if respondQuotaError(w, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, domain.ErrForbidden):
+		utils.RespondError(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, domain.ErrProjectNotFound), errors.Is(err, domain.ErrEnvironmentNotFound):
+		utils.RespondError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrNotOrganizationMember):
+		utils.RespondError(w, domain.ErrOrganizationNotFound.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrProjectSlugTaken), errors.Is(err, domain.ErrEnvironmentExists), errors.Is(err, domain.ErrProjectArchived):
+		utils.RespondError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, domain.ErrInvalidProject), errors.Is(err, domain.ErrInvalidEnvironment):
+		utils.RespondError(w, err.Error(), http.StatusBadRequest)
+	default:
+		slog.Error("project operation failed", "error", err)
+		utils.RespondError(w, "internal server error", http.StatusInternalServerError)
+	}
+}
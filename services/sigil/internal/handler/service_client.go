@@ -0,0 +1,223 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+	"github.com/vivek-344/diagon/sigil/internal/middleware"
+	"github.com/vivek-344/diagon/sigil/internal/service"
+	"github.com/vivek-344/diagon/sigil/utils"
+)
+
+type ServiceClientHandler struct {
+	serviceClientSvc *service.ServiceClientService
+}
+
+func NewServiceClientHandler(serviceClientSvc *service.ServiceClientService) *ServiceClientHandler {
+	return &ServiceClientHandler{serviceClientSvc: serviceClientSvc}
+}
+
+type createServiceClientRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+type rotateClientSecretRequest struct {
+	OverlapSeconds *int64 `json:"overlap_seconds,omitempty"`
+}
+
+type clientSecretResponse struct {
+	Prefix    string     `json:"prefix"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type serviceClientResponse struct {
+	ID         string                 `json:"id"`
+	ClientID   string                 `json:"client_id"`
+	ProjectID  string                 `json:"project_id"`
+	Name       string                 `json:"name"`
+	Scopes     []string               `json:"scopes"`
+	Secrets    []clientSecretResponse `json:"secrets"`
+	LastUsedAt *time.Time             `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+type createServiceClientResponse struct {
+	serviceClientResponse
+	ClientSecret string `json:"client_secret"`
+}
+
+type rotateClientSecretResponse struct {
+	ClientSecret string `json:"client_secret"`
+}
+
+func newServiceClientResponse(client *domain.ServiceClient) serviceClientResponse {
+	secrets := make([]clientSecretResponse, 0, len(client.Secrets))
+	for _, secret := range client.Secrets {
+		secrets = append(secrets, clientSecretResponse{
+			Prefix:    secret.Prefix,
+			ExpiresAt: secret.ExpiresAt,
+			CreatedAt: secret.CreatedAt,
+		})
+	}
+
+	return serviceClientResponse{
+		ID:         client.ID.String(),
+		ClientID:   client.ClientID,
+		ProjectID:  client.ProjectID.String(),
+		Name:       client.Name,
+		Scopes:     client.Scopes,
+		Secrets:    secrets,
+		LastUsedAt: client.LastUsedAt,
+		CreatedAt:  client.CreatedAt,
+	}
+}
+
+// Create registers a service client. The secret is only ever returned here.
+func (h *ServiceClientHandler) Create(w http.ResponseWriter, r *http.Request) {
+	developerID, projectID, ok := h.callerAndProject(w, r)
+	if !ok {
+		return
+	}
+
+	var req createServiceClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	secret, client, err := h.serviceClientSvc.Create(r.Context(), developerID, projectID, req.Name, req.Scopes, trustedCaller(r))
+	if err != nil {
+		h.respondServiceClientError(w, err)
+		return
+	}
+
+	utils.RespondSuccess(w, createServiceClientResponse{
+		serviceClientResponse: newServiceClientResponse(client),
+		ClientSecret:          secret,
+	}, http.StatusCreated)
+}
+
+func (h *ServiceClientHandler) List(w http.ResponseWriter, r *http.Request) {
+	developerID, projectID, ok := h.callerAndProject(w, r)
+	if !ok {
+		return
+	}
+
+	clients, err := h.serviceClientSvc.List(r.Context(), developerID, projectID)
+	if err != nil {
+		h.respondServiceClientError(w, err)
+		return
+	}
+
+	resp := make([]serviceClientResponse, 0, len(clients))
+	for _, client := range clients {
+		resp = append(resp, newServiceClientResponse(client))
+	}
+
+	utils.RespondSuccess(w, resp, http.StatusOK)
+}
+
+// RotateSecret issues a new secret. The previous ones keep working for
+// overlap_seconds (default one day) so deployments can pick up the new one.
+func (h *ServiceClientHandler) RotateSecret(w http.ResponseWriter, r *http.Request) {
+	developerID, projectID, ok := h.callerAndProject(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "clientID"))
+	if err != nil {
+		utils.RespondError(w, "invalid client id", http.StatusBadRequest)
+		return
+	}
+
+	// The body is optional
+	var req rotateClientSecretRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		utils.RespondError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	overlap := service.DefaultSecretOverlap
+	if req.OverlapSeconds != nil {
+		overlap = time.Duration(*req.OverlapSeconds) * time.Second
+	}
+
+	secret, err := h.serviceClientSvc.RotateSecret(r.Context(), developerID, projectID, id, overlap, trustedCaller(r))
+	if err != nil {
+		h.respondServiceClientError(w, err)
+		return
+	}
+
+	utils.RespondSuccess(w, rotateClientSecretResponse{ClientSecret: secret}, http.StatusOK)
+}
+
+func (h *ServiceClientHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	developerID, projectID, ok := h.callerAndProject(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "clientID"))
+	if err != nil {
+		utils.RespondError(w, "invalid client id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.serviceClientSvc.Revoke(r.Context(), developerID, projectID, id, trustedCaller(r)); err != nil {
+		h.respondServiceClientError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// trustedCaller reports whether the caller may grant and manage token
+// introspection and revocation, which reach every developer
+func trustedCaller(r *http.Request) bool {
+	actor, ok := middleware.GetActorFromContext(r.Context())
+	return ok && actor.Role.CanTrustClients()
+}
+
+// callerAndProject reads the caller and the {projectID} path parameter
+func (h *ServiceClientHandler) callerAndProject(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	projectID, err := uuid.Parse(chi.URLParam(r, "projectID"))
+	if err != nil {
+		utils.RespondError(w, "invalid project id", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return developerID, projectID, true
+}
+
+func (h *ServiceClientHandler) respondServiceClientError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrForbidden):
+		utils.RespondError(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, domain.ErrProjectNotFound), errors.Is(err, domain.ErrServiceClientNotFound):
+		utils.RespondError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrProjectArchived):
+		utils.RespondError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, domain.ErrInvalidInput), errors.Is(err, domain.ErrInvalidScope):
+		utils.RespondError(w, err.Error(), http.StatusBadRequest)
+	default:
+		slog.Error("service client operation failed", "error", err)
+		utils.RespondError(w, "internal server error", http.StatusInternalServerError)
+	}
+}
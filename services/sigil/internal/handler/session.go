@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+	"github.com/vivek-344/diagon/sigil/internal/middleware"
+	"github.com/vivek-344/diagon/sigil/internal/service"
+	"github.com/vivek-344/diagon/sigil/utils"
+)
+
+type SessionHandler struct {
+	authSvc *service.AuthService
+}
+
+func NewSessionHandler(authSvc *service.AuthService) *SessionHandler {
+	return &SessionHandler{authSvc: authSvc}
+}
+
+type sessionResponse struct {
+	ID             string     `json:"id"`
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty"`
+	OAuthClientID  *uuid.UUID `json:"oauth_client_id,omitempty"`
+	Scopes         []string   `json:"scopes,omitempty"`
+	DeviceName     *string    `json:"device_name,omitempty"`
+	IPAddress      string     `json:"ip_address"`
+	UserAgent      string     `json:"user_agent"`
+	Current        bool       `json:"current"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastUsedAt     time.Time  `json:"last_used_at"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+}
+
+type switchOrganizationRequest struct {
+	OrganizationID *uuid.UUID `json:"organization_id"`
+}
+
+type switchOrganizationResponse struct {
+	AccessToken string `json:"access_token"`
+}
+
+// Logout revokes the session the access token belongs to
+func (h *SessionHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	sessionID, ok := middleware.GetSessionIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "token is not bound to a session", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.authSvc.RevokeSession(r.Context(), developerID, sessionID); err != nil && !errors.Is(err, domain.ErrSessionNotFound) {
+		slog.Error("failed to log out", "error", err)
+		utils.RespondError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// List returns the developer's active sessions
+func (h *SessionHandler) List(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	currentID, _ := middleware.GetSessionIDFromContext(r.Context())
+
+	sessions, err := h.authSvc.ListSessions(r.Context(), developerID)
+	if err != nil {
+		slog.Error("failed to list sessions", "error", err)
+		utils.RespondError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]sessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		resp = append(resp, sessionResponse{
+			ID:             session.ID.String(),
+			OrganizationID: session.OrganizationID,
+			OAuthClientID:  session.OAuthClientID,
+			Scopes:         session.Scopes,
+			DeviceName:     session.DeviceName,
+			IPAddress:      session.IPAddress,
+			UserAgent:      session.UserAgent,
+			Current:        session.ID == currentID,
+			CreatedAt:      session.CreatedAt,
+			LastUsedAt:     session.LastUsedAt,
+			ExpiresAt:      session.ExpiresAt,
+		})
+	}
+
+	utils.RespondSuccess(w, resp, http.StatusOK)
+}
+
+// Revoke signs out one of the developer's sessions
+func (h *SessionHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sessionID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondError(w, "invalid session id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.authSvc.RevokeSession(r.Context(), developerID, sessionID); err != nil {
+		if errors.Is(err, domain.ErrSessionNotFound) {
+			utils.RespondError(w, "session not found", http.StatusNotFound)
+			return
+		}
+		slog.Error("failed to revoke session", "error", err)
+		utils.RespondError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeOthers signs out every session except the current one
+func (h *SessionHandler) RevokeOthers(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	// Without a session there is no "current" one to keep
+	currentID, ok := middleware.GetSessionIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "token is not bound to a session", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.authSvc.RevokeOtherSessions(r.Context(), developerID, currentID); err != nil {
+		slog.Error("failed to revoke sessions", "error", err)
+		utils.RespondError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SwitchOrganization sets the organization the current session acts within
+// and returns a fresh access token carrying org_id and org_role. Later
+// refreshes keep the context; a null organization_id returns to personal use.
+func (h *SessionHandler) SwitchOrganization(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	sessionID, ok := middleware.GetSessionIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "token is not bound to a session", http.StatusBadRequest)
+		return
+	}
+
+	var req switchOrganizationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	accessToken, err := h.authSvc.SwitchOrganization(r.Context(), developerID, sessionID, req.OrganizationID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotOrganizationMember):
+			utils.RespondError(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, domain.ErrSessionNotFound):
+			utils.RespondError(w, "session has been revoked", http.StatusUnauthorized)
+		case errors.Is(err, domain.ErrNotFound):
+			utils.RespondError(w, "developer not found", http.StatusNotFound)
+		default:
+			slog.Error("failed to switch organization", "error", err)
+			utils.RespondError(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	utils.RespondSuccess(w, switchOrganizationResponse{AccessToken: accessToken}, http.StatusOK)
+}
+
+// clientInfo collects device details; RemoteAddr is already rewritten by middleware.RealIP
+func clientInfo(r *http.Request, deviceName *string) domain.ClientInfo {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	return domain.ClientInfo{
+		DeviceName: deviceName,
+		IPAddress:  ip,
+		UserAgent:  r.UserAgent(),
+	}
+}
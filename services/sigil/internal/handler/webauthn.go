@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/google/uuid"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+	"github.com/vivek-344/diagon/sigil/internal/middleware"
+	"github.com/vivek-344/diagon/sigil/internal/service"
+	"github.com/vivek-344/diagon/sigil/utils"
+)
+
+type WebAuthnHandler struct {
+	developerSvc *service.DeveloperService
+	webauthnSvc  *service.WebAuthnService
+}
+
+func NewWebAuthnHandler(developerSvc *service.DeveloperService, webauthnSvc *service.WebAuthnService) *WebAuthnHandler {
+	return &WebAuthnHandler{
+		developerSvc: developerSvc,
+		webauthnSvc:  webauthnSvc,
+	}
+}
+
+type webauthnBeginResponse struct {
+	ChallengeID string `json:"challenge_id"`
+	Options     any    `json:"options"`
+}
+
+type webauthnLoginBeginRequest struct {
+	Email string `json:"email"`
+}
+
+type webauthnCredentialResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+func newWebAuthnCredentialResponse(credential *domain.WebAuthnCredential) webauthnCredentialResponse {
+	return webauthnCredentialResponse{
+		ID:         credential.ID.String(),
+		Name:       credential.Name,
+		CreatedAt:  credential.CreatedAt,
+		LastUsedAt: credential.LastUsedAt,
+	}
+}
+
+// BeginRegistration returns creation options for navigator.credentials.create
+func (h *WebAuthnHandler) BeginRegistration(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	challengeID, options, err := h.webauthnSvc.BeginRegistration(r.Context(), developerID)
+	if err != nil {
+		h.respondWebAuthnError(w, err)
+		return
+	}
+
+	utils.RespondSuccess(w, webauthnBeginResponse{
+		ChallengeID: challengeID.String(),
+		Options:     options,
+	}, http.StatusOK)
+}
+
+// FinishRegistration takes the authenticator response as the body, with
+// challenge_id and an optional name in the query string
+func (h *WebAuthnHandler) FinishRegistration(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	challengeID, err := uuid.Parse(r.URL.Query().Get("challenge_id"))
+	if err != nil {
+		utils.RespondError(w, "invalid challenge id", http.StatusBadRequest)
+		return
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBody(r.Body)
+	if err != nil {
+		utils.RespondError(w, "invalid credential response", http.StatusBadRequest)
+		return
+	}
+
+	credential, err := h.webauthnSvc.FinishRegistration(r.Context(), developerID, challengeID, r.URL.Query().Get("name"), parsed)
+	if err != nil {
+		h.respondWebAuthnError(w, err)
+		return
+	}
+
+	utils.RespondSuccess(w, newWebAuthnCredentialResponse(credential), http.StatusCreated)
+}
+
+// BeginLogin returns request options for navigator.credentials.get; leave
+// email empty to let the browser offer any discoverable passkey
+func (h *WebAuthnHandler) BeginLogin(w http.ResponseWriter, r *http.Request) {
+	var req webauthnLoginBeginRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	challengeID, options, err := h.webauthnSvc.BeginLogin(r.Context(), req.Email)
+	if err != nil {
+		h.respondWebAuthnError(w, err)
+		return
+	}
+
+	utils.RespondSuccess(w, webauthnBeginResponse{
+		ChallengeID: challengeID.String(),
+		Options:     options,
+	}, http.StatusOK)
+}
+
+// FinishLogin verifies the assertion and returns the same payload as Login
+func (h *WebAuthnHandler) FinishLogin(w http.ResponseWriter, r *http.Request) {
+	challengeID, err := uuid.Parse(r.URL.Query().Get("challenge_id"))
+	if err != nil {
+		utils.RespondError(w, "invalid challenge id", http.StatusBadRequest)
+		return
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBody(r.Body)
+	if err != nil {
+		utils.RespondError(w, "invalid credential response", http.StatusBadRequest)
+		return
+	}
+
+	dev, tokens, err := h.webauthnSvc.FinishLogin(r.Context(), challengeID, parsed, clientInfo(r, nil))
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAccountSuspended):
+			utils.RespondError(w, "account suspended", http.StatusForbidden)
+		default:
+			h.respondWebAuthnError(w, err)
+		}
+		return
+	}
+
+	if err := h.developerSvc.UpdateLastLogin(r.Context(), dev.ID); err != nil {
+		slog.Warn("failed to update last login", "error", err)
+	}
+
+	utils.RespondSuccess(w, newLoginResponse(dev, tokens), http.StatusOK)
+}
+
+// ListCredentials returns the developer's registered passkeys
+func (h *WebAuthnHandler) ListCredentials(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	credentials, err := h.webauthnSvc.ListCredentials(r.Context(), developerID)
+	if err != nil {
+		h.respondWebAuthnError(w, err)
+		return
+	}
+
+	resp := make([]webauthnCredentialResponse, 0, len(credentials))
+	for _, credential := range credentials {
+		resp = append(resp, newWebAuthnCredentialResponse(credential))
+	}
+
+	utils.RespondSuccess(w, resp, http.StatusOK)
+}
+
+// DeleteCredential removes one of the developer's passkeys
+func (h *WebAuthnHandler) DeleteCredential(w http.ResponseWriter, r *http.Request) {
+	developerID, ok := middleware.GetDeveloperIDFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondError(w, "invalid credential id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.webauthnSvc.DeleteCredential(r.Context(), developerID, id); err != nil {
+		h.respondWebAuthnError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *WebAuthnHandler) respondWebAuthnError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrChallengeNotFound):
+		utils.RespondError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrWebAuthnFailed), errors.Is(err, domain.ErrCredentialCloned):
+		utils.RespondError(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, domain.ErrCredentialExists):
+		utils.RespondError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, domain.ErrCredentialNotFound):
+		utils.RespondError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrNotFound):
+		utils.RespondError(w, "developer not found", http.StatusNotFound)
+	default:
+		slog.Error("webauthn operation failed", "error", err)
+		utils.RespondError(w, "internal server error", http.StatusInternalServerError)
+	}
+}
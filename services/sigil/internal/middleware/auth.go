@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"strings"
 
@@ -10,10 +11,21 @@ import (
 	"github.com/vivek-344/diagon/sigil/utils"
 )
 
-// AuthMiddleware validates JWT tokens and adds claims to context
-func AuthMiddleware(jwtSecret string) func(http.Handler) http.Handler {
+// AuthMiddleware validates JWT tokens or API keys and adds the caller to context.
+// API keys are accepted in the X-API-Key header or as a Bearer token. Service
+// client tokens carry no developer; GetPrincipalFromContext tells them apart.
+func AuthMiddleware(
+	jwtManager *utils.JWTManager,
+	revocations domain.SessionRevocationChecker,
+	apiKeys domain.APIKeyAuthenticator,
+) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if apiKey := r.Header.Get("X-API-Key"); apiKey != "" {
+				serveWithAPIKey(w, r, next, apiKeys, apiKey)
+				return
+			}
+
 			// Extract token from Authorization header
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
@@ -30,26 +42,96 @@ func AuthMiddleware(jwtSecret string) func(http.Handler) http.Handler {
 
 			tokenString := parts[1]
 
+			if strings.HasPrefix(tokenString, domain.APIKeyPrefix) {
+				serveWithAPIKey(w, r, next, apiKeys, tokenString)
+				return
+			}
+
 			// Validate token
-			claims, err := utils.ValidateToken(tokenString, jwtSecret)
+			claims, err := jwtManager.ValidateAccessToken(tokenString)
 			if err != nil {
 				if err == utils.ErrExpiredToken {
 					http.Error(w, `{"error": "token has expired"}`, http.StatusUnauthorized)
 					return
 				}
+				if err == utils.ErrWrongTokenType {
+					http.Error(w, `{"error": "access token required"}`, http.StatusUnauthorized)
+					return
+				}
 				http.Error(w, `{"error": "invalid token"}`, http.StatusUnauthorized)
 				return
 			}
 
+			if claims.TokenType == utils.TokenTypeClient {
+				ctx := context.WithValue(r.Context(), domain.PrincipalKey, domain.PrincipalServiceClient)
+				ctx = context.WithValue(ctx, domain.ClientIDKey, claims.ClientID)
+				if claims.ProjectID != nil {
+					ctx = context.WithValue(ctx, domain.ProjectIDKey, *claims.ProjectID)
+				}
+				ctx = context.WithValue(ctx, domain.ScopesKey, claims.Scopes())
+
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			// Reject tokens of signed-out sessions
+			if claims.SessionID != uuid.Nil && revocations.IsRevoked(claims.SessionID) {
+				http.Error(w, `{"error": "session has been revoked"}`, http.StatusUnauthorized)
+				return
+			}
+
 			// Add claims to context
-			ctx := context.WithValue(r.Context(), domain.DeveloperIDKey, claims.DeveloperID)
+			ctx := context.WithValue(r.Context(), domain.PrincipalKey, domain.PrincipalDeveloper)
+			ctx = context.WithValue(ctx, domain.DeveloperIDKey, claims.DeveloperID)
 			ctx = context.WithValue(ctx, domain.EmailKey, claims.Email)
+			ctx = context.WithValue(ctx, domain.RoleKey, domain.Role(claims.Role))
+			ctx = context.WithValue(ctx, domain.SessionIDKey, claims.SessionID)
+			ctx = context.WithValue(ctx, domain.ScopesKey, claims.Scopes())
+			if claims.OrgID != nil {
+				ctx = context.WithValue(ctx, domain.OrgIDKey, *claims.OrgID)
+				ctx = context.WithValue(ctx, domain.OrgRoleKey, domain.OrgRole(claims.OrgRole))
+			}
+			if claims.ClientID != "" {
+				// Delegated to a third-party OAuth client
+				ctx = context.WithValue(ctx, domain.ClientIDKey, claims.ClientID)
+			}
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+func serveWithAPIKey(w http.ResponseWriter, r *http.Request, next http.Handler, apiKeys domain.APIKeyAuthenticator, rawKey string) {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+
+	key, dev, err := apiKeys.AuthenticateAPIKey(r.Context(), rawKey, ip)
+	if err != nil {
+		switch err {
+		case domain.ErrExpiredAPIKey:
+			http.Error(w, `{"error": "api key has expired"}`, http.StatusUnauthorized)
+		case domain.ErrAccountSuspended:
+			http.Error(w, `{"error": "account suspended"}`, http.StatusForbidden)
+		case domain.ErrInvalidAPIKey:
+			http.Error(w, `{"error": "invalid api key"}`, http.StatusUnauthorized)
+		default:
+			http.Error(w, `{"error": "internal server error"}`, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), domain.PrincipalKey, domain.PrincipalDeveloper)
+	ctx = context.WithValue(ctx, domain.DeveloperIDKey, dev.ID)
+	ctx = context.WithValue(ctx, domain.EmailKey, dev.Email)
+	ctx = context.WithValue(ctx, domain.RoleKey, dev.Role)
+	ctx = context.WithValue(ctx, domain.APIKeyIDKey, key.ID)
+	ctx = context.WithValue(ctx, domain.ScopesKey, key.Scopes)
+
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
 // GetDeveloperIDFromContext extracts developer ID from context
 func GetDeveloperIDFromContext(ctx context.Context) (uuid.UUID, bool) {
 	id, ok := ctx.Value(domain.DeveloperIDKey).(uuid.UUID)
@@ -61,3 +143,87 @@ func GetEmailFromContext(ctx context.Context) (string, bool) {
 	email, ok := ctx.Value(domain.EmailKey).(string)
 	return email, ok
 }
+
+// GetActorFromContext returns the authenticated developer and their role.
+// Staff privileges only apply when the credential also carries the admin
+// scope, so a narrow API key of an admin acts as a plain developer.
+func GetActorFromContext(ctx context.Context) (domain.Actor, bool) {
+	id, ok := GetDeveloperIDFromContext(ctx)
+	if !ok {
+		return domain.Actor{}, false
+	}
+	role, _ := ctx.Value(domain.RoleKey).(domain.Role)
+	if !role.Valid() || !domain.HasScope(GetScopesFromContext(ctx), domain.ScopeAdmin) {
+		role = domain.RoleDeveloper
+	}
+	return domain.Actor{ID: id, Role: role}, true
+}
+
+// GetSessionIDFromContext extracts the session ID from context
+func GetSessionIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	id, ok := ctx.Value(domain.SessionIDKey).(uuid.UUID)
+	return id, ok && id != uuid.Nil
+}
+
+// IsFirstPartySession reports whether the request carries a developer's own
+// session token, as opposed to an API key or a token issued to a client
+func IsFirstPartySession(ctx context.Context) bool {
+	if _, viaKey := GetAPIKeyIDFromContext(ctx); viaKey {
+		return false
+	}
+	if _, viaClient := ctx.Value(domain.ClientIDKey).(string); viaClient {
+		return false
+	}
+	_, ok := GetSessionIDFromContext(ctx)
+	return ok
+}
+
+// GetAPIKeyIDFromContext reports the API key the request authenticated with, if any
+func GetAPIKeyIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	id, ok := ctx.Value(domain.APIKeyIDKey).(uuid.UUID)
+	return id, ok
+}
+
+// GetScopesFromContext returns the scopes granted to the request's credential
+func GetScopesFromContext(ctx context.Context) []string {
+	scopes, _ := ctx.Value(domain.ScopesKey).([]string)
+	return scopes
+}
+
+// GetOrgFromContext returns the organization the access token acts within, if
+// any. The role is as of token issue; authorization decisions re-check it.
+func GetOrgFromContext(ctx context.Context) (uuid.UUID, domain.OrgRole, bool) {
+	id, ok := ctx.Value(domain.OrgIDKey).(uuid.UUID)
+	if !ok {
+		return uuid.Nil, "", false
+	}
+	role, _ := ctx.Value(domain.OrgRoleKey).(domain.OrgRole)
+	return id, role, true
+}
+
+// GetPrincipalFromContext reports what kind of caller authenticated the request
+func GetPrincipalFromContext(ctx context.Context) (domain.PrincipalType, bool) {
+	principal, ok := ctx.Value(domain.PrincipalKey).(domain.PrincipalType)
+	return principal, ok
+}
+
+// GetServiceClientFromContext returns the client ID and project of a service
+// client caller
+func GetServiceClientFromContext(ctx context.Context) (string, uuid.UUID, bool) {
+	clientID, ok := ctx.Value(domain.ClientIDKey).(string)
+	if !ok {
+		return "", uuid.Nil, false
+	}
+	projectID, ok := ctx.Value(domain.ProjectIDKey).(uuid.UUID)
+	return clientID, projectID, ok
+}
+
+// GetOAuthClientFromContext returns the OAuth client a developer's token was
+// issued to; it is false for first-party logins and API keys
+func GetOAuthClientFromContext(ctx context.Context) (string, bool) {
+	if principal, _ := GetPrincipalFromContext(ctx); principal != domain.PrincipalDeveloper {
+		return "", false
+	}
+	clientID, ok := ctx.Value(domain.ClientIDKey).(string)
+	return clientID, ok
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+)
+
+// RateLimit enforces the developer's requests_per_minute entitlement.
+// It must run after AuthMiddleware; service clients are not limited.
+func RateLimit(limiter domain.RequestLimiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			developerID, ok := GetDeveloperIDFromContext(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if allowed, retryAfter := limiter.Allow(r.Context(), developerID); !allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				http.Error(w, `{"error": "rate limit exceeded", "code": "rate_limited"}`, http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
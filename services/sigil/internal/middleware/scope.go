@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+)
+
+// RequireScopes rejects requests whose credential lacks any of the given scopes.
+// It must run after AuthMiddleware.
+func RequireScopes(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			granted := GetScopesFromContext(r.Context())
+
+			for _, scope := range scopes {
+				if !domain.HasScope(granted, scope) {
+					w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q`, scope))
+					http.Error(w, fmt.Sprintf(`{"error": "insufficient scope", "missing_scope": %q}`, scope), http.StatusForbidden)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireFirstPartySession rejects API keys and client tokens, leaving only
+// developers signed in to a session. It must run after AuthMiddleware.
+func RequireFirstPartySession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IsFirstPartySession(r.Context()) {
+			http.Error(w, `{"error": "a first-party session is required"}`, http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+)
+
+type apiKeyRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewAPIKeyRepository(db *pgxpool.Pool) domain.APIKeyRepository {
+	return &apiKeyRepo{db: db}
+}
+
+func (r *apiKeyRepo) Create(ctx context.Context, key *domain.APIKey) error {
+	query := `
+		INSERT INTO api_keys (
+			developer_id, name, prefix, key_hash, scopes, expires_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`
+
+	return r.db.QueryRow(
+		ctx, query, key.DeveloperID, key.Name, key.Prefix, key.KeyHash, key.Scopes, key.ExpiresAt,
+	).Scan(&key.ID, &key.CreatedAt)
+}
+
+func (r *apiKeyRepo) GetByHash(ctx context.Context, keyHash string) (*domain.APIKey, error) {
+	query := `
+		SELECT id, developer_id, name, prefix, key_hash, scopes, expires_at,
+		       revoked_at, last_used_at, last_used_ip, created_at
+		FROM api_keys WHERE key_hash = $1`
+
+	key := &domain.APIKey{}
+	err := r.db.QueryRow(ctx, query, keyHash).Scan(
+		&key.ID, &key.DeveloperID, &key.Name, &key.Prefix, &key.KeyHash, &key.Scopes, &key.ExpiresAt,
+		&key.RevokedAt, &key.LastUsedAt, &key.LastUsedIP, &key.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (r *apiKeyRepo) ListActive(ctx context.Context, developerID uuid.UUID) ([]*domain.APIKey, error) {
+	query := `
+		SELECT id, developer_id, name, prefix, key_hash, scopes, expires_at,
+		       revoked_at, last_used_at, last_used_ip, created_at
+		FROM api_keys
+		WHERE developer_id = $1 AND revoked_at IS NULL
+		  AND (expires_at IS NULL OR expires_at > NOW())
+		ORDER BY created_at DESC`
+
+	rows, err := r.db.Query(ctx, query, developerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []*domain.APIKey{}
+
+	for rows.Next() {
+		key := &domain.APIKey{}
+		if err := rows.Scan(
+			&key.ID,
+			&key.DeveloperID,
+			&key.Name,
+			&key.Prefix,
+			&key.KeyHash,
+			&key.Scopes,
+			&key.ExpiresAt,
+			&key.RevokedAt,
+			&key.LastUsedAt,
+			&key.LastUsedIP,
+			&key.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (r *apiKeyRepo) Revoke(ctx context.Context, id uuid.UUID, developerID uuid.UUID) error {
+	query := `
+		UPDATE api_keys
+		SET revoked_at = NOW()
+		WHERE id = $1 AND developer_id = $2 AND revoked_at IS NULL`
+
+	res, err := r.db.Exec(ctx, query, id, developerID)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return domain.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// Touch records usage, writing at most once a minute per key unless the IP changes
+func (r *apiKeyRepo) Touch(ctx context.Context, id uuid.UUID, ipAddress string) error {
+	query := `
+		UPDATE api_keys
+		SET last_used_at = NOW(), last_used_ip = $2
+		WHERE id = $1
+		  AND (last_used_at IS NULL
+		       OR last_used_at < NOW() - INTERVAL '1 minute'
+		       OR last_used_ip IS DISTINCT FROM $2)`
+
+	_, err := r.db.Exec(ctx, query, id, ipAddress)
+	return err
+}
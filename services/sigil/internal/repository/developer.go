@@ -57,7 +57,7 @@ func (r *developerRepo) Create(ctx context.Context, input *domain.CreateDevelope
 
 func (r *developerRepo) VerifyEmail(ctx context.Context, id uuid.UUID) error {
 	query := `
-		UPDATE developers SET email_verified = true
+		UPDATE developers SET email_verified = true, version = version + 1
 		WHERE id = $1 AND status != 'deleted'`
 
 	res, err := r.db.Exec(ctx, query, id)
@@ -73,8 +73,8 @@ func (r *developerRepo) VerifyEmail(ctx context.Context, id uuid.UUID) error {
 func (r *developerRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Developer, error) {
 	query := `
 		SELECT id, email, password_hash, full_name, company_name,
-		       status, email_verified, plan_tier, created_at, 
-		       updated_at, last_login_at, metadata
+		       status, role, email_verified, plan_tier, created_at, 
+		       updated_at, version, last_login_at, metadata
 		FROM developers WHERE id = $1 AND status != 'deleted'`
 
 	dev := &domain.Developer{}
@@ -83,8 +83,8 @@ func (r *developerRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Deve
 
 	err := r.db.QueryRow(ctx, query, id).Scan(
 		&dev.ID, &dev.Email, &dev.PasswordHash, &dev.FullName, &dev.CompanyName,
-		&dev.Status, &dev.EmailVerified, &dev.PlanTier, &dev.CreatedAt,
-		&dev.UpdatedAt, &lastLogin, &metadata,
+		&dev.Status, &dev.Role, &dev.EmailVerified, &dev.PlanTier, &dev.CreatedAt,
+		&dev.UpdatedAt, &dev.Version, &lastLogin, &metadata,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -104,8 +104,8 @@ func (r *developerRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Deve
 func (r *developerRepo) GetByEmail(ctx context.Context, email string) (*domain.Developer, error) {
 	query := `
 		SELECT id, email, password_hash, full_name, company_name,
-		       status, email_verified, plan_tier, created_at, 
-		       updated_at, last_login_at, metadata
+		       status, role, email_verified, plan_tier, created_at, 
+		       updated_at, version, last_login_at, metadata
 		FROM developers WHERE email = $1 AND status != 'deleted'`
 
 	dev := &domain.Developer{}
@@ -114,8 +114,8 @@ func (r *developerRepo) GetByEmail(ctx context.Context, email string) (*domain.D
 
 	err := r.db.QueryRow(ctx, query, email).Scan(
 		&dev.ID, &dev.Email, &dev.PasswordHash, &dev.FullName, &dev.CompanyName,
-		&dev.Status, &dev.EmailVerified, &dev.PlanTier, &dev.CreatedAt,
-		&dev.UpdatedAt, &lastLogin, &metadata,
+		&dev.Status, &dev.Role, &dev.EmailVerified, &dev.PlanTier, &dev.CreatedAt,
+		&dev.UpdatedAt, &dev.Version, &lastLogin, &metadata,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -132,12 +132,27 @@ func (r *developerRepo) GetByEmail(ctx context.Context, email string) (*domain.D
 	return dev, nil
 }
 
-func (r *developerRepo) GetAll(ctx context.Context, filter domain.DeveloperFilter, page int, pageSize int) ([]*domain.Developer, error) {
-	if page <= 0 {
-		page = 1
+// developerSortColumns maps sort fields to the expressions the keyset indexes cover
+var developerSortColumns = map[domain.DeveloperSort]string{
+	domain.SortByCreatedAt:   "created_at",
+	domain.SortByUpdatedAt:   "updated_at",
+	domain.SortByLastLoginAt: "COALESCE(last_login_at, 'epoch'::timestamptz)",
+	domain.SortByEmail:       "email",
+}
+
+func (r *developerRepo) GetAll(ctx context.Context, filter domain.DeveloperFilter, page domain.DeveloperPageRequest) (*domain.DeveloperPage, error) {
+	if page.Limit <= 0 {
+		page.Limit = 20
+	}
+	if page.Sort == "" {
+		page.Sort = domain.SortByCreatedAt
 	}
-	if pageSize <= 0 {
-		pageSize = 20
+	sortColumn, ok := developerSortColumns[page.Sort]
+	if !ok {
+		return nil, domain.ErrInvalidInput
+	}
+	if page.After != nil && (page.After.Sort != page.Sort || page.After.Ascending != page.Ascending) {
+		return nil, domain.ErrInvalidCursor
 	}
 
 	var (
@@ -146,6 +161,12 @@ func (r *developerRepo) GetAll(ctx context.Context, filter domain.DeveloperFilte
 		argPos       = 1
 	)
 
+	arg := func(value any) string {
+		args = append(args, value)
+		argPos++
+		return "$" + fmt.Sprint(argPos-1)
+	}
+
 	switch {
 	case filter.Status == nil:
 		whereClauses = append(whereClauses, "status != 'deleted'")
@@ -153,25 +174,71 @@ func (r *developerRepo) GetAll(ctx context.Context, filter domain.DeveloperFilte
 	case *filter.Status == domain.StatusDeleted:
 
 	default:
-		whereClauses = append(whereClauses, "status = $"+fmt.Sprint(argPos))
-		args = append(args, *filter.Status)
-		argPos++
+		whereClauses = append(whereClauses, "status = "+arg(*filter.Status))
 	}
 
 	if filter.PlanTier != nil && *filter.PlanTier != "" {
-		whereClauses = append(whereClauses, "plan_tier = $"+fmt.Sprint(argPos))
-		args = append(args, *filter.PlanTier)
-		argPos++
+		whereClauses = append(whereClauses, "plan_tier = "+arg(*filter.PlanTier))
+	}
+
+	if filter.Query != nil && *filter.Query != "" {
+		pattern := arg("%" + escapeLike(*filter.Query) + "%")
+		whereClauses = append(whereClauses,
+			"(email ILIKE "+pattern+" OR full_name ILIKE "+pattern+" OR company_name ILIKE "+pattern+")")
+	}
+
+	if filter.EmailVerified != nil {
+		whereClauses = append(whereClauses, "email_verified = "+arg(*filter.EmailVerified))
+	}
+	if filter.CreatedAfter != nil {
+		whereClauses = append(whereClauses, "created_at >= "+arg(*filter.CreatedAfter))
+	}
+	if filter.CreatedBefore != nil {
+		whereClauses = append(whereClauses, "created_at < "+arg(*filter.CreatedBefore))
+	}
+	if filter.LastLoginAfter != nil {
+		whereClauses = append(whereClauses, "last_login_at >= "+arg(*filter.LastLoginAfter))
+	}
+	if filter.LastLoginBefore != nil {
+		whereClauses = append(whereClauses, "last_login_at < "+arg(*filter.LastLoginBefore))
+	}
+
+	for key, value := range filter.Metadata {
+		whereClauses = append(whereClauses, "metadata ->> "+arg(key)+" = "+arg(value))
+	}
+
+	result := &domain.DeveloperPage{Developers: []*domain.Developer{}}
+
+	// The total ignores the cursor so it stays the same across pages
+	if page.IncludeTotal {
+		countQuery := `SELECT COUNT(*) FROM developers`
+		if len(whereClauses) > 0 {
+			countQuery += " WHERE " + strings.Join(whereClauses, " AND ")
+		}
+		var total int
+		if err := r.db.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+			return nil, err
+		}
+		result.Total = &total
+	}
+
+	direction, comparison := "DESC", "<"
+	if page.Ascending {
+		direction, comparison = "ASC", ">"
 	}
 
-	limitPos := argPos
-	offsetPos := argPos + 1
-	args = append(args, pageSize, (page-1)*pageSize)
+	if page.After != nil {
+		whereClauses = append(whereClauses,
+			"("+sortColumn+", id) "+comparison+" ("+arg(page.After.Key)+", "+arg(page.After.ID)+")")
+	}
+
+	// Fetch one extra row to learn whether another page follows
+	limit := arg(page.Limit + 1)
 
 	query := `
 		SELECT id, email, password_hash, full_name, company_name,
-		       status, email_verified, plan_tier, created_at,
-		       updated_at, last_login_at, metadata
+		       status, role, email_verified, plan_tier, created_at,
+		       updated_at, version, last_login_at, metadata
 		FROM developers
 	`
 
@@ -180,9 +247,8 @@ func (r *developerRepo) GetAll(ctx context.Context, filter domain.DeveloperFilte
 	}
 
 	query += `
-		ORDER BY created_at DESC
-		LIMIT $` + fmt.Sprint(limitPos) + `
-		OFFSET $` + fmt.Sprint(offsetPos)
+		ORDER BY ` + sortColumn + ` ` + direction + `, id ` + direction + `
+		LIMIT ` + limit
 
 	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
@@ -190,8 +256,6 @@ func (r *developerRepo) GetAll(ctx context.Context, filter domain.DeveloperFilte
 	}
 	defer rows.Close()
 
-	var developers []*domain.Developer
-
 	for rows.Next() {
 		dev := &domain.Developer{}
 		var metadata []byte
@@ -204,10 +268,12 @@ func (r *developerRepo) GetAll(ctx context.Context, filter domain.DeveloperFilte
 			&dev.FullName,
 			&dev.CompanyName,
 			&dev.Status,
+			&dev.Role,
 			&dev.EmailVerified,
 			&dev.PlanTier,
 			&dev.CreatedAt,
 			&dev.UpdatedAt,
+			&dev.Version,
 			&lastLogin,
 			&metadata,
 		); err != nil {
@@ -220,25 +286,33 @@ func (r *developerRepo) GetAll(ctx context.Context, filter domain.DeveloperFilte
 
 		json.Unmarshal(metadata, &dev.Metadata)
 
-		developers = append(developers, dev)
+		result.Developers = append(result.Developers, dev)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	if len(developers) == 0 {
-		return nil, domain.ErrNotFound
+	if len(result.Developers) > page.Limit {
+		result.Developers = result.Developers[:page.Limit]
+		last := result.Developers[page.Limit-1]
+		result.NextCursor = &domain.DeveloperCursor{
+			Sort:      page.Sort,
+			Ascending: page.Ascending,
+			Key:       developerSortKey(last, page.Sort),
+			ID:        last.ID,
+		}
 	}
 
-	return developers, nil
+	return result, nil
 }
 
 func (r *developerRepo) UpdatePassword(ctx context.Context, id uuid.UUID, oldPasswordHash string, newPasswordHash string) error {
 	query := `
 		UPDATE developers SET
 			password_hash = $1,
-			updated_at = NOW()
+			updated_at = NOW(),
+			version = version + 1
 		WHERE password_hash = $2 AND id = $3 AND status != 'deleted'`
 
 	res, err := r.db.Exec(ctx, query, newPasswordHash, oldPasswordHash, id)
@@ -252,35 +326,46 @@ func (r *developerRepo) UpdatePassword(ctx context.Context, id uuid.UUID, oldPas
 }
 
 func (r *developerRepo) Update(ctx context.Context, id uuid.UUID, input *domain.UpdateDeveloperInput) error {
-	var updatedAt time.Time
+	var (
+		args       []any
+		setClauses []string
+		argPos     = 1
+	)
 
-	query := `
-		UPDATE developers SET
-			full_name = $1,
-			company_name = $2,
-			status = $3,
-			plan_tier = $4,
-			updated_at = NOW()
-		WHERE id = $5
-		RETURNING updated_at`
-
-	err := r.db.QueryRow(ctx, query,
-		input.FullName, input.CompanyName, input.Status, input.PlanTier, id,
-	).Scan(&updatedAt)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return domain.ErrNotFound
-		}
-		return err
+	set := func(column string, value any) {
+		setClauses = append(setClauses, column+" = $"+fmt.Sprint(argPos))
+		args = append(args, value)
+		argPos++
 	}
-	return nil
+
+	if input.FullName.Set {
+		set("full_name", input.FullName.Value)
+	}
+	if input.CompanyName.Set {
+		set("company_name", input.CompanyName.Value)
+	}
+	if input.Status.Set {
+		set("status", input.Status.Value)
+	}
+	if input.PlanTier.Set {
+		set("plan_tier", input.PlanTier.Value)
+	}
+	setClauses = append(setClauses, "updated_at = NOW()", "version = version + 1")
+
+	query := `
+		UPDATE developers SET ` + strings.Join(setClauses, ", ") + `
+		WHERE id = $` + fmt.Sprint(argPos) + ` AND status != 'deleted'`
+	args = append(args, id)
+
+	return r.execConditional(ctx, query, args, id, input.IfVersion)
 }
 
 func (r *developerRepo) UpdateLastLogin(ctx context.Context, id uuid.UUID, loginTime time.Time) error {
 	query := `
 		UPDATE developers SET
 			last_login_at = $1,
-			updated_at = NOW()
+			updated_at = NOW(),
+			version = version + 1
 		WHERE id = $2 AND status != 'deleted'`
 
 	res, err := r.db.Exec(ctx, query, loginTime, id)
@@ -297,7 +382,8 @@ func (r *developerRepo) ResetPassword(ctx context.Context, id uuid.UUID, newPass
 	query := `
 		UPDATE developers SET
 			password_hash = $1,
-			updated_at = NOW()
+			updated_at = NOW(),
+			version = version + 1
 		WHERE id = $2 AND status != 'deleted'`
 
 	res, err := r.db.Exec(ctx, query, newPasswordHash, id)
@@ -314,7 +400,8 @@ func (r *developerRepo) AddMetadata(ctx context.Context, id uuid.UUID, key strin
 	query := `
         UPDATE developers
         SET metadata = metadata || jsonb_build_object($1, to_jsonb($2)),
-            updated_at = NOW()
+            updated_at = NOW(),
+            version = version + 1
         WHERE id = $3 AND status != 'deleted'
     `
 
@@ -328,49 +415,107 @@ func (r *developerRepo) AddMetadata(ctx context.Context, id uuid.UUID, key strin
 	return nil
 }
 
-func (r *developerRepo) Delete(ctx context.Context, id uuid.UUID) error {
+func (r *developerRepo) Delete(ctx context.Context, id uuid.UUID, ifVersion *int64) error {
 	query := `DELETE FROM developers WHERE id = $1`
 
-	res, err := r.db.Exec(ctx, query, id)
-	if err != nil {
-		return err
-	}
-	if res.RowsAffected() == 0 {
-		return domain.ErrNotFound
-	}
-	return nil
+	return r.execConditional(ctx, query, []any{id}, id, ifVersion)
 }
 
-func (r *developerRepo) SoftDelete(ctx context.Context, id uuid.UUID) error {
+func (r *developerRepo) SoftDelete(ctx context.Context, id uuid.UUID, ifVersion *int64) error {
 	query := `
 		UPDATE developers SET
 			status = 'deleted',
-			updated_at = NOW()
+			updated_at = NOW(),
+			version = version + 1
 		WHERE id = $1`
 
-	res, err := r.db.Exec(ctx, query, id)
-	if err != nil {
-		return err
-	}
-	if res.RowsAffected() == 0 {
-		return domain.ErrNotFound
-	}
-	return nil
+	return r.execConditional(ctx, query, []any{id}, id, ifVersion)
 }
 
-func (r *developerRepo) Suspend(ctx context.Context, id uuid.UUID) error {
+func (r *developerRepo) Suspend(ctx context.Context, id uuid.UUID, ifVersion *int64) error {
 	query := `
 		UPDATE developers SET
 			status = 'suspended',
-			updated_at = NOW()
+			updated_at = NOW(),
+			version = version + 1
 		WHERE id = $1`
 
-	res, err := r.db.Exec(ctx, query, id)
+	return r.execConditional(ctx, query, []any{id}, id, ifVersion)
+}
+
+func (r *developerRepo) SetRole(ctx context.Context, id uuid.UUID, role domain.Role, ifVersion *int64) error {
+	query := `
+		UPDATE developers SET
+			role = $2,
+			updated_at = NOW(),
+			version = version + 1
+		WHERE id = $1 AND status != 'deleted'`
+
+	return r.execConditional(ctx, query, []any{id, role}, id, ifVersion)
+}
+
+func (r *developerRepo) CountByRole(ctx context.Context, role domain.Role) (int, error) {
+	query := `SELECT COUNT(*) FROM developers WHERE role = $1 AND status != 'deleted'`
+
+	var count int
+	if err := r.db.QueryRow(ctx, query, role).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// execConditional runs a write on one developer, first narrowing it to
+// ifVersion when given. The query must filter by id and take no more than
+// len(args) parameters.
+func (r *developerRepo) execConditional(ctx context.Context, query string, args []any, id uuid.UUID, ifVersion *int64) error {
+	if ifVersion != nil {
+		args = append(args, *ifVersion)
+		query += ` AND version = $` + fmt.Sprint(len(args))
+	}
+
+	res, err := r.db.Exec(ctx, query, args...)
 	if err != nil {
 		return err
 	}
-	if res.RowsAffected() == 0 {
+	if res.RowsAffected() > 0 {
+		return nil
+	}
+
+	if ifVersion == nil {
 		return domain.ErrNotFound
 	}
-	return nil
+
+	// Tell a stale precondition apart from a missing row
+	var exists bool
+	err = r.db.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM developers WHERE id = $1 AND status != 'deleted')`, id,
+	).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return domain.ErrPreconditionFailed
+	}
+	return domain.ErrNotFound
+}
+
+func developerSortKey(dev *domain.Developer, sort domain.DeveloperSort) any {
+	switch sort {
+	case domain.SortByUpdatedAt:
+		return dev.UpdatedAt
+	case domain.SortByLastLoginAt:
+		if dev.LastLoginAt == nil {
+			return time.Unix(0, 0).UTC()
+		}
+		return *dev.LastLoginAt
+	case domain.SortByEmail:
+		return dev.Email
+	default:
+		return dev.CreatedAt
+	}
+}
+
+// escapeLike makes user input match literally inside a LIKE pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
 }
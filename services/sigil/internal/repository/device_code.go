@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+)
+
+type deviceCodeRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewDeviceCodeRepository(db *pgxpool.Pool) domain.DeviceCodeRepository {
+	return &deviceCodeRepo{db: db}
+}
+
+func (r *deviceCodeRepo) Create(ctx context.Context, code *domain.DeviceCode) error {
+	// Abandoned and finished device codes are cleaned up opportunistically
+	if _, err := r.db.Exec(ctx, `DELETE FROM oauth_device_codes WHERE expires_at < NOW()`); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO oauth_device_codes (
+			device_code_hash, user_code, oauth_client_id, scopes, interval_seconds, expires_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`
+
+	err := r.db.QueryRow(
+		ctx, query, code.DeviceCodeHash, code.UserCode, code.OAuthClientID, code.Scopes,
+		int(code.Interval.Seconds()), code.ExpiresAt,
+	).Scan(&code.ID, &code.CreatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return domain.ErrUserCodeTaken
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *deviceCodeRepo) GetByUserCode(ctx context.Context, userCode string) (*domain.DeviceCode, error) {
+	query := `
+		SELECT id, device_code_hash, user_code, oauth_client_id, scopes, interval_seconds,
+		       developer_id, approved_at, denied_at, used_at, last_polled_at, expires_at, created_at
+		FROM oauth_device_codes WHERE user_code = $1`
+
+	code, err := scanDeviceCode(r.db.QueryRow(ctx, query, userCode))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrDeviceCodeNotFound
+		}
+		return nil, err
+	}
+	return code, nil
+}
+
+func (r *deviceCodeRepo) Decide(ctx context.Context, id uuid.UUID, developerID uuid.UUID, approved bool) error {
+	query := `
+		UPDATE oauth_device_codes SET
+			developer_id = $2,
+			approved_at = CASE WHEN $3 THEN NOW() END,
+			denied_at = CASE WHEN $3 THEN NULL ELSE NOW() END
+		WHERE id = $1 AND approved_at IS NULL AND denied_at IS NULL AND expires_at > NOW()`
+
+	res, err := r.db.Exec(ctx, query, id, developerID, approved)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrDeviceCodeNotFound
+	}
+	return nil
+}
+
+func (r *deviceCodeRepo) Poll(ctx context.Context, deviceCodeHash string) (*domain.DeviceCode, error) {
+	// The subquery locks the row and yields the previous poll time
+	query := `
+		UPDATE oauth_device_codes d SET last_polled_at = NOW()
+		FROM (
+			SELECT id, last_polled_at FROM oauth_device_codes
+			WHERE device_code_hash = $1
+			FOR UPDATE
+		) prev
+		WHERE d.id = prev.id
+		RETURNING d.id, d.device_code_hash, d.user_code, d.oauth_client_id, d.scopes, d.interval_seconds,
+		          d.developer_id, d.approved_at, d.denied_at, d.used_at, prev.last_polled_at, d.expires_at, d.created_at`
+
+	code, err := scanDeviceCode(r.db.QueryRow(ctx, query, deviceCodeHash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrDeviceCodeNotFound
+		}
+		return nil, err
+	}
+	return code, nil
+}
+
+func (r *deviceCodeRepo) SlowDown(ctx context.Context, id uuid.UUID, interval time.Duration) error {
+	query := `UPDATE oauth_device_codes SET interval_seconds = $2 WHERE id = $1`
+
+	_, err := r.db.Exec(ctx, query, id, int(interval.Seconds()))
+	return err
+}
+
+func (r *deviceCodeRepo) Consume(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE oauth_device_codes SET used_at = NOW()
+		WHERE id = $1 AND used_at IS NULL`
+
+	res, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrDeviceCodeNotFound
+	}
+	return nil
+}
+
+func scanDeviceCode(row pgx.Row) (*domain.DeviceCode, error) {
+	code := &domain.DeviceCode{}
+	var intervalSeconds int
+	err := row.Scan(
+		&code.ID, &code.DeviceCodeHash, &code.UserCode, &code.OAuthClientID, &code.Scopes, &intervalSeconds,
+		&code.DeveloperID, &code.ApprovedAt, &code.DeniedAt, &code.UsedAt, &code.LastPolledAt, &code.ExpiresAt, &code.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	code.Interval = time.Duration(intervalSeconds) * time.Second
+	return code, nil
+}
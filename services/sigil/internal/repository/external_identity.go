@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+)
+
+type externalIdentityRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewExternalIdentityRepository(db *pgxpool.Pool) domain.ExternalIdentityRepository {
+	return &externalIdentityRepo{db: db}
+}
+
+func (r *externalIdentityRepo) Create(ctx context.Context, identity *domain.ExternalIdentity) error {
+	query := `
+		INSERT INTO external_identities (
+			developer_id, provider, subject, email, last_login_at
+		)
+		VALUES ($1, $2, $3, $4, NOW())
+		RETURNING id, created_at, last_login_at`
+
+	err := r.db.QueryRow(
+		ctx, query, identity.DeveloperID, identity.Provider, identity.Subject, identity.Email,
+	).Scan(&identity.ID, &identity.CreatedAt, &identity.LastLoginAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return domain.ErrExternalIdentityLinked
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *externalIdentityRepo) GetBySubject(ctx context.Context, provider string, subject string) (*domain.ExternalIdentity, error) {
+	query := `
+		SELECT id, developer_id, provider, subject, email, created_at, last_login_at
+		FROM external_identities WHERE provider = $1 AND subject = $2`
+
+	identity := &domain.ExternalIdentity{}
+	err := r.db.QueryRow(ctx, query, provider, subject).Scan(
+		&identity.ID, &identity.DeveloperID, &identity.Provider, &identity.Subject,
+		&identity.Email, &identity.CreatedAt, &identity.LastLoginAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrExternalIdentityNotFound
+		}
+		return nil, err
+	}
+
+	return identity, nil
+}
+
+func (r *externalIdentityRepo) ListByDeveloper(ctx context.Context, developerID uuid.UUID) ([]*domain.ExternalIdentity, error) {
+	query := `
+		SELECT id, developer_id, provider, subject, email, created_at, last_login_at
+		FROM external_identities
+		WHERE developer_id = $1
+		ORDER BY created_at`
+
+	rows, err := r.db.Query(ctx, query, developerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := []*domain.ExternalIdentity{}
+
+	for rows.Next() {
+		identity := &domain.ExternalIdentity{}
+		if err := rows.Scan(
+			&identity.ID,
+			&identity.DeveloperID,
+			&identity.Provider,
+			&identity.Subject,
+			&identity.Email,
+			&identity.CreatedAt,
+			&identity.LastLoginAt,
+		); err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return identities, nil
+}
+
+func (r *externalIdentityRepo) RecordLogin(ctx context.Context, id uuid.UUID, email string) error {
+	query := `
+		UPDATE external_identities SET
+			email = COALESCE(NULLIF($2, ''), email),
+			last_login_at = NOW()
+		WHERE id = $1`
+
+	res, err := r.db.Exec(ctx, query, id, email)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrExternalIdentityNotFound
+	}
+	return nil
+}
+
+func (r *externalIdentityRepo) Delete(ctx context.Context, id uuid.UUID, developerID uuid.UUID) error {
+	query := `DELETE FROM external_identities WHERE id = $1 AND developer_id = $2`
+
+	res, err := r.db.Exec(ctx, query, id, developerID)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrExternalIdentityNotFound
+	}
+	return nil
+}
+
+func (r *externalIdentityRepo) SaveLogin(ctx context.Context, login *domain.FederatedLogin) error {
+	// Abandoned logins are cleaned up opportunistically
+	if _, err := r.db.Exec(ctx, `DELETE FROM federated_logins WHERE expires_at < NOW()`); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO federated_logins (
+			state_hash, binding_hash, provider, nonce, code_verifier, developer_id, expires_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at`
+
+	return r.db.QueryRow(
+		ctx, query, login.StateHash, login.BindingHash, login.Provider, login.Nonce, login.CodeVerifier,
+		login.DeveloperID, login.ExpiresAt,
+	).Scan(&login.ID, &login.CreatedAt)
+}
+
+func (r *externalIdentityRepo) ConsumeLogin(ctx context.Context, stateHash string, provider string) (*domain.FederatedLogin, error) {
+	query := `
+		DELETE FROM federated_logins
+		WHERE state_hash = $1 AND provider = $2 AND expires_at > NOW()
+		RETURNING id, state_hash, binding_hash, provider, nonce, code_verifier, developer_id, expires_at, created_at`
+
+	login := &domain.FederatedLogin{}
+	err := r.db.QueryRow(ctx, query, stateHash, provider).Scan(
+		&login.ID, &login.StateHash, &login.BindingHash, &login.Provider, &login.Nonce, &login.CodeVerifier,
+		&login.DeveloperID, &login.ExpiresAt, &login.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrFederatedLoginNotFound
+		}
+		return nil, err
+	}
+
+	return login, nil
+}
@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+)
+
+type mfaRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewMFARepository(db *pgxpool.Pool) domain.MFARepository {
+	return &mfaRepo{db: db}
+}
+
+func (r *mfaRepo) SavePending(ctx context.Context, developerID uuid.UUID, secretEncrypted string) error {
+	// Re-enrolling replaces an unconfirmed secret but never an active one
+	query := `
+		INSERT INTO developer_mfa (developer_id, totp_secret)
+		VALUES ($1, $2)
+		ON CONFLICT (developer_id) DO UPDATE SET
+			totp_secret = EXCLUDED.totp_secret,
+			last_used_step = NULL,
+			updated_at = NOW()
+		WHERE developer_mfa.enabled_at IS NULL`
+
+	res, err := r.db.Exec(ctx, query, developerID, secretEncrypted)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrMFAAlreadyEnabled
+	}
+	return nil
+}
+
+func (r *mfaRepo) Get(ctx context.Context, developerID uuid.UUID) (*domain.MFA, error) {
+	query := `
+		SELECT developer_id, totp_secret, enabled_at, last_used_step,
+		       created_at, updated_at
+		FROM developer_mfa WHERE developer_id = $1`
+
+	mfa := &domain.MFA{}
+	err := r.db.QueryRow(ctx, query, developerID).Scan(
+		&mfa.DeveloperID, &mfa.SecretEncrypted, &mfa.EnabledAt, &mfa.LastUsedStep,
+		&mfa.CreatedAt, &mfa.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrMFANotEnrolled
+		}
+		return nil, err
+	}
+
+	return mfa, nil
+}
+
+func (r *mfaRepo) Enable(ctx context.Context, developerID uuid.UUID, step int64, recoveryCodeHashes []string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE developer_mfa SET
+			enabled_at = NOW(),
+			last_used_step = $1,
+			updated_at = NOW()
+		WHERE developer_id = $2 AND enabled_at IS NULL`
+
+	res, err := tx.Exec(ctx, query, step, developerID)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrMFAAlreadyEnabled
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE developer_id = $1`, developerID); err != nil {
+		return err
+	}
+
+	insertQuery := `
+		INSERT INTO mfa_recovery_codes (developer_id, code_hash)
+		SELECT $1, UNNEST($2::text[])`
+
+	if _, err := tx.Exec(ctx, insertQuery, developerID, recoveryCodeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *mfaRepo) UseStep(ctx context.Context, developerID uuid.UUID, step int64) error {
+	query := `
+		UPDATE developer_mfa SET
+			last_used_step = $1,
+			updated_at = NOW()
+		WHERE developer_id = $2
+		  AND (last_used_step IS NULL OR last_used_step < $1)`
+
+	res, err := r.db.Exec(ctx, query, step, developerID)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (r *mfaRepo) UseRecoveryCode(ctx context.Context, developerID uuid.UUID, codeHash string) error {
+	query := `
+		UPDATE mfa_recovery_codes SET used_at = NOW()
+		WHERE id = (
+			SELECT id FROM mfa_recovery_codes
+			WHERE developer_id = $1 AND code_hash = $2 AND used_at IS NULL
+			LIMIT 1
+		) AND used_at IS NULL`
+
+	res, err := r.db.Exec(ctx, query, developerID, codeHash)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (r *mfaRepo) Delete(ctx context.Context, developerID uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE developer_id = $1`, developerID); err != nil {
+		return err
+	}
+
+	res, err := tx.Exec(ctx, `DELETE FROM developer_mfa WHERE developer_id = $1`, developerID)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrMFANotEnrolled
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *mfaRepo) AttemptCode(ctx context.Context, developerID uuid.UUID, maxAttempts int, lockout time.Duration) error {
+	// Counted before the code is checked, so parallel guesses share the budget.
+	// An expired lock starts a fresh count.
+	query := `
+		UPDATE developer_mfa SET
+			failed_attempts = CASE WHEN locked_until IS NULL THEN failed_attempts + 1 ELSE 1 END,
+			locked_until = CASE
+				WHEN (CASE WHEN locked_until IS NULL THEN failed_attempts + 1 ELSE 1 END) >= $2
+				THEN NOW() + make_interval(secs => $3)
+			END,
+			updated_at = NOW()
+		WHERE developer_id = $1 AND (locked_until IS NULL OR locked_until <= NOW())`
+
+	res, err := r.db.Exec(ctx, query, developerID, maxAttempts, int(lockout.Seconds()))
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrMFALocked
+	}
+	return nil
+}
+
+func (r *mfaRepo) ResetAttempts(ctx context.Context, developerID uuid.UUID) error {
+	query := `
+		UPDATE developer_mfa SET
+			failed_attempts = 0,
+			locked_until = NULL,
+			updated_at = NOW()
+		WHERE developer_id = $1`
+
+	_, err := r.db.Exec(ctx, query, developerID)
+	return err
+}
+
+func (r *mfaRepo) CreateChallenge(ctx context.Context, challenge *domain.MFAChallenge) error {
+	// Abandoned challenges are cleaned up opportunistically
+	if _, err := r.db.Exec(ctx, `DELETE FROM mfa_challenges WHERE expires_at < NOW()`); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO mfa_challenges (id, developer_id, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING created_at`
+
+	return r.db.QueryRow(ctx, query, challenge.ID, challenge.DeveloperID, challenge.ExpiresAt).Scan(&challenge.CreatedAt)
+}
+
+func (r *mfaRepo) AttemptChallenge(ctx context.Context, id uuid.UUID, maxAttempts int) (*domain.MFAChallenge, error) {
+	// Counted before the code is checked, so parallel guesses share the budget
+	query := `
+		UPDATE mfa_challenges SET attempts = attempts + 1
+		WHERE id = $1 AND used_at IS NULL AND expires_at > NOW() AND attempts < $2
+		RETURNING id, developer_id, attempts, used_at, expires_at, created_at`
+
+	challenge := &domain.MFAChallenge{}
+	err := r.db.QueryRow(ctx, query, id, maxAttempts).Scan(
+		&challenge.ID, &challenge.DeveloperID, &challenge.Attempts,
+		&challenge.UsedAt, &challenge.ExpiresAt, &challenge.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrInvalidMFAChallenge
+		}
+		return nil, err
+	}
+
+	return challenge, nil
+}
+
+func (r *mfaRepo) ConsumeChallenge(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE mfa_challenges SET used_at = NOW() WHERE id = $1 AND used_at IS NULL`
+
+	res, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrInvalidMFAChallenge
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+)
+
+type oauthRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewOAuthRepository(db *pgxpool.Pool) domain.OAuthRepository {
+	return &oauthRepo{db: db}
+}
+
+func (r *oauthRepo) CreateClient(ctx context.Context, client *domain.OAuthClient) error {
+	query := `
+		INSERT INTO oauth_clients (
+			client_id, name, secret_hash, redirect_uris, created_by
+		)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`
+
+	return r.db.QueryRow(
+		ctx, query, client.ClientID, client.Name, client.SecretHash, client.RedirectURIs, client.CreatedBy,
+	).Scan(&client.ID, &client.CreatedAt)
+}
+
+func (r *oauthRepo) GetClientByClientID(ctx context.Context, clientID string) (*domain.OAuthClient, error) {
+	return r.getClient(ctx, "client_id", clientID)
+}
+
+func (r *oauthRepo) GetClientByID(ctx context.Context, id uuid.UUID) (*domain.OAuthClient, error) {
+	return r.getClient(ctx, "id", id)
+}
+
+func (r *oauthRepo) getClient(ctx context.Context, column string, value any) (*domain.OAuthClient, error) {
+	query := `
+		SELECT id, client_id, name, secret_hash, redirect_uris, created_by, revoked_at, created_at
+		FROM oauth_clients WHERE ` + column + ` = $1`
+
+	client := &domain.OAuthClient{}
+	err := r.db.QueryRow(ctx, query, value).Scan(
+		&client.ID, &client.ClientID, &client.Name, &client.SecretHash,
+		&client.RedirectURIs, &client.CreatedBy, &client.RevokedAt, &client.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrOAuthClientNotFound
+		}
+		return nil, err
+	}
+
+	return client, nil
+}
+
+func (r *oauthRepo) ListClientsByDeveloper(ctx context.Context, developerID uuid.UUID) ([]*domain.OAuthClient, error) {
+	query := `
+		SELECT id, client_id, name, secret_hash, redirect_uris, created_by, revoked_at, created_at
+		FROM oauth_clients
+		WHERE created_by = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC`
+
+	rows, err := r.db.Query(ctx, query, developerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clients := []*domain.OAuthClient{}
+
+	for rows.Next() {
+		client := &domain.OAuthClient{}
+		if err := rows.Scan(
+			&client.ID,
+			&client.ClientID,
+			&client.Name,
+			&client.SecretHash,
+			&client.RedirectURIs,
+			&client.CreatedBy,
+			&client.RevokedAt,
+			&client.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return clients, nil
+}
+
+func (r *oauthRepo) CountActiveClients(ctx context.Context) (int, error) {
+	query := `SELECT COUNT(*) FROM oauth_clients WHERE revoked_at IS NULL`
+
+	var count int
+	if err := r.db.QueryRow(ctx, query).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *oauthRepo) RevokeClient(ctx context.Context, id uuid.UUID, developerID uuid.UUID) error {
+	query := `
+		UPDATE oauth_clients SET revoked_at = NOW()
+		WHERE id = $1 AND created_by = $2 AND revoked_at IS NULL`
+
+	res, err := r.db.Exec(ctx, query, id, developerID)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrOAuthClientNotFound
+	}
+	return nil
+}
+
+func (r *oauthRepo) GetConsent(ctx context.Context, developerID uuid.UUID, clientID uuid.UUID) ([]string, error) {
+	query := `
+		SELECT scopes FROM oauth_consents
+		WHERE developer_id = $1 AND oauth_client_id = $2`
+
+	var scopes []string
+	if err := r.db.QueryRow(ctx, query, developerID, clientID).Scan(&scopes); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return scopes, nil
+}
+
+func (r *oauthRepo) SaveConsent(ctx context.Context, developerID uuid.UUID, clientID uuid.UUID, scopes []string) error {
+	query := `
+		INSERT INTO oauth_consents (developer_id, oauth_client_id, scopes)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (developer_id, oauth_client_id) DO UPDATE SET
+			scopes = ARRAY(SELECT DISTINCT unnest(oauth_consents.scopes || EXCLUDED.scopes)),
+			updated_at = NOW()`
+
+	_, err := r.db.Exec(ctx, query, developerID, clientID, scopes)
+	return err
+}
+
+func (r *oauthRepo) CreateCode(ctx context.Context, code *domain.AuthorizationCode) error {
+	// Unredeemed and spent codes are cleaned up opportunistically
+	if _, err := r.db.Exec(ctx, `DELETE FROM oauth_authorization_codes WHERE expires_at < NOW()`); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO oauth_authorization_codes (
+			code_hash, oauth_client_id, developer_id, redirect_uri, scopes,
+			code_challenge, code_challenge_method, nonce, expires_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at`
+
+	return r.db.QueryRow(
+		ctx, query, code.CodeHash, code.OAuthClientID, code.DeveloperID, code.RedirectURI, code.Scopes,
+		code.CodeChallenge, code.CodeChallengeMethod, code.Nonce, code.ExpiresAt,
+	).Scan(&code.ID, &code.CreatedAt)
+}
+
+func (r *oauthRepo) ConsumeCode(ctx context.Context, codeHash string) (*domain.AuthorizationCode, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the code so two exchanges cannot both see it unused
+	query := `
+		SELECT id, code_hash, oauth_client_id, developer_id, redirect_uri, scopes,
+		       code_challenge, code_challenge_method, nonce, expires_at, used_at, session_id, created_at
+		FROM oauth_authorization_codes WHERE code_hash = $1
+		FOR UPDATE`
+
+	code := &domain.AuthorizationCode{}
+	err = tx.QueryRow(ctx, query, codeHash).Scan(
+		&code.ID, &code.CodeHash, &code.OAuthClientID, &code.DeveloperID, &code.RedirectURI, &code.Scopes,
+		&code.CodeChallenge, &code.CodeChallengeMethod, &code.Nonce, &code.ExpiresAt, &code.UsedAt, &code.SessionID, &code.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrInvalidGrant
+		}
+		return nil, err
+	}
+	if code.UsedAt != nil {
+		return code, domain.ErrAuthorizationCodeUsed
+	}
+
+	consume := `
+		UPDATE oauth_authorization_codes SET used_at = NOW()
+		WHERE id = $1 RETURNING used_at`
+
+	if err := tx.QueryRow(ctx, consume, code.ID).Scan(&code.UsedAt); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return code, nil
+}
+
+func (r *oauthRepo) SetCodeSession(ctx context.Context, id uuid.UUID, sessionID uuid.UUID) error {
+	query := `UPDATE oauth_authorization_codes SET session_id = $1 WHERE id = $2`
+
+	_, err := r.db.Exec(ctx, query, sessionID, id)
+	return err
+}
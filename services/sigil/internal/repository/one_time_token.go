@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+)
+
+type oneTimeTokenRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewOneTimeTokenRepository(db *pgxpool.Pool) domain.OneTimeTokenRepository {
+	return &oneTimeTokenRepo{db: db}
+}
+
+func (r *oneTimeTokenRepo) Create(ctx context.Context, token *domain.OneTimeToken) error {
+	query := `
+		INSERT INTO one_time_tokens (
+			developer_id, purpose, token_hash, expires_at
+		)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+
+	return r.db.QueryRow(
+		ctx, query, token.DeveloperID, token.Purpose, token.TokenHash, token.ExpiresAt,
+	).Scan(&token.ID, &token.CreatedAt)
+}
+
+func (r *oneTimeTokenRepo) Consume(ctx context.Context, tokenHash string, purpose domain.TokenPurpose) (*domain.OneTimeToken, error) {
+	query := `
+		UPDATE one_time_tokens SET used_at = NOW()
+		WHERE token_hash = $1 AND purpose = $2
+		  AND used_at IS NULL AND expires_at > NOW()
+		RETURNING id, developer_id, purpose, token_hash, expires_at, used_at, created_at`
+
+	token := &domain.OneTimeToken{}
+	err := r.db.QueryRow(ctx, query, tokenHash, purpose).Scan(
+		&token.ID, &token.DeveloperID, &token.Purpose, &token.TokenHash,
+		&token.ExpiresAt, &token.UsedAt, &token.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrInvalidOneTimeToken
+		}
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func (r *oneTimeTokenRepo) LatestCreatedAt(ctx context.Context, developerID uuid.UUID, purpose domain.TokenPurpose) (*time.Time, error) {
+	query := `
+		SELECT MAX(created_at) FROM one_time_tokens
+		WHERE developer_id = $1 AND purpose = $2`
+
+	var createdAt *time.Time
+	if err := r.db.QueryRow(ctx, query, developerID, purpose).Scan(&createdAt); err != nil {
+		return nil, err
+	}
+	return createdAt, nil
+}
+
+func (r *oneTimeTokenRepo) InvalidateAll(ctx context.Context, developerID uuid.UUID, purpose domain.TokenPurpose) error {
+	query := `
+		UPDATE one_time_tokens SET used_at = NOW()
+		WHERE developer_id = $1 AND purpose = $2 AND used_at IS NULL`
+
+	_, err := r.db.Exec(ctx, query, developerID, purpose)
+	return err
+}
@@ -0,0 +1,408 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+)
+
+type organizationRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewOrganizationRepository(db *pgxpool.Pool) domain.OrganizationRepository {
+	return &organizationRepo{db: db}
+}
+
+func (r *organizationRepo) Create(ctx context.Context, org *domain.Organization, ownerID uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO organizations (name, slug)
+		VALUES ($1, $2)
+		RETURNING id, created_at, updated_at`
+
+	err = tx.QueryRow(ctx, query, org.Name, org.Slug).Scan(&org.ID, &org.CreatedAt, &org.UpdatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return domain.ErrOrganizationSlugTaken
+		}
+		return err
+	}
+
+	memberQuery := `
+		INSERT INTO organization_members (organization_id, developer_id, role)
+		VALUES ($1, $2, $3)`
+
+	if _, err := tx.Exec(ctx, memberQuery, org.ID, ownerID, domain.OrgRoleOwner); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *organizationRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Organization, error) {
+	query := `
+		SELECT id, name, slug, created_at, updated_at
+		FROM organizations WHERE id = $1`
+
+	org := &domain.Organization{}
+	err := r.db.QueryRow(ctx, query, id).Scan(&org.ID, &org.Name, &org.Slug, &org.CreatedAt, &org.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrOrganizationNotFound
+		}
+		return nil, err
+	}
+
+	return org, nil
+}
+
+func (r *organizationRepo) ListForDeveloper(ctx context.Context, developerID uuid.UUID) ([]*domain.OrganizationMembership, error) {
+	query := `
+		SELECT o.id, o.name, o.slug, o.created_at, o.updated_at, m.role, m.created_at
+		FROM organization_members m
+		JOIN organizations o ON o.id = m.organization_id
+		WHERE m.developer_id = $1
+		ORDER BY o.name`
+
+	rows, err := r.db.Query(ctx, query, developerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	memberships := []*domain.OrganizationMembership{}
+
+	for rows.Next() {
+		org := &domain.Organization{}
+		membership := &domain.OrganizationMembership{Organization: org}
+		if err := rows.Scan(
+			&org.ID,
+			&org.Name,
+			&org.Slug,
+			&org.CreatedAt,
+			&org.UpdatedAt,
+			&membership.Role,
+			&membership.JoinedAt,
+		); err != nil {
+			return nil, err
+		}
+		memberships = append(memberships, membership)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return memberships, nil
+}
+
+func (r *organizationRepo) Rename(ctx context.Context, id uuid.UUID, name string) error {
+	query := `UPDATE organizations SET name = $1, updated_at = NOW() WHERE id = $2`
+
+	res, err := r.db.Exec(ctx, query, name, id)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrOrganizationNotFound
+	}
+	return nil
+}
+
+func (r *organizationRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM organizations WHERE id = $1`
+
+	res, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrOrganizationNotFound
+	}
+	return nil
+}
+
+func (r *organizationRepo) GetMemberRole(ctx context.Context, orgID uuid.UUID, developerID uuid.UUID) (domain.OrgRole, error) {
+	query := `
+		SELECT role FROM organization_members
+		WHERE organization_id = $1 AND developer_id = $2`
+
+	var role domain.OrgRole
+	if err := r.db.QueryRow(ctx, query, orgID, developerID).Scan(&role); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", domain.ErrNotOrganizationMember
+		}
+		return "", err
+	}
+	return role, nil
+}
+
+func (r *organizationRepo) ListMembers(ctx context.Context, orgID uuid.UUID) ([]*domain.OrganizationMember, error) {
+	query := `
+		SELECT m.organization_id, m.developer_id, d.email, d.full_name, m.role, m.created_at
+		FROM organization_members m
+		JOIN developers d ON d.id = m.developer_id
+		WHERE m.organization_id = $1
+		ORDER BY m.created_at`
+
+	rows, err := r.db.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []*domain.OrganizationMember{}
+
+	for rows.Next() {
+		member := &domain.OrganizationMember{}
+		if err := rows.Scan(
+			&member.OrganizationID,
+			&member.DeveloperID,
+			&member.Email,
+			&member.FullName,
+			&member.Role,
+			&member.JoinedAt,
+		); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+func (r *organizationRepo) SetMemberRole(ctx context.Context, orgID uuid.UUID, developerID uuid.UUID, role domain.OrgRole) error {
+	query := `
+		UPDATE organization_members SET role = $1
+		WHERE organization_id = $2 AND developer_id = $3`
+
+	res, err := r.db.Exec(ctx, query, role, orgID, developerID)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrNotOrganizationMember
+	}
+	return nil
+}
+
+func (r *organizationRepo) RemoveMember(ctx context.Context, orgID uuid.UUID, developerID uuid.UUID) error {
+	query := `
+		DELETE FROM organization_members
+		WHERE organization_id = $1 AND developer_id = $2`
+
+	res, err := r.db.Exec(ctx, query, orgID, developerID)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrNotOrganizationMember
+	}
+	return nil
+}
+
+func (r *organizationRepo) TransferOwnership(ctx context.Context, orgID uuid.UUID, fromID uuid.UUID, toID uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Demote first so the single-owner index never sees two owners
+	demote := `
+		UPDATE organization_members SET role = $1
+		WHERE organization_id = $2 AND developer_id = $3 AND role = $4`
+
+	res, err := tx.Exec(ctx, demote, domain.OrgRoleAdmin, orgID, fromID, domain.OrgRoleOwner)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrForbidden
+	}
+
+	promote := `
+		UPDATE organization_members SET role = $1
+		WHERE organization_id = $2 AND developer_id = $3`
+
+	res, err = tx.Exec(ctx, promote, domain.OrgRoleOwner, orgID, toID)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrNotOrganizationMember
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *organizationRepo) CreateInvitation(ctx context.Context, invitation *domain.OrganizationInvitation) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	revoke := `
+		UPDATE organization_invitations SET revoked_at = NOW()
+		WHERE organization_id = $1 AND LOWER(email) = LOWER($2)
+		  AND accepted_at IS NULL AND revoked_at IS NULL`
+
+	if _, err := tx.Exec(ctx, revoke, invitation.OrganizationID, invitation.Email); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO organization_invitations (
+			organization_id, email, role, token_hash, invited_by, expires_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`
+
+	err = tx.QueryRow(
+		ctx, query, invitation.OrganizationID, invitation.Email, invitation.Role,
+		invitation.TokenHash, invitation.InvitedBy, invitation.ExpiresAt,
+	).Scan(&invitation.ID, &invitation.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *organizationRepo) ListPendingInvitations(ctx context.Context, orgID uuid.UUID) ([]*domain.OrganizationInvitation, error) {
+	query := `
+		SELECT id, organization_id, email, role, token_hash, invited_by,
+		       expires_at, accepted_at, revoked_at, created_at
+		FROM organization_invitations
+		WHERE organization_id = $1 AND accepted_at IS NULL
+		  AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY created_at DESC`
+
+	rows, err := r.db.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := []*domain.OrganizationInvitation{}
+
+	for rows.Next() {
+		invitation := &domain.OrganizationInvitation{}
+		if err := rows.Scan(
+			&invitation.ID,
+			&invitation.OrganizationID,
+			&invitation.Email,
+			&invitation.Role,
+			&invitation.TokenHash,
+			&invitation.InvitedBy,
+			&invitation.ExpiresAt,
+			&invitation.AcceptedAt,
+			&invitation.RevokedAt,
+			&invitation.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, invitation)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return invitations, nil
+}
+
+func (r *organizationRepo) RevokeInvitation(ctx context.Context, id uuid.UUID, orgID uuid.UUID) error {
+	query := `
+		UPDATE organization_invitations SET revoked_at = NOW()
+		WHERE id = $1 AND organization_id = $2
+		  AND accepted_at IS NULL AND revoked_at IS NULL`
+
+	res, err := r.db.Exec(ctx, query, id, orgID)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrInvitationNotFound
+	}
+	return nil
+}
+
+func (r *organizationRepo) GetPendingInvitation(ctx context.Context, tokenHash string) (*domain.OrganizationInvitation, error) {
+	query := `
+		SELECT id, organization_id, email, role, token_hash, invited_by,
+		       expires_at, accepted_at, revoked_at, created_at
+		FROM organization_invitations
+		WHERE token_hash = $1 AND accepted_at IS NULL
+		  AND revoked_at IS NULL AND expires_at > NOW()`
+
+	invitation := &domain.OrganizationInvitation{}
+	err := r.db.QueryRow(ctx, query, tokenHash).Scan(
+		&invitation.ID, &invitation.OrganizationID, &invitation.Email, &invitation.Role,
+		&invitation.TokenHash, &invitation.InvitedBy, &invitation.ExpiresAt,
+		&invitation.AcceptedAt, &invitation.RevokedAt, &invitation.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrInvalidInvitation
+		}
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func (r *organizationRepo) AcceptInvitation(ctx context.Context, id uuid.UUID, developerID uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE organization_invitations SET accepted_at = NOW()
+		WHERE id = $1 AND accepted_at IS NULL
+		  AND revoked_at IS NULL AND expires_at > NOW()
+		RETURNING organization_id, role`
+
+	var (
+		orgID uuid.UUID
+		role  domain.OrgRole
+	)
+	if err := tx.QueryRow(ctx, query, id).Scan(&orgID, &role); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrInvalidInvitation
+		}
+		return err
+	}
+
+	memberQuery := `
+		INSERT INTO organization_members (organization_id, developer_id, role)
+		VALUES ($1, $2, $3)`
+
+	if _, err := tx.Exec(ctx, memberQuery, orgID, developerID, role); err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return domain.ErrAlreadyMember
+		}
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+)
+
+type projectRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewProjectRepository(db *pgxpool.Pool) domain.ProjectRepository {
+	return &projectRepo{db: db}
+}
+
+func (r *projectRepo) Create(ctx context.Context, project *domain.Project, environments []domain.EnvironmentName) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO projects (
+			name, slug, owner_developer_id, owner_organization_id
+		)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at`
+
+	err = tx.QueryRow(
+		ctx, query, project.Name, project.Slug, project.OwnerDeveloperID, project.OwnerOrganizationID,
+	).Scan(&project.ID, &project.CreatedAt, &project.UpdatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return domain.ErrProjectSlugTaken
+		}
+		return err
+	}
+
+	envQuery := `INSERT INTO project_environments (project_id, name) VALUES ($1, $2)`
+	for _, name := range environments {
+		if _, err := tx.Exec(ctx, envQuery, project.ID, name); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *projectRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Project, error) {
+	query := `
+		SELECT id, name, slug, owner_developer_id, owner_organization_id,
+		       archived_at, created_at, updated_at
+		FROM projects WHERE id = $1`
+
+	project := &domain.Project{}
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&project.ID, &project.Name, &project.Slug, &project.OwnerDeveloperID,
+		&project.OwnerOrganizationID, &project.ArchivedAt, &project.CreatedAt, &project.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrProjectNotFound
+		}
+		return nil, err
+	}
+
+	return project, nil
+}
+
+func (r *projectRepo) ListByDeveloper(ctx context.Context, developerID uuid.UUID, includeArchived bool) ([]*domain.Project, error) {
+	return r.list(ctx, "owner_developer_id", developerID, includeArchived)
+}
+
+func (r *projectRepo) ListByOrganization(ctx context.Context, orgID uuid.UUID, includeArchived bool) ([]*domain.Project, error) {
+	return r.list(ctx, "owner_organization_id", orgID, includeArchived)
+}
+
+func (r *projectRepo) list(ctx context.Context, ownerColumn string, ownerID uuid.UUID, includeArchived bool) ([]*domain.Project, error) {
+	query := `
+		SELECT id, name, slug, owner_developer_id, owner_organization_id,
+		       archived_at, created_at, updated_at
+		FROM projects
+		WHERE ` + ownerColumn + ` = $1`
+
+	if !includeArchived {
+		query += " AND archived_at IS NULL"
+	}
+	query += " ORDER BY name"
+
+	rows, err := r.db.Query(ctx, query, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects := []*domain.Project{}
+
+	for rows.Next() {
+		project := &domain.Project{}
+		if err := rows.Scan(
+			&project.ID,
+			&project.Name,
+			&project.Slug,
+			&project.OwnerDeveloperID,
+			&project.OwnerOrganizationID,
+			&project.ArchivedAt,
+			&project.CreatedAt,
+			&project.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		projects = append(projects, project)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return projects, nil
+}
+
+func (r *projectRepo) Rename(ctx context.Context, id uuid.UUID, name string) error {
+	query := `UPDATE projects SET name = $1, updated_at = NOW() WHERE id = $2`
+
+	res, err := r.db.Exec(ctx, query, name, id)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrProjectNotFound
+	}
+	return nil
+}
+
+func (r *projectRepo) SetArchived(ctx context.Context, id uuid.UUID, archived bool) error {
+	query := `
+		UPDATE projects SET
+			archived_at = CASE WHEN $1 THEN COALESCE(archived_at, NOW()) END,
+			updated_at = NOW()
+		WHERE id = $2`
+
+	res, err := r.db.Exec(ctx, query, archived, id)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrProjectNotFound
+	}
+	return nil
+}
+
+func (r *projectRepo) ListEnvironments(ctx context.Context, projectID uuid.UUID) ([]*domain.Environment, error) {
+	query := `
+		SELECT id, project_id, name, created_at
+		FROM project_environments
+		WHERE project_id = $1
+		ORDER BY created_at`
+
+	rows, err := r.db.Query(ctx, query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	environments := []*domain.Environment{}
+
+	for rows.Next() {
+		env := &domain.Environment{}
+		if err := rows.Scan(&env.ID, &env.ProjectID, &env.Name, &env.CreatedAt); err != nil {
+			return nil, err
+		}
+		environments = append(environments, env)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return environments, nil
+}
+
+func (r *projectRepo) CreateEnvironment(ctx context.Context, env *domain.Environment) error {
+	query := `
+		INSERT INTO project_environments (project_id, name)
+		VALUES ($1, $2)
+		RETURNING id, created_at`
+
+	err := r.db.QueryRow(ctx, query, env.ProjectID, env.Name).Scan(&env.ID, &env.CreatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return domain.ErrEnvironmentExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *projectRepo) DeleteEnvironment(ctx context.Context, id uuid.UUID, projectID uuid.UUID) error {
+	query := `DELETE FROM project_environments WHERE id = $1 AND project_id = $2`
+
+	res, err := r.db.Exec(ctx, query, id, projectID)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrEnvironmentNotFound
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+)
+
+type refreshTokenRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewRefreshTokenRepository(db *pgxpool.Pool) domain.RefreshTokenRepository {
+	return &refreshTokenRepo{db: db}
+}
+
+func (r *refreshTokenRepo) Create(ctx context.Context, token *domain.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (
+			developer_id, session_id, token_hash, expires_at
+		)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+
+	return r.db.QueryRow(
+		ctx, query, token.DeveloperID, token.SessionID, token.TokenHash, token.ExpiresAt,
+	).Scan(&token.ID, &token.CreatedAt)
+}
+
+func (r *refreshTokenRepo) GetByHash(ctx context.Context, tokenHash string) (*domain.RefreshToken, error) {
+	query := `
+		SELECT id, developer_id, session_id, token_hash, expires_at,
+		       consumed_at, revoked_at, created_at
+		FROM refresh_tokens WHERE token_hash = $1`
+
+	token := &domain.RefreshToken{}
+	err := r.db.QueryRow(ctx, query, tokenHash).Scan(
+		&token.ID, &token.DeveloperID, &token.SessionID, &token.TokenHash, &token.ExpiresAt,
+		&token.ConsumedAt, &token.RevokedAt, &token.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func (r *refreshTokenRepo) Rotate(ctx context.Context, consumedID uuid.UUID, next *domain.RefreshToken) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Only one caller can consume a given token; a second attempt is reuse
+	consumeQuery := `
+		UPDATE refresh_tokens SET consumed_at = NOW()
+		WHERE id = $1 AND consumed_at IS NULL AND revoked_at IS NULL`
+
+	res, err := tx.Exec(ctx, consumeQuery, consumedID)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrRefreshTokenReused
+	}
+
+	insertQuery := `
+		INSERT INTO refresh_tokens (
+			developer_id, session_id, token_hash, expires_at
+		)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+
+	err = tx.QueryRow(
+		ctx, insertQuery, next.DeveloperID, next.SessionID, next.TokenHash, next.ExpiresAt,
+	).Scan(&next.ID, &next.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
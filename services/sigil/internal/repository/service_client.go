@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+)
+
+type serviceClientRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewServiceClientRepository(db *pgxpool.Pool) domain.ServiceClientRepository {
+	return &serviceClientRepo{db: db}
+}
+
+func (r *serviceClientRepo) Create(ctx context.Context, client *domain.ServiceClient, secret *domain.ServiceClientSecret) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO service_clients (
+			project_id, name, client_id, scopes, created_by
+		)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`
+
+	err = tx.QueryRow(
+		ctx, query, client.ProjectID, client.Name, client.ClientID, client.Scopes, client.CreatedBy,
+	).Scan(&client.ID, &client.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	secret.ServiceClientID = client.ID
+	if err := insertClientSecret(ctx, tx, secret); err != nil {
+		return err
+	}
+	client.Secrets = []*domain.ServiceClientSecret{secret}
+
+	return tx.Commit(ctx)
+}
+
+func (r *serviceClientRepo) ListByProject(ctx context.Context, projectID uuid.UUID) ([]*domain.ServiceClient, error) {
+	query := `
+		SELECT c.id, c.project_id, c.name, c.client_id, c.scopes, c.created_by,
+		       c.revoked_at, c.last_used_at, c.created_at,
+		       s.id, s.prefix, s.secret_hash, s.expires_at, s.created_at
+		FROM service_clients c
+		JOIN service_client_secrets s ON s.service_client_id = c.id
+		WHERE c.project_id = $1 AND c.revoked_at IS NULL
+		  AND (s.expires_at IS NULL OR s.expires_at > NOW())
+		ORDER BY c.created_at, s.created_at DESC`
+
+	rows, err := r.db.Query(ctx, query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clients := []*domain.ServiceClient{}
+	var current *domain.ServiceClient
+
+	for rows.Next() {
+		client := &domain.ServiceClient{}
+		secret := &domain.ServiceClientSecret{}
+		if err := rows.Scan(
+			&client.ID,
+			&client.ProjectID,
+			&client.Name,
+			&client.ClientID,
+			&client.Scopes,
+			&client.CreatedBy,
+			&client.RevokedAt,
+			&client.LastUsedAt,
+			&client.CreatedAt,
+			&secret.ID,
+			&secret.Prefix,
+			&secret.SecretHash,
+			&secret.ExpiresAt,
+			&secret.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		// Rows arrive grouped by client
+		if current == nil || current.ID != client.ID {
+			current = client
+			clients = append(clients, current)
+		}
+		secret.ServiceClientID = current.ID
+		current.Secrets = append(current.Secrets, secret)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return clients, nil
+}
+
+func (r *serviceClientRepo) GetByID(ctx context.Context, id uuid.UUID, projectID uuid.UUID) (*domain.ServiceClient, error) {
+	query := `
+		SELECT id, project_id, name, client_id, scopes, created_by,
+		       revoked_at, last_used_at, created_at
+		FROM service_clients WHERE id = $1 AND project_id = $2`
+
+	client := &domain.ServiceClient{}
+	err := r.db.QueryRow(ctx, query, id, projectID).Scan(
+		&client.ID, &client.ProjectID, &client.Name, &client.ClientID, &client.Scopes,
+		&client.CreatedBy, &client.RevokedAt, &client.LastUsedAt, &client.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrServiceClientNotFound
+		}
+		return nil, err
+	}
+
+	return client, nil
+}
+
+func (r *serviceClientRepo) GetByClientID(ctx context.Context, clientID string) (*domain.ServiceClient, error) {
+	query := `
+		SELECT id, project_id, name, client_id, scopes, created_by,
+		       revoked_at, last_used_at, created_at
+		FROM service_clients WHERE client_id = $1`
+
+	client := &domain.ServiceClient{}
+	err := r.db.QueryRow(ctx, query, clientID).Scan(
+		&client.ID, &client.ProjectID, &client.Name, &client.ClientID, &client.Scopes,
+		&client.CreatedBy, &client.RevokedAt, &client.LastUsedAt, &client.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrServiceClientNotFound
+		}
+		return nil, err
+	}
+
+	return client, nil
+}
+
+func (r *serviceClientRepo) Revoke(ctx context.Context, id uuid.UUID, projectID uuid.UUID) error {
+	query := `
+		UPDATE service_clients SET revoked_at = NOW()
+		WHERE id = $1 AND project_id = $2 AND revoked_at IS NULL`
+
+	res, err := r.db.Exec(ctx, query, id, projectID)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrServiceClientNotFound
+	}
+	return nil
+}
+
+func (r *serviceClientRepo) RotateSecret(ctx context.Context, id uuid.UUID, projectID uuid.UUID, secret *domain.ServiceClientSecret, retireAt time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the client so concurrent rotations apply one after the other
+	lock := `
+		SELECT id FROM service_clients
+		WHERE id = $1 AND project_id = $2 AND revoked_at IS NULL
+		FOR UPDATE`
+
+	if err := tx.QueryRow(ctx, lock, id, projectID).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrServiceClientNotFound
+		}
+		return err
+	}
+
+	// Never extend a secret that is already due to expire sooner
+	retire := `
+		UPDATE service_client_secrets
+		SET expires_at = LEAST(COALESCE(expires_at, $2), $2)
+		WHERE service_client_id = $1 AND (expires_at IS NULL OR expires_at > NOW())`
+
+	if _, err := tx.Exec(ctx, retire, id, retireAt); err != nil {
+		return err
+	}
+
+	secret.ServiceClientID = id
+	if err := insertClientSecret(ctx, tx, secret); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *serviceClientRepo) MatchSecret(ctx context.Context, id uuid.UUID, secretHash string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM service_client_secrets
+			WHERE service_client_id = $1 AND secret_hash = $2
+			  AND (expires_at IS NULL OR expires_at > NOW())
+		)`
+
+	var matched bool
+	if err := r.db.QueryRow(ctx, query, id, secretHash).Scan(&matched); err != nil {
+		return false, err
+	}
+	return matched, nil
+}
+
+func (r *serviceClientRepo) Touch(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE service_clients SET last_used_at = NOW()
+		WHERE id = $1
+		  AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')`
+
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
+
+func insertClientSecret(ctx context.Context, tx pgx.Tx, secret *domain.ServiceClientSecret) error {
+	query := `
+		INSERT INTO service_client_secrets (service_client_id, prefix, secret_hash)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at`
+
+	return tx.QueryRow(ctx, query, secret.ServiceClientID, secret.Prefix, secret.SecretHash).
+		Scan(&secret.ID, &secret.CreatedAt)
+}
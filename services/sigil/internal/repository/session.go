@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+)
+
+type sessionRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewSessionRepository(db *pgxpool.Pool) domain.SessionRepository {
+	return &sessionRepo{db: db}
+}
+
+func (r *sessionRepo) Create(ctx context.Context, session *domain.Session) error {
+	query := `
+		INSERT INTO sessions (
+			developer_id, oauth_client_id, scopes, device_name, ip_address, user_agent, expires_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, last_used_at`
+
+	return r.db.QueryRow(
+		ctx, query, session.DeveloperID, session.OAuthClientID, session.Scopes, session.DeviceName,
+		session.IPAddress, session.UserAgent, session.ExpiresAt,
+	).Scan(&session.ID, &session.CreatedAt, &session.LastUsedAt)
+}
+
+func (r *sessionRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Session, error) {
+	query := `
+		SELECT id, developer_id, organization_id, oauth_client_id, scopes, device_name,
+		       ip_address, user_agent, expires_at, revoked_at, created_at, last_used_at
+		FROM sessions WHERE id = $1`
+
+	session := &domain.Session{}
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&session.ID, &session.DeveloperID, &session.OrganizationID, &session.OAuthClientID, &session.Scopes,
+		&session.DeviceName, &session.IPAddress, &session.UserAgent, &session.ExpiresAt, &session.RevokedAt, &session.CreatedAt, &session.LastUsedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrSessionNotFound
+		}
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (r *sessionRepo) ListActive(ctx context.Context, developerID uuid.UUID) ([]*domain.Session, error) {
+	query := `
+		SELECT id, developer_id, organization_id, oauth_client_id, scopes, device_name,
+		       ip_address, user_agent, expires_at, revoked_at, created_at, last_used_at
+		FROM sessions
+		WHERE developer_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_used_at DESC`
+
+	rows, err := r.db.Query(ctx, query, developerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*domain.Session{}
+
+	for rows.Next() {
+		session := &domain.Session{}
+		if err := rows.Scan(
+			&session.ID,
+			&session.DeveloperID,
+			&session.OrganizationID,
+			&session.OAuthClientID,
+			&session.Scopes,
+			&session.DeviceName,
+			&session.IPAddress,
+			&session.UserAgent,
+			&session.ExpiresAt,
+			&session.RevokedAt,
+			&session.CreatedAt,
+			&session.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (r *sessionRepo) Touch(ctx context.Context, id uuid.UUID, ipAddress string, expiresAt time.Time) error {
+	query := `
+		UPDATE sessions SET
+			ip_address = $1,
+			expires_at = $2,
+			last_used_at = NOW()
+		WHERE id = $3 AND revoked_at IS NULL`
+
+	res, err := r.db.Exec(ctx, query, ipAddress, expiresAt, id)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrSessionNotFound
+	}
+	return nil
+}
+
+func (r *sessionRepo) SetOrganization(ctx context.Context, id uuid.UUID, developerID uuid.UUID, orgID *uuid.UUID) error {
+	query := `
+		UPDATE sessions SET organization_id = $1
+		WHERE id = $2 AND developer_id = $3 AND revoked_at IS NULL`
+
+	res, err := r.db.Exec(ctx, query, orgID, id, developerID)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrSessionNotFound
+	}
+	return nil
+}
+
+func (r *sessionRepo) ClearOrganization(ctx context.Context, developerID uuid.UUID, orgID uuid.UUID) error {
+	query := `
+		UPDATE sessions SET organization_id = NULL
+		WHERE developer_id = $1 AND organization_id = $2`
+
+	_, err := r.db.Exec(ctx, query, developerID, orgID)
+	return err
+}
+
+func (r *sessionRepo) Revoke(ctx context.Context, id uuid.UUID, developerID uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE sessions SET revoked_at = NOW()
+		WHERE id = $1 AND developer_id = $2 AND revoked_at IS NULL`
+
+	res, err := tx.Exec(ctx, query, id, developerID)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrSessionNotFound
+	}
+
+	if err := revokeRefreshTokens(ctx, tx, []uuid.UUID{id}); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *sessionRepo) RevokeAllExcept(ctx context.Context, developerID uuid.UUID, keepID uuid.UUID) ([]uuid.UUID, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE sessions SET revoked_at = NOW()
+		WHERE developer_id = $1 AND id != $2 AND revoked_at IS NULL
+		RETURNING id`
+
+	rows, err := tx.Query(ctx, query, developerID, keepID)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return nil, err
+	}
+
+	if err := revokeRefreshTokens(ctx, tx, ids); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *sessionRepo) ListRevokedSince(ctx context.Context, since time.Time) ([]uuid.UUID, error) {
+	query := `SELECT id FROM sessions WHERE revoked_at >= $1`
+
+	rows, err := r.db.Query(ctx, query, since)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+}
+
+func revokeRefreshTokens(ctx context.Context, tx pgx.Tx, sessionIDs []uuid.UUID) error {
+	if len(sessionIDs) == 0 {
+		return nil
+	}
+
+	query := `
+		UPDATE refresh_tokens SET revoked_at = NOW()
+		WHERE session_id = ANY($1) AND revoked_at IS NULL`
+
+	_, err := tx.Exec(ctx, query, sessionIDs)
+	return err
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+)
+
+type usageRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewUsageRepository(db *pgxpool.Pool) domain.UsageRepository {
+	return &usageRepo{db: db}
+}
+
+func (r *usageRepo) CountProjectsByDeveloper(ctx context.Context, developerID uuid.UUID) (int, error) {
+	query := `
+		SELECT COUNT(*) FROM projects
+		WHERE owner_developer_id = $1 AND archived_at IS NULL`
+
+	return r.count(ctx, query, developerID)
+}
+
+func (r *usageRepo) CountProjectsByOrganization(ctx context.Context, orgID uuid.UUID) (int, error) {
+	query := `
+		SELECT COUNT(*) FROM projects
+		WHERE owner_organization_id = $1 AND archived_at IS NULL`
+
+	return r.count(ctx, query, orgID)
+}
+
+func (r *usageRepo) CountActiveAPIKeys(ctx context.Context, developerID uuid.UUID) (int, error) {
+	query := `
+		SELECT COUNT(*) FROM api_keys
+		WHERE developer_id = $1 AND revoked_at IS NULL
+		  AND (expires_at IS NULL OR expires_at > NOW())`
+
+	return r.count(ctx, query, developerID)
+}
+
+func (r *usageRepo) CountOrgSeats(ctx context.Context, orgID uuid.UUID, exceptEmail string) (int, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM organization_members WHERE organization_id = $1) +
+			(SELECT COUNT(*) FROM organization_invitations
+			 WHERE organization_id = $1 AND accepted_at IS NULL
+			   AND revoked_at IS NULL AND expires_at > NOW()
+			   AND LOWER(email) <> LOWER($2))`
+
+	return r.count(ctx, query, orgID, exceptEmail)
+}
+
+func (r *usageRepo) GetOrganizationPlanTier(ctx context.Context, orgID uuid.UUID) (string, error) {
+	query := `
+		SELECT d.plan_tier
+		FROM organization_members m
+		JOIN developers d ON d.id = m.developer_id
+		WHERE m.organization_id = $1 AND m.role = 'owner'`
+
+	var tier *string
+	if err := r.db.QueryRow(ctx, query, orgID).Scan(&tier); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", domain.ErrOrganizationNotFound
+		}
+		return "", err
+	}
+	if tier == nil {
+		return domain.PlanFree, nil
+	}
+	return *tier, nil
+}
+
+func (r *usageRepo) count(ctx context.Context, query string, args ...any) (int, error) {
+	var n int
+	if err := r.db.QueryRow(ctx, query, args...).Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
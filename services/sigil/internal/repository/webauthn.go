@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+)
+
+type webAuthnRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewWebAuthnRepository(db *pgxpool.Pool) domain.WebAuthnRepository {
+	return &webAuthnRepo{db: db}
+}
+
+func (r *webAuthnRepo) CreateCredential(ctx context.Context, credential *domain.WebAuthnCredential) error {
+	query := `
+		INSERT INTO webauthn_credentials (
+			developer_id, name, credential_id, data
+		)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+
+	err := r.db.QueryRow(
+		ctx, query, credential.DeveloperID, credential.Name, credential.CredentialID, credential.Data,
+	).Scan(&credential.ID, &credential.CreatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return domain.ErrCredentialExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *webAuthnRepo) ListCredentials(ctx context.Context, developerID uuid.UUID) ([]*domain.WebAuthnCredential, error) {
+	query := `
+		SELECT id, developer_id, name, credential_id, data, created_at, last_used_at
+		FROM webauthn_credentials
+		WHERE developer_id = $1
+		ORDER BY created_at`
+
+	rows, err := r.db.Query(ctx, query, developerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	credentials := []*domain.WebAuthnCredential{}
+
+	for rows.Next() {
+		credential := &domain.WebAuthnCredential{}
+		if err := rows.Scan(
+			&credential.ID,
+			&credential.DeveloperID,
+			&credential.Name,
+			&credential.CredentialID,
+			&credential.Data,
+			&credential.CreatedAt,
+			&credential.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, credential)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return credentials, nil
+}
+
+func (r *webAuthnRepo) GetCredential(ctx context.Context, credentialID []byte) (*domain.WebAuthnCredential, error) {
+	query := `
+		SELECT id, developer_id, name, credential_id, data, created_at, last_used_at
+		FROM webauthn_credentials WHERE credential_id = $1`
+
+	credential := &domain.WebAuthnCredential{}
+	err := r.db.QueryRow(ctx, query, credentialID).Scan(
+		&credential.ID, &credential.DeveloperID, &credential.Name, &credential.CredentialID,
+		&credential.Data, &credential.CreatedAt, &credential.LastUsedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrCredentialNotFound
+		}
+		return nil, err
+	}
+
+	return credential, nil
+}
+
+func (r *webAuthnRepo) UpdateCredentialData(ctx context.Context, id uuid.UUID, data []byte) error {
+	query := `
+		UPDATE webauthn_credentials SET
+			data = $1,
+			last_used_at = NOW()
+		WHERE id = $2`
+
+	res, err := r.db.Exec(ctx, query, data, id)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrCredentialNotFound
+	}
+	return nil
+}
+
+func (r *webAuthnRepo) DeleteCredential(ctx context.Context, id uuid.UUID, developerID uuid.UUID) error {
+	query := `DELETE FROM webauthn_credentials WHERE id = $1 AND developer_id = $2`
+
+	res, err := r.db.Exec(ctx, query, id, developerID)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrCredentialNotFound
+	}
+	return nil
+}
+
+func (r *webAuthnRepo) SaveChallenge(ctx context.Context, challenge *domain.WebAuthnChallenge) error {
+	// Abandoned ceremonies are cleaned up opportunistically
+	if _, err := r.db.Exec(ctx, `DELETE FROM webauthn_challenges WHERE expires_at < NOW()`); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO webauthn_challenges (
+			developer_id, ceremony, session_data, expires_at
+		)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`
+
+	return r.db.QueryRow(
+		ctx, query, challenge.DeveloperID, challenge.Ceremony, challenge.SessionData, challenge.ExpiresAt,
+	).Scan(&challenge.ID)
+}
+
+func (r *webAuthnRepo) ConsumeChallenge(ctx context.Context, id uuid.UUID, ceremony string) (*domain.WebAuthnChallenge, error) {
+	query := `
+		DELETE FROM webauthn_challenges
+		WHERE id = $1 AND ceremony = $2 AND expires_at > NOW()
+		RETURNING id, developer_id, ceremony, session_data, expires_at`
+
+	challenge := &domain.WebAuthnChallenge{}
+	err := r.db.QueryRow(ctx, query, id, ceremony).Scan(
+		&challenge.ID, &challenge.DeveloperID, &challenge.Ceremony, &challenge.SessionData, &challenge.ExpiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrChallengeNotFound
+		}
+		return nil, err
+	}
+
+	return challenge, nil
+}
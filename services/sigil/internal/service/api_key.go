@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+	"github.com/vivek-344/diagon/sigil/utils"
+)
+
+// apiKeyPrefixLength is how much of a key is kept in clear: "sgl_" plus 8 characters
+const apiKeyPrefixLength = len(domain.APIKeyPrefix) + 8
+
+type APIKeyService struct {
+	repo          domain.APIKeyRepository
+	developerRepo domain.DeveloperRepository
+	plans         *PlanService
+}
+
+func NewAPIKeyService(repo domain.APIKeyRepository, developerRepo domain.DeveloperRepository, plans *PlanService) *APIKeyService {
+	return &APIKeyService{
+		repo:          repo,
+		developerRepo: developerRepo,
+		plans:         plans,
+	}
+}
+
+// Create mints a new key and returns it in clear alongside the stored record.
+// The raw key cannot be recovered afterwards.
+func (s *APIKeyService) Create(
+	ctx context.Context,
+	developerID uuid.UUID,
+	name string,
+	expiresAt *time.Time,
+	scopes []string,
+	callerScopes []string,
+) (string, *domain.APIKey, error) {
+	slog.Debug("creating api key", "developer_id", developerID)
+
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 255 {
+		return "", nil, domain.ErrInvalidInput
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return "", nil, domain.ErrInvalidInput
+	}
+
+	dev, err := s.developerRepo.GetByID(ctx, developerID)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return "", nil, err
+		}
+		return "", nil, fmt.Errorf("failed to fetch developer: %w", err)
+	}
+
+	scopes, err = keyScopes(scopes, domain.GrantedScopes(dev), callerScopes)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := s.plans.CheckAPIKeyQuota(ctx, developerID); err != nil {
+		return "", nil, err
+	}
+
+	secret, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate api key: %w", err)
+	}
+	rawKey := domain.APIKeyPrefix + secret
+
+	key := &domain.APIKey{
+		DeveloperID: developerID,
+		Name:        name,
+		Prefix:      rawKey[:apiKeyPrefixLength],
+		KeyHash:     utils.HashToken(rawKey),
+		Scopes:      scopes,
+		ExpiresAt:   expiresAt,
+	}
+	if err := s.repo.Create(ctx, key); err != nil {
+		return "", nil, fmt.Errorf("failed to store api key: %w", err)
+	}
+
+	slog.Info("api key created", "developer_id", developerID, "api_key_id", key.ID)
+	return rawKey, key, nil
+}
+
+func (s *APIKeyService) List(ctx context.Context, developerID uuid.UUID) ([]*domain.APIKey, error) {
+	keys, err := s.repo.ListActive(ctx, developerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	return keys, nil
+}
+
+func (s *APIKeyService) Revoke(ctx context.Context, developerID uuid.UUID, id uuid.UUID) error {
+	if err := s.repo.Revoke(ctx, id, developerID); err != nil {
+		if err == domain.ErrAPIKeyNotFound {
+			return err
+		}
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	slog.Info("api key revoked", "developer_id", developerID, "api_key_id", id)
+	return nil
+}
+
+// AuthenticateAPIKey resolves a raw key presented to AuthMiddleware and records its use
+func (s *APIKeyService) AuthenticateAPIKey(ctx context.Context, rawKey string, ipAddress string) (*domain.APIKey, *domain.Developer, error) {
+	if !strings.HasPrefix(rawKey, domain.APIKeyPrefix) {
+		return nil, nil, domain.ErrInvalidAPIKey
+	}
+
+	key, err := s.repo.GetByHash(ctx, utils.HashToken(rawKey))
+	if err != nil {
+		if err == domain.ErrAPIKeyNotFound {
+			return nil, nil, domain.ErrInvalidAPIKey
+		}
+		return nil, nil, fmt.Errorf("failed to fetch api key: %w", err)
+	}
+	if key.RevokedAt != nil {
+		return nil, nil, domain.ErrInvalidAPIKey
+	}
+	if key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt) {
+		return nil, nil, domain.ErrExpiredAPIKey
+	}
+
+	dev, err := s.developerRepo.GetByID(ctx, key.DeveloperID)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return nil, nil, domain.ErrInvalidAPIKey
+		}
+		return nil, nil, fmt.Errorf("failed to fetch developer: %w", err)
+	}
+	if dev.Status == domain.StatusSuspended {
+		return nil, nil, domain.ErrAccountSuspended
+	}
+
+	// A key never carries more than its owner currently holds
+	effective := make([]string, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		if domain.HasScope(domain.GrantedScopes(dev), scope) {
+			effective = append(effective, scope)
+		}
+	}
+	key.Scopes = effective
+
+	if err := s.repo.Touch(ctx, key.ID, ipAddress); err != nil {
+		slog.Warn("failed to record api key usage", "api_key_id", key.ID, "error", err)
+	}
+
+	return key, dev, nil
+}
+
+// keyScopes validates requested scopes against the developer's grant and the
+// scopes of the credential minting the key, so a key never outranks either.
+// Keys created without scopes are read-only.
+func keyScopes(requested []string, granted []string, callerScopes []string) ([]string, error) {
+	if len(requested) == 0 {
+		requested = []string{domain.ScopeDevelopersRead}
+	}
+
+	scopes := make([]string, 0, len(requested))
+	for _, scope := range requested {
+		if !domain.IsKnownScope(scope) || !domain.HasScope(granted, scope) || !domain.HasScope(callerScopes, scope) {
+			return nil, domain.ErrInvalidScope
+		}
+		if !domain.HasScope(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
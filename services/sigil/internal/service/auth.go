@@ -0,0 +1,396 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+	"github.com/vivek-344/diagon/sigil/utils"
+)
+
+type AuthService struct {
+	developerRepo domain.DeveloperRepository
+	refreshRepo   domain.RefreshTokenRepository
+	sessionRepo   domain.SessionRepository
+	orgRepo       domain.OrganizationRepository
+	oauthRepo     domain.OAuthRepository
+	revocations   *RevocationList
+	jwtManager    *utils.JWTManager
+}
+
+func NewAuthService(
+	developerRepo domain.DeveloperRepository,
+	refreshRepo domain.RefreshTokenRepository,
+	sessionRepo domain.SessionRepository,
+	orgRepo domain.OrganizationRepository,
+	oauthRepo domain.OAuthRepository,
+	revocations *RevocationList,
+	jwtManager *utils.JWTManager,
+) *AuthService {
+	return &AuthService{
+		developerRepo: developerRepo,
+		refreshRepo:   refreshRepo,
+		sessionRepo:   sessionRepo,
+		orgRepo:       orgRepo,
+		oauthRepo:     oauthRepo,
+		revocations:   revocations,
+		jwtManager:    jwtManager,
+	}
+}
+
+// IssueTokens starts a new session for the developer and returns its first token pair
+func (s *AuthService) IssueTokens(ctx context.Context, dev *domain.Developer, client domain.ClientInfo) (*utils.TokenPair, error) {
+	slog.Debug("issuing token pair", "developer_id", dev.ID)
+
+	session := &domain.Session{
+		DeveloperID: dev.ID,
+		DeviceName:  client.DeviceName,
+		IPAddress:   client.IPAddress,
+		UserAgent:   client.UserAgent,
+		ExpiresAt:   time.Now().Add(utils.RefreshTokenTTL),
+	}
+	return s.startSession(ctx, dev, session, nil, "")
+}
+
+// IssueOAuthTokens starts a session on behalf of an OAuth client, limited to
+// the consented scopes, and returns its first token pair. With the openid
+// scope the pair includes an ID token carrying nonce.
+func (s *AuthService) IssueOAuthTokens(
+	ctx context.Context,
+	dev *domain.Developer,
+	oauthClient *domain.OAuthClient,
+	scopes []string,
+	nonce string,
+	client domain.ClientInfo,
+) (*utils.TokenPair, *domain.Session, error) {
+	slog.Debug("issuing oauth token pair", "developer_id", dev.ID, "client_id", oauthClient.ClientID)
+
+	session := &domain.Session{
+		DeveloperID:   dev.ID,
+		OAuthClientID: &oauthClient.ID,
+		Scopes:        scopes,
+		DeviceName:    &oauthClient.Name,
+		IPAddress:     client.IPAddress,
+		UserAgent:     client.UserAgent,
+		ExpiresAt:     time.Now().Add(utils.RefreshTokenTTL),
+	}
+	pair, err := s.startSession(ctx, dev, session, oauthClient, nonce)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pair, session, nil
+}
+
+func (s *AuthService) startSession(ctx context.Context, dev *domain.Developer, session *domain.Session, oauthClient *domain.OAuthClient, nonce string) (*utils.TokenPair, error) {
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	accessToken, err := s.accessToken(dev, session, oauthClient, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue tokens: %w", err)
+	}
+
+	idToken, err := s.idToken(dev, session, oauthClient, nonce)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue tokens: %w", err)
+	}
+
+	refreshToken, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue tokens: %w", err)
+	}
+
+	err = s.refreshRepo.Create(ctx, &domain.RefreshToken{
+		DeveloperID: dev.ID,
+		SessionID:   session.ID,
+		TokenHash:   utils.HashToken(refreshToken),
+		ExpiresAt:   session.ExpiresAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	slog.Info("session created", "developer_id", dev.ID, "session_id", session.ID)
+	return &utils.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		IDToken:      idToken,
+	}, nil
+}
+
+// Refresh consumes a refresh token and issues a new pair in the same session.
+// Presenting an already consumed token revokes the whole session.
+func (s *AuthService) Refresh(ctx context.Context, rawToken string, client domain.ClientInfo) (*utils.TokenPair, error) {
+	pair, _, err := s.refresh(ctx, rawToken, nil, client)
+	return pair, err
+}
+
+// RefreshOAuth is Refresh for sessions started by IssueOAuthTokens; the
+// token must belong to a session of oauthClient
+func (s *AuthService) RefreshOAuth(ctx context.Context, rawToken string, oauthClient *domain.OAuthClient, client domain.ClientInfo) (*utils.TokenPair, []string, error) {
+	return s.refresh(ctx, rawToken, oauthClient, client)
+}
+
+func (s *AuthService) refresh(ctx context.Context, rawToken string, oauthClient *domain.OAuthClient, client domain.ClientInfo) (*utils.TokenPair, []string, error) {
+	current, err := s.refreshRepo.GetByHash(ctx, utils.HashToken(rawToken))
+	if err != nil {
+		if err == domain.ErrInvalidRefreshToken {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("failed to fetch refresh token: %w", err)
+	}
+
+	if current.RevokedAt != nil {
+		return nil, nil, domain.ErrInvalidRefreshToken
+	}
+	if current.ConsumedAt != nil {
+		s.revokeReusedSession(ctx, current)
+		return nil, nil, domain.ErrRefreshTokenReused
+	}
+	if time.Now().After(current.ExpiresAt) {
+		return nil, nil, domain.ErrExpiredRefreshToken
+	}
+
+	// Verify developer still exists and is active
+	dev, err := s.developerRepo.GetByID(ctx, current.DeveloperID)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("failed to fetch developer: %w", err)
+	}
+	if dev.Status == domain.StatusSuspended {
+		return nil, nil, domain.ErrAccountSuspended
+	}
+
+	session, err := s.sessionRepo.GetByID(ctx, current.SessionID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch session: %w", err)
+	}
+	// OAuth sessions refresh only through their own client, and first-party ones only at /auth/refresh
+	if !sameOAuthClient(session, oauthClient) {
+		return nil, nil, domain.ErrInvalidRefreshToken
+	}
+	org, err := s.orgContext(ctx, session)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	accessToken, err := s.accessToken(dev, session, oauthClient, org)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to issue tokens: %w", err)
+	}
+
+	// Refreshed ID tokens carry no nonce (OIDC Core 12.2)
+	idToken, err := s.idToken(dev, session, oauthClient, "")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to issue tokens: %w", err)
+	}
+
+	refreshToken, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to issue tokens: %w", err)
+	}
+
+	expiresAt := time.Now().Add(utils.RefreshTokenTTL)
+	err = s.refreshRepo.Rotate(ctx, current.ID, &domain.RefreshToken{
+		DeveloperID: dev.ID,
+		SessionID:   current.SessionID,
+		TokenHash:   utils.HashToken(refreshToken),
+		ExpiresAt:   expiresAt,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrRefreshTokenReused) {
+			// Lost a race against another use of the same token
+			s.revokeReusedSession(ctx, current)
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	if err := s.sessionRepo.Touch(ctx, current.SessionID, client.IPAddress, expiresAt); err != nil {
+		slog.Warn("failed to update session last use", "session_id", current.SessionID, "error", err)
+	}
+
+	slog.Debug("refresh token rotated", "developer_id", dev.ID, "session_id", current.SessionID)
+	return &utils.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		IDToken:      idToken,
+	}, sessionScopes(dev, session), nil
+}
+
+// SwitchOrganization sets the organization the session acts within and
+// returns an access token carrying it; a nil orgID returns to personal context
+func (s *AuthService) SwitchOrganization(ctx context.Context, developerID uuid.UUID, sessionID uuid.UUID, orgID *uuid.UUID) (string, error) {
+	slog.Debug("switching organization", "developer_id", developerID, "session_id", sessionID, "org_id", orgID)
+
+	dev, err := s.developerRepo.GetByID(ctx, developerID)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return "", err
+		}
+		return "", fmt.Errorf("failed to fetch developer: %w", err)
+	}
+
+	var org *utils.OrgContext
+	if orgID != nil {
+		role, err := s.orgRepo.GetMemberRole(ctx, *orgID, developerID)
+		if err != nil {
+			if err == domain.ErrNotOrganizationMember {
+				return "", err
+			}
+			return "", fmt.Errorf("failed to fetch membership: %w", err)
+		}
+		org = &utils.OrgContext{ID: *orgID, Role: string(role)}
+	}
+
+	if err := s.sessionRepo.SetOrganization(ctx, sessionID, developerID, orgID); err != nil {
+		if err == domain.ErrSessionNotFound {
+			return "", err
+		}
+		return "", fmt.Errorf("failed to update session: %w", err)
+	}
+
+	// The new token keeps the session's scopes and OAuth client
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch session: %w", err)
+	}
+	var oauthClient *domain.OAuthClient
+	if session.OAuthClientID != nil {
+		if oauthClient, err = s.oauthRepo.GetClientByID(ctx, *session.OAuthClientID); err != nil {
+			return "", fmt.Errorf("failed to fetch oauth client: %w", err)
+		}
+	}
+
+	accessToken, err := s.accessToken(dev, session, oauthClient, org)
+	if err != nil {
+		return "", fmt.Errorf("failed to issue access token: %w", err)
+	}
+
+	slog.Info("organization context switched", "developer_id", developerID, "session_id", sessionID, "org_id", orgID)
+	return accessToken, nil
+}
+
+func (s *AuthService) ListSessions(ctx context.Context, developerID uuid.UUID) ([]*domain.Session, error) {
+	slog.Debug("listing sessions", "developer_id", developerID)
+	sessions, err := s.sessionRepo.ListActive(ctx, developerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs out a single session owned by the developer
+func (s *AuthService) RevokeSession(ctx context.Context, developerID uuid.UUID, sessionID uuid.UUID) error {
+	slog.Debug("revoking session", "developer_id", developerID, "session_id", sessionID)
+	err := s.sessionRepo.Revoke(ctx, sessionID, developerID)
+	if err != nil {
+		if err == domain.ErrSessionNotFound {
+			return err
+		}
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	s.revocations.Add(sessionID)
+	slog.Info("session revoked", "developer_id", developerID, "session_id", sessionID)
+	return nil
+}
+
+// RevokeOtherSessions signs out every session except keepID; uuid.Nil signs out all of them
+func (s *AuthService) RevokeOtherSessions(ctx context.Context, developerID uuid.UUID, keepID uuid.UUID) error {
+	slog.Debug("revoking other sessions", "developer_id", developerID, "kept_session_id", keepID)
+	ids, err := s.sessionRepo.RevokeAllExcept(ctx, developerID, keepID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	s.revocations.Add(ids...)
+	slog.Info("sessions revoked", "developer_id", developerID, "count", len(ids))
+	return nil
+}
+
+// orgContext re-reads the member's role so refreshed tokens follow role
+// changes; a session whose membership ended falls back to personal context
+func (s *AuthService) orgContext(ctx context.Context, session *domain.Session) (*utils.OrgContext, error) {
+	if session.OrganizationID == nil {
+		return nil, nil
+	}
+
+	role, err := s.orgRepo.GetMemberRole(ctx, *session.OrganizationID, session.DeveloperID)
+	if err != nil {
+		if err == domain.ErrNotOrganizationMember {
+			if err := s.sessionRepo.SetOrganization(ctx, session.ID, session.DeveloperID, nil); err != nil {
+				slog.Warn("failed to clear organization context", "session_id", session.ID, "error", err)
+			}
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to fetch membership: %w", err)
+	}
+
+	return &utils.OrgContext{ID: *session.OrganizationID, Role: string(role)}, nil
+}
+
+// accessToken issues an access token for the session. OAuth sessions carry
+// their consented scopes, less any the developer no longer holds.
+func (s *AuthService) accessToken(dev *domain.Developer, session *domain.Session, oauthClient *domain.OAuthClient, org *utils.OrgContext) (string, error) {
+	scopes := sessionScopes(dev, session)
+	if oauthClient != nil {
+		return s.jwtManager.GenerateDelegatedAccessToken(dev.ID, dev.Email, string(dev.Role), session.ID, oauthClient.ClientID, scopes, org)
+	}
+	return s.jwtManager.GenerateAccessToken(dev.ID, dev.Email, string(dev.Role), session.ID, scopes, org)
+}
+
+// idToken issues an ID token for OAuth sessions holding the openid scope;
+// other sessions get none
+func (s *AuthService) idToken(dev *domain.Developer, session *domain.Session, oauthClient *domain.OAuthClient, nonce string) (string, error) {
+	scopes := sessionScopes(dev, session)
+	if oauthClient == nil || !domain.HasScope(scopes, domain.ScopeOpenID) {
+		return "", nil
+	}
+
+	info := domain.NewUserInfo(dev, scopes)
+	return s.jwtManager.GenerateIDToken(dev.ID, oauthClient.ClientID, utils.IDTokenClaims{
+		Email:         info.Email,
+		EmailVerified: info.EmailVerified,
+		Name:          info.Name,
+		Nonce:         nonce,
+		SessionID:     session.ID,
+	})
+}
+
+func sessionScopes(dev *domain.Developer, session *domain.Session) []string {
+	granted := domain.GrantedScopes(dev)
+	if session.Scopes == nil {
+		return granted
+	}
+
+	scopes := make([]string, 0, len(session.Scopes))
+	for _, scope := range session.Scopes {
+		// Identity scopes stay; API scopes must still be held
+		if domain.HasScope(granted, scope) || domain.IsOIDCScope(scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+func sameOAuthClient(session *domain.Session, oauthClient *domain.OAuthClient) bool {
+	if session.OAuthClientID == nil || oauthClient == nil {
+		return session.OAuthClientID == nil && oauthClient == nil
+	}
+	return *session.OAuthClientID == oauthClient.ID
+}
+
+func (s *AuthService) revokeReusedSession(ctx context.Context, token *domain.RefreshToken) {
+	slog.Warn("refresh token reuse detected, revoking session",
+		"developer_id", token.DeveloperID, "session_id", token.SessionID)
+	if err := s.RevokeSession(ctx, token.DeveloperID, token.SessionID); err != nil && err != domain.ErrSessionNotFound {
+		slog.Error("failed to revoke reused session", "session_id", token.SessionID, "error", err)
+	}
+}
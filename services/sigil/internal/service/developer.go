@@ -13,11 +13,17 @@ import (
 )
 
 type DeveloperService struct {
-	repo domain.DeveloperRepository
+	repo    domain.DeveloperRepository
+	plans   *PlanService
+	authSvc *AuthService
 }
 
-func NewDeveloperService(repo domain.DeveloperRepository) *DeveloperService {
-	return &DeveloperService{repo: repo}
+func NewDeveloperService(repo domain.DeveloperRepository, plans *PlanService, authSvc *AuthService) *DeveloperService {
+	return &DeveloperService{
+		repo:    repo,
+		plans:   plans,
+		authSvc: authSvc,
+	}
 }
 
 func (s *DeveloperService) Create(ctx context.Context, input domain.CreateDeveloperInput, passwordHash string) (*domain.Developer, error) {
@@ -66,8 +72,12 @@ func (s *DeveloperService) VerifyEmail(ctx context.Context, id uuid.UUID) error
 	return nil
 }
 
-func (s *DeveloperService) GetByID(ctx context.Context, id uuid.UUID) (*domain.Developer, error) {
+// GetByID returns a developer to themselves or to staff
+func (s *DeveloperService) GetByID(ctx context.Context, actor domain.Actor, id uuid.UUID) (*domain.Developer, error) {
 	slog.Debug("fetching developer by ID", "developer_id", id)
+	if !actor.IsSelf(id) && !actor.Role.IsStaff() {
+		return nil, domain.ErrForbidden
+	}
 	dev, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		if err == domain.ErrNotFound {
@@ -93,21 +103,29 @@ func (s *DeveloperService) GetByEmail(ctx context.Context, email string) (*domai
 	return dev, nil
 }
 
-func (s *DeveloperService) GetAll(ctx context.Context, filter domain.DeveloperFilter, page int, pageSize int) ([]*domain.Developer, error) {
-	slog.Debug("fetching all developers", "filter", filter, "page", page, "page_size", pageSize)
-	res, err := s.repo.GetAll(ctx, filter, page, pageSize)
+// GetAll lists developers a page at a time; staff only
+func (s *DeveloperService) GetAll(ctx context.Context, actor domain.Actor, filter domain.DeveloperFilter, page domain.DeveloperPageRequest) (*domain.DeveloperPage, error) {
+	slog.Debug("fetching all developers", "filter", filter, "limit", page.Limit)
+	if !actor.Role.IsStaff() {
+		return nil, domain.ErrForbidden
+	}
+	res, err := s.repo.GetAll(ctx, filter, page)
 	if err != nil {
-		if err == domain.ErrNotFound {
-			return nil, err
-		}
 		return nil, fmt.Errorf("failed to fetch developers: %w", err)
 	}
-	slog.Debug("developers fetched successfully", "data", res)
+	slog.Debug("developers fetched successfully", "count", len(res.Developers))
 	return res, nil
 }
 
-func (s *DeveloperService) UpdatePassword(ctx context.Context, id uuid.UUID, oldPassword string, newPassword string) error {
+// UpdatePassword changes the actor's own password
+func (s *DeveloperService) UpdatePassword(ctx context.Context, actor domain.Actor, id uuid.UUID, oldPassword string, newPassword string) error {
 	slog.Debug("updating developer password", "developer_id", id)
+	if !actor.IsSelf(id) {
+		return domain.ErrForbidden
+	}
+	if err := utils.IsStrongPassword(newPassword); err != nil {
+		return err
+	}
 
 	dev, err := s.repo.GetByID(ctx, id)
 	if err != nil {
@@ -137,11 +155,19 @@ func (s *DeveloperService) UpdatePassword(ctx context.Context, id uuid.UUID, old
 	return nil
 }
 
-func (s *DeveloperService) Update(ctx context.Context, id uuid.UUID, input *domain.UpdateDeveloperInput) error {
+// Update applies a partial update. Developers may edit their own profile;
+// status and plan tier are reserved for admins.
+func (s *DeveloperService) Update(ctx context.Context, actor domain.Actor, id uuid.UUID, input *domain.UpdateDeveloperInput) error {
 	slog.Debug("updating developer info", "developer_id", id)
+	if err := s.validateUpdate(input); err != nil {
+		return err
+	}
+	if err := s.authorize(ctx, actor, id, domain.RoleAdmin, !input.Privileged()); err != nil {
+		return err
+	}
 	err := s.repo.Update(ctx, id, input)
 	if err != nil {
-		if err == domain.ErrNotFound {
+		if err == domain.ErrNotFound || err == domain.ErrPreconditionFailed {
 			return err
 		}
 		return fmt.Errorf("failed to update developer info: %w", err)
@@ -180,8 +206,11 @@ func (s *DeveloperService) ResetPassword(ctx context.Context, id uuid.UUID, newP
 	return nil
 }
 
-func (s *DeveloperService) AddMetadata(ctx context.Context, id uuid.UUID, key string, value any) error {
+func (s *DeveloperService) AddMetadata(ctx context.Context, actor domain.Actor, id uuid.UUID, key string, value any) error {
 	slog.Debug("adding metadata to developer", "developer_id", id, "key", key)
+	if err := s.authorize(ctx, actor, id, domain.RoleAdmin, true); err != nil {
+		return err
+	}
 	err := s.repo.AddMetadata(ctx, id, key, value)
 	if err != nil {
 		if err == domain.ErrNotFound {
@@ -193,11 +222,20 @@ func (s *DeveloperService) AddMetadata(ctx context.Context, id uuid.UUID, key st
 	return nil
 }
 
-func (s *DeveloperService) Delete(ctx context.Context, id uuid.UUID) error {
+// Delete removes a developer for good. Their sessions are revoked first: the
+// rows cascade away with the developer, so the revocation list would never
+// learn of them and issued access tokens would outlive the account.
+func (s *DeveloperService) Delete(ctx context.Context, actor domain.Actor, id uuid.UUID, ifVersion *int64) error {
 	slog.Debug("deleting developer", "developer_id", id)
-	err := s.repo.Delete(ctx, id)
+	if err := s.authorize(ctx, actor, id, domain.RoleAdmin, true); err != nil {
+		return err
+	}
+	if err := s.authSvc.RevokeOtherSessions(ctx, id, uuid.Nil); err != nil {
+		return err
+	}
+	err := s.repo.Delete(ctx, id, ifVersion)
 	if err != nil {
-		if err == domain.ErrNotFound {
+		if err == domain.ErrNotFound || err == domain.ErrPreconditionFailed {
 			return err
 		}
 		return fmt.Errorf("failed to delete developer: %w", err)
@@ -206,11 +244,14 @@ func (s *DeveloperService) Delete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
-func (s *DeveloperService) SoftDelete(ctx context.Context, id uuid.UUID) error {
+func (s *DeveloperService) SoftDelete(ctx context.Context, actor domain.Actor, id uuid.UUID, ifVersion *int64) error {
 	slog.Debug("soft deleting developer", "developer_id", id)
-	err := s.repo.SoftDelete(ctx, id)
+	if err := s.authorize(ctx, actor, id, domain.RoleAdmin, true); err != nil {
+		return err
+	}
+	err := s.repo.SoftDelete(ctx, id, ifVersion)
 	if err != nil {
-		if err == domain.ErrNotFound {
+		if err == domain.ErrNotFound || err == domain.ErrPreconditionFailed {
 			return err
 		}
 		return fmt.Errorf("failed to soft delete developer: %w", err)
@@ -219,11 +260,18 @@ func (s *DeveloperService) SoftDelete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
-func (s *DeveloperService) Suspend(ctx context.Context, id uuid.UUID) error {
+// Suspend blocks a developer from signing in; staff only, never yourself
+func (s *DeveloperService) Suspend(ctx context.Context, actor domain.Actor, id uuid.UUID, ifVersion *int64) error {
 	slog.Debug("suspending developer", "developer_id", id)
-	err := s.repo.Suspend(ctx, id)
+	if actor.IsSelf(id) {
+		return domain.ErrForbidden
+	}
+	if err := s.authorize(ctx, actor, id, domain.RoleSupport, false); err != nil {
+		return err
+	}
+	err := s.repo.Suspend(ctx, id, ifVersion)
 	if err != nil {
-		if err == domain.ErrNotFound {
+		if err == domain.ErrNotFound || err == domain.ErrPreconditionFailed {
 			return err
 		}
 		return fmt.Errorf("failed to suspend developer: %w", err)
@@ -231,3 +279,107 @@ func (s *DeveloperService) Suspend(ctx context.Context, id uuid.UUID) error {
 	slog.Info("developer suspended", "developer_id", id)
 	return nil
 }
+
+// SetRole changes a developer's role; superadmins only, and not their own
+func (s *DeveloperService) SetRole(ctx context.Context, actor domain.Actor, id uuid.UUID, role domain.Role, ifVersion *int64) error {
+	slog.Debug("changing developer role", "developer_id", id, "role", role)
+	if !role.Valid() {
+		return domain.ErrInvalidRole
+	}
+	if actor.Role != domain.RoleSuperadmin || actor.IsSelf(id) {
+		return domain.ErrForbidden
+	}
+
+	err := s.repo.SetRole(ctx, id, role, ifVersion)
+	if err != nil {
+		if err == domain.ErrNotFound || err == domain.ErrPreconditionFailed {
+			return err
+		}
+		return fmt.Errorf("failed to change role: %w", err)
+	}
+	slog.Info("developer role changed", "developer_id", id, "role", role, "by", actor.ID)
+	return nil
+}
+
+// BootstrapSuperadmin promotes the developer with the given email while no
+// superadmin exists yet, so the first operator can be created from config
+func (s *DeveloperService) BootstrapSuperadmin(ctx context.Context, email string) error {
+	count, err := s.repo.CountByRole(ctx, domain.RoleSuperadmin)
+	if err != nil {
+		return fmt.Errorf("failed to count superadmins: %w", err)
+	}
+	if count > 0 {
+		slog.Debug("superadmin already exists, skipping bootstrap")
+		return nil
+	}
+
+	dev, err := s.repo.GetByEmail(ctx, email)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			slog.Warn("bootstrap superadmin not registered yet", "email", email)
+			return nil
+		}
+		return fmt.Errorf("failed to fetch developer: %w", err)
+	}
+
+	if err := s.repo.SetRole(ctx, dev.ID, domain.RoleSuperadmin, nil); err != nil {
+		return fmt.Errorf("failed to promote superadmin: %w", err)
+	}
+	slog.Info("bootstrap superadmin promoted", "developer_id", dev.ID)
+	return nil
+}
+
+// authorize lets the actor through when acting on themselves (if allowSelf)
+// or when they hold minRole and outrank the target
+func (s *DeveloperService) authorize(ctx context.Context, actor domain.Actor, id uuid.UUID, minRole domain.Role, allowSelf bool) error {
+	if actor.IsSelf(id) && allowSelf {
+		return nil
+	}
+	if !actor.Role.AtLeast(minRole) {
+		return domain.ErrForbidden
+	}
+	if actor.IsSelf(id) {
+		return nil
+	}
+
+	target, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return err
+		}
+		return fmt.Errorf("failed to fetch developer: %w", err)
+	}
+	if !actor.CanManage(target.Role, minRole) {
+		return domain.ErrForbidden
+	}
+	return nil
+}
+
+func (s *DeveloperService) validateUpdate(input *domain.UpdateDeveloperInput) error {
+	if input.Empty() {
+		return domain.ErrInvalidInput
+	}
+	if input.Status.Set {
+		// Deletion and suspension go through Delete/SoftDelete and Suspend so
+		// sessions are revoked too
+		if input.Status.Value == nil {
+			return domain.ErrInvalidInput
+		}
+		switch *input.Status.Value {
+		case domain.StatusPending, domain.StatusActive:
+		case domain.StatusSuspended:
+			return domain.ErrSuspendViaUpdate
+		default:
+			return domain.ErrInvalidInput
+		}
+	}
+	if input.PlanTier.Set {
+		if input.PlanTier.Value == nil || *input.PlanTier.Value == "" {
+			return domain.ErrInvalidInput
+		}
+		if !s.plans.ValidTier(*input.PlanTier.Value) {
+			return domain.ErrUnknownPlan
+		}
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+	"github.com/vivek-344/diagon/sigil/utils"
+)
+
+const (
+	deviceCodeTTL      = 10 * time.Minute
+	devicePollInterval = 5 * time.Second
+	slowDownIncrement  = 5 * time.Second // RFC 8628 3.5
+
+	// Consonants only, so user codes never spell words (RFC 8628 6.1)
+	userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+	userCodeLength   = 8
+)
+
+// DeviceAuthorizationService runs the device authorization grant (RFC 8628)
+// for clients that cannot handle browser redirects, such as the CLI
+type DeviceAuthorizationService struct {
+	repo          domain.DeviceCodeRepository
+	oauthRepo     domain.OAuthRepository
+	developerRepo domain.DeveloperRepository
+	oauthSvc      *OAuthService
+	authSvc       *AuthService
+}
+
+func NewDeviceAuthorizationService(
+	repo domain.DeviceCodeRepository,
+	oauthRepo domain.OAuthRepository,
+	developerRepo domain.DeveloperRepository,
+	oauthSvc *OAuthService,
+	authSvc *AuthService,
+) *DeviceAuthorizationService {
+	return &DeviceAuthorizationService{
+		repo:          repo,
+		oauthRepo:     oauthRepo,
+		developerRepo: developerRepo,
+		oauthSvc:      oauthSvc,
+		authSvc:       authSvc,
+	}
+}
+
+// Start issues a device code and user code for the client. The raw device
+// code is returned only here.
+func (s *DeviceAuthorizationService) Start(ctx context.Context, clientID string, clientSecret string, scopes []string) (string, *domain.DeviceCode, error) {
+	client, err := s.oauthSvc.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(scopes) == 0 {
+		scopes = defaultOAuthScopes
+	}
+	for _, scope := range scopes {
+		if !domain.IsKnownScope(scope) && !domain.IsOIDCScope(scope) {
+			return "", nil, domain.ErrInvalidScope
+		}
+	}
+
+	rawCode, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate device code: %w", err)
+	}
+
+	code := &domain.DeviceCode{
+		DeviceCodeHash: utils.HashToken(rawCode),
+		OAuthClientID:  client.ID,
+		Scopes:         scopes,
+		Interval:       devicePollInterval,
+		ExpiresAt:      time.Now().Add(deviceCodeTTL),
+	}
+
+	// User codes are short enough to collide now and then; try a few
+	for attempt := 0; ; attempt++ {
+		if code.UserCode, err = generateUserCode(); err != nil {
+			return "", nil, fmt.Errorf("failed to generate user code: %w", err)
+		}
+		err = s.repo.Create(ctx, code)
+		if err != domain.ErrUserCodeTaken || attempt == 2 {
+			break
+		}
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to store device code: %w", err)
+	}
+
+	slog.Info("device authorization started", "client_id", client.ClientID, "device_code_id", code.ID)
+	return rawCode, code, nil
+}
+
+// Lookup returns a pending device code and its client for the approval
+// page. Decided and expired codes are not found.
+func (s *DeviceAuthorizationService) Lookup(ctx context.Context, userCode string) (*domain.DeviceCode, *domain.OAuthClient, error) {
+	code, err := s.repo.GetByUserCode(ctx, domain.NormalizeUserCode(userCode))
+	if err != nil {
+		if err == domain.ErrDeviceCodeNotFound {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("failed to fetch device code: %w", err)
+	}
+	if !code.Pending() || time.Now().After(code.ExpiresAt) {
+		return nil, nil, domain.ErrDeviceCodeNotFound
+	}
+
+	client, err := s.oauthRepo.GetClientByID(ctx, code.OAuthClientID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch oauth client: %w", err)
+	}
+	if client.RevokedAt != nil {
+		return nil, nil, domain.ErrDeviceCodeNotFound
+	}
+
+	return code, client, nil
+}
+
+// Decide records the developer's approval or denial of a user code.
+// Approval also counts as consent to the requested scopes.
+func (s *DeviceAuthorizationService) Decide(ctx context.Context, developerID uuid.UUID, userCode string, approved bool) error {
+	code, client, err := s.Lookup(ctx, userCode)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Decide(ctx, code.ID, developerID, approved); err != nil {
+		if err == domain.ErrDeviceCodeNotFound {
+			return err
+		}
+		return fmt.Errorf("failed to update device code: %w", err)
+	}
+
+	if approved {
+		if err := s.oauthRepo.SaveConsent(ctx, developerID, client.ID, code.Scopes); err != nil {
+			slog.Warn("failed to store consent", "developer_id", developerID, "client_id", client.ClientID, "error", err)
+		}
+	}
+
+	slog.Info("device authorization decided", "developer_id", developerID, "client_id", client.ClientID, "approved", approved)
+	return nil
+}
+
+// Exchange answers a device's poll: ErrAuthorizationPending until the
+// developer decides, ErrSlowDown when polled faster than the interval, and a
+// token pair once approved
+func (s *DeviceAuthorizationService) Exchange(
+	ctx context.Context,
+	clientID string,
+	clientSecret string,
+	rawCode string,
+	info domain.ClientInfo,
+) (*utils.TokenPair, []string, error) {
+	client, err := s.oauthSvc.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	code, err := s.repo.Poll(ctx, utils.HashToken(rawCode))
+	if err != nil {
+		if err == domain.ErrDeviceCodeNotFound {
+			return nil, nil, domain.ErrInvalidGrant
+		}
+		return nil, nil, fmt.Errorf("failed to fetch device code: %w", err)
+	}
+	if code.OAuthClientID != client.ID || code.UsedAt != nil {
+		return nil, nil, domain.ErrInvalidGrant
+	}
+
+	now := time.Now()
+	if now.After(code.ExpiresAt) {
+		return nil, nil, domain.ErrExpiredDeviceCode
+	}
+	if code.LastPolledAt != nil && now.Sub(*code.LastPolledAt) < code.Interval {
+		if err := s.repo.SlowDown(ctx, code.ID, code.Interval+slowDownIncrement); err != nil {
+			slog.Warn("failed to extend poll interval", "device_code_id", code.ID, "error", err)
+		}
+		return nil, nil, domain.ErrSlowDown
+	}
+	if code.DeniedAt != nil {
+		return nil, nil, domain.ErrAccessDenied
+	}
+	if code.ApprovedAt == nil {
+		return nil, nil, domain.ErrAuthorizationPending
+	}
+
+	if err := s.repo.Consume(ctx, code.ID); err != nil {
+		if err == domain.ErrDeviceCodeNotFound {
+			return nil, nil, domain.ErrInvalidGrant
+		}
+		return nil, nil, fmt.Errorf("failed to consume device code: %w", err)
+	}
+
+	dev, err := s.developerRepo.GetByID(ctx, *code.DeveloperID)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return nil, nil, domain.ErrInvalidGrant
+		}
+		return nil, nil, fmt.Errorf("failed to fetch developer: %w", err)
+	}
+	if dev.Status == domain.StatusSuspended {
+		return nil, nil, domain.ErrInvalidGrant
+	}
+
+	pair, session, err := s.authSvc.IssueOAuthTokens(ctx, dev, client, code.Scopes, "", info)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pair, sessionScopes(dev, session), nil
+}
+
+// generateUserCode draws userCodeLength characters uniformly from userCodeAlphabet
+func generateUserCode() (string, error) {
+	alphabetSize := big.NewInt(int64(len(userCodeAlphabet)))
+	code := make([]byte, userCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, alphabetSize)
+		if err != nil {
+			return "", err
+		}
+		code[i] = userCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
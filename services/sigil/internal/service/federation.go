@@ -0,0 +1,351 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+	"github.com/vivek-344/diagon/sigil/utils"
+)
+
+const (
+	federatedLoginTTL     = 10 * time.Minute
+	federatedLoginCodeTTL = time.Minute
+)
+
+// FederationService signs developers in through upstream identity providers
+// and manages the external identities linked to them
+type FederationService struct {
+	providers     IdentityProviders
+	repo          domain.ExternalIdentityRepository
+	developerRepo domain.DeveloperRepository
+	tokenRepo     domain.OneTimeTokenRepository
+}
+
+func NewFederationService(
+	providers IdentityProviders,
+	repo domain.ExternalIdentityRepository,
+	developerRepo domain.DeveloperRepository,
+	tokenRepo domain.OneTimeTokenRepository,
+) *FederationService {
+	return &FederationService{
+		providers:     providers,
+		repo:          repo,
+		developerRepo: developerRepo,
+		tokenRepo:     tokenRepo,
+	}
+}
+
+// FederatedStart is where to send the browser, and the binding it must keep
+// (as a cookie) until the provider redirects it back
+type FederatedStart struct {
+	AuthorizationURL string
+	Binding          string
+	ExpiresAt        time.Time
+}
+
+// FederatedResult is the outcome of a provider callback. LoginCode is set for
+// sign-ins and is exchanged for tokens by the dashboard; links have none.
+type FederatedResult struct {
+	Provider    string
+	DeveloperID uuid.UUID
+	LoginCode   string
+	Linked      bool // the identity was linked during this callback
+}
+
+// Providers lists the configured identity providers
+func (s *FederationService) Providers() []IdentityProvider {
+	return s.providers.Sorted()
+}
+
+// Begin starts a login, or a link when developerID is set, and returns the
+// provider URL to send the browser to
+func (s *FederationService) Begin(ctx context.Context, providerName string, developerID *uuid.UUID) (*FederatedStart, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, domain.ErrUnknownIdentityProvider
+	}
+
+	state, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate state: %w", err)
+	}
+	binding, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate binding: %w", err)
+	}
+	nonce, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	codeVerifier, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate code verifier: %w", err)
+	}
+
+	login := &domain.FederatedLogin{
+		StateHash:    utils.HashToken(state),
+		BindingHash:  utils.HashToken(binding),
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		DeveloperID:  developerID,
+		ExpiresAt:    time.Now().Add(federatedLoginTTL),
+	}
+	if err := s.repo.SaveLogin(ctx, login); err != nil {
+		return nil, fmt.Errorf("failed to store federated login: %w", err)
+	}
+
+	challenge := sha256.Sum256([]byte(codeVerifier))
+	authURL, err := provider.AuthorizationURL(ctx, state, nonce, base64.RawURLEncoding.EncodeToString(challenge[:]))
+	if err != nil {
+		slog.Warn("identity provider unavailable", "provider", providerName, "error", err)
+		return nil, domain.ErrFederatedLoginFailed
+	}
+
+	slog.Debug("federated login started", "provider", providerName, "linking", developerID != nil)
+	return &FederatedStart{
+		AuthorizationURL: authURL,
+		Binding:          binding,
+		ExpiresAt:        login.ExpiresAt,
+	}, nil
+}
+
+// Complete handles the provider callback: it redeems the code, then links
+// the identity or signs the developer in. Unknown identities are matched to
+// an existing developer by verified email, or get a new developer. binding
+// must come from the browser that started the login.
+func (s *FederationService) Complete(ctx context.Context, providerName string, state string, binding string, code string) (*FederatedResult, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, domain.ErrUnknownIdentityProvider
+	}
+
+	login, err := s.repo.ConsumeLogin(ctx, utils.HashToken(state), providerName)
+	if err != nil {
+		if err == domain.ErrFederatedLoginNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to fetch federated login: %w", err)
+	}
+	// A provider URL opened in another browser must not finish the login there
+	if subtle.ConstantTimeCompare([]byte(utils.HashToken(binding)), []byte(login.BindingHash)) != 1 {
+		slog.Warn("federated login callback from another browser", "provider", providerName)
+		return nil, domain.ErrFederatedLoginNotFound
+	}
+
+	profile, err := provider.Identify(ctx, code, login.CodeVerifier, login.Nonce)
+	if err != nil {
+		slog.Warn("identity provider login failed", "provider", providerName, "error", err)
+		return nil, domain.ErrFederatedLoginFailed
+	}
+	profile.Email = strings.TrimSpace(profile.Email)
+
+	if login.DeveloperID != nil {
+		return s.link(ctx, providerName, *login.DeveloperID, profile)
+	}
+
+	identity, err := s.repo.GetBySubject(ctx, providerName, profile.Subject)
+	if err != nil && err != domain.ErrExternalIdentityNotFound {
+		return nil, fmt.Errorf("failed to fetch external identity: %w", err)
+	}
+
+	result := &FederatedResult{Provider: providerName}
+	var dev *domain.Developer
+	if identity != nil {
+		if err := s.repo.RecordLogin(ctx, identity.ID, profile.Email); err != nil {
+			slog.Warn("failed to record external login", "identity_id", identity.ID, "error", err)
+		}
+		dev, err = s.developerRepo.GetByID(ctx, identity.DeveloperID)
+		if err != nil {
+			// The identity outlives a soft-deleted developer, who must not be revived by a login
+			if err == domain.ErrNotFound {
+				return nil, domain.ErrExternalAccountDeleted
+			}
+			return nil, fmt.Errorf("failed to fetch developer: %w", err)
+		}
+	} else {
+		dev, err = s.matchOrCreate(ctx, profile)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.createIdentity(ctx, providerName, dev.ID, profile); err != nil {
+			return nil, err
+		}
+		result.Linked = true
+	}
+
+	if dev.Status == domain.StatusSuspended {
+		return nil, domain.ErrAccountSuspended
+	}
+
+	rawCode, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate login code: %w", err)
+	}
+	err = s.tokenRepo.Create(ctx, &domain.OneTimeToken{
+		DeveloperID: dev.ID,
+		Purpose:     domain.PurposeFederatedLogin,
+		TokenHash:   utils.HashToken(rawCode),
+		ExpiresAt:   time.Now().Add(federatedLoginCodeTTL),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store login code: %w", err)
+	}
+
+	slog.Info("federated login completed", "provider", providerName, "developer_id", dev.ID)
+	result.DeveloperID = dev.ID
+	result.LoginCode = rawCode
+	return result, nil
+}
+
+// Exchange redeems a login code from Complete for the developer it signs in
+func (s *FederationService) Exchange(ctx context.Context, rawCode string) (*domain.Developer, error) {
+	token, err := s.tokenRepo.Consume(ctx, utils.HashToken(rawCode), domain.PurposeFederatedLogin)
+	if err != nil {
+		if err == domain.ErrInvalidOneTimeToken {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to consume login code: %w", err)
+	}
+
+	dev, err := s.developerRepo.GetByID(ctx, token.DeveloperID)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return nil, domain.ErrInvalidOneTimeToken
+		}
+		return nil, fmt.Errorf("failed to fetch developer: %w", err)
+	}
+	if dev.Status == domain.StatusSuspended {
+		return nil, domain.ErrAccountSuspended
+	}
+
+	return dev, nil
+}
+
+// ListIdentities returns the external identities linked to the developer
+func (s *FederationService) ListIdentities(ctx context.Context, developerID uuid.UUID) ([]*domain.ExternalIdentity, error) {
+	identities, err := s.repo.ListByDeveloper(ctx, developerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list external identities: %w", err)
+	}
+	return identities, nil
+}
+
+// Unlink removes one of the developer's external identities. Developers
+// created through a provider can still set a password by resetting it.
+func (s *FederationService) Unlink(ctx context.Context, developerID uuid.UUID, id uuid.UUID) error {
+	if err := s.repo.Delete(ctx, id, developerID); err != nil {
+		if err == domain.ErrExternalIdentityNotFound {
+			return err
+		}
+		return fmt.Errorf("failed to unlink external identity: %w", err)
+	}
+
+	slog.Info("external identity unlinked", "developer_id", developerID, "identity_id", id)
+	return nil
+}
+
+// link attaches the identity to the developer who started the flow
+func (s *FederationService) link(ctx context.Context, providerName string, developerID uuid.UUID, profile *domain.ExternalProfile) (*FederatedResult, error) {
+	result := &FederatedResult{Provider: providerName, DeveloperID: developerID}
+
+	identity, err := s.repo.GetBySubject(ctx, providerName, profile.Subject)
+	switch {
+	case err == nil:
+		if identity.DeveloperID != developerID {
+			return nil, domain.ErrExternalIdentityLinked
+		}
+		return result, nil
+	case err != domain.ErrExternalIdentityNotFound:
+		return nil, fmt.Errorf("failed to fetch external identity: %w", err)
+	}
+
+	if err := s.createIdentity(ctx, providerName, developerID, profile); err != nil {
+		return nil, err
+	}
+
+	result.Linked = true
+	return result, nil
+}
+
+// matchOrCreate finds the developer owning the profile's email, or registers
+// one. Only verified emails on both sides are trusted to match accounts.
+func (s *FederationService) matchOrCreate(ctx context.Context, profile *domain.ExternalProfile) (*domain.Developer, error) {
+	if profile.Email == "" || !profile.EmailVerified {
+		return nil, domain.ErrExternalEmailUnverified
+	}
+
+	dev, err := s.developerRepo.GetByEmail(ctx, profile.Email)
+	if err == nil {
+		if !dev.EmailVerified {
+			return nil, domain.ErrExternalEmailConflict
+		}
+		return dev, nil
+	}
+	if err != domain.ErrNotFound {
+		return nil, fmt.Errorf("failed to fetch developer: %w", err)
+	}
+
+	// Federated developers get an unusable random password until they reset it
+	password, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate password: %w", err)
+	}
+	passwordHash, err := utils.HashPassword(password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	input := &domain.CreateDeveloperInput{Email: profile.Email}
+	if profile.Name != "" {
+		input.FullName = &profile.Name
+	}
+	created, err := s.developerRepo.Create(ctx, input, passwordHash)
+	if err != nil {
+		if err == domain.ErrEmailExists {
+			return nil, domain.ErrExternalEmailConflict
+		}
+		return nil, fmt.Errorf("failed to create developer: %w", err)
+	}
+	if err := s.developerRepo.VerifyEmail(ctx, created.ID); err != nil {
+		return nil, fmt.Errorf("failed to verify email: %w", err)
+	}
+
+	dev, err = s.developerRepo.GetByID(ctx, created.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch developer: %w", err)
+	}
+
+	slog.Info("new developer created from external identity", "developer_id", dev.ID)
+	return dev, nil
+}
+
+func (s *FederationService) createIdentity(ctx context.Context, providerName string, developerID uuid.UUID, profile *domain.ExternalProfile) error {
+	identity := &domain.ExternalIdentity{
+		DeveloperID: developerID,
+		Provider:    providerName,
+		Subject:     profile.Subject,
+	}
+	if profile.Email != "" {
+		identity.Email = &profile.Email
+	}
+
+	if err := s.repo.Create(ctx, identity); err != nil {
+		if err == domain.ErrExternalIdentityLinked {
+			return err
+		}
+		return fmt.Errorf("failed to link external identity: %w", err)
+	}
+
+	slog.Info("external identity linked", "developer_id", developerID, "provider", providerName, "identity_id", identity.ID)
+	return nil
+}
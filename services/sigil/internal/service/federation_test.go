@@ -0,0 +1,373 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+)
+
+// memoryExternalIdentityRepo keeps federated logins and identities in memory.
+// Methods the federation flow does not use are left to the nil interface.
+type memoryExternalIdentityRepo struct {
+	domain.ExternalIdentityRepository
+
+	logins     map[string]*domain.FederatedLogin
+	identities []*domain.ExternalIdentity
+}
+
+func (r *memoryExternalIdentityRepo) SaveLogin(ctx context.Context, login *domain.FederatedLogin) error {
+	login.ID = uuid.New()
+	login.CreatedAt = time.Now()
+	r.logins[login.StateHash] = login
+	return nil
+}
+
+func (r *memoryExternalIdentityRepo) ConsumeLogin(ctx context.Context, stateHash string, provider string) (*domain.FederatedLogin, error) {
+	login, ok := r.logins[stateHash]
+	if !ok || login.Provider != provider || !login.ExpiresAt.After(time.Now()) {
+		return nil, domain.ErrFederatedLoginNotFound
+	}
+	delete(r.logins, stateHash)
+	return login, nil
+}
+
+func (r *memoryExternalIdentityRepo) GetBySubject(ctx context.Context, provider string, subject string) (*domain.ExternalIdentity, error) {
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+	return nil, domain.ErrExternalIdentityNotFound
+}
+
+func (r *memoryExternalIdentityRepo) Create(ctx context.Context, identity *domain.ExternalIdentity) error {
+	if _, err := r.GetBySubject(ctx, identity.Provider, identity.Subject); err == nil {
+		return domain.ErrExternalIdentityLinked
+	}
+	identity.ID = uuid.New()
+	identity.CreatedAt = time.Now()
+	r.identities = append(r.identities, identity)
+	return nil
+}
+
+func (r *memoryExternalIdentityRepo) RecordLogin(ctx context.Context, id uuid.UUID, email string) error {
+	for _, identity := range r.identities {
+		if identity.ID == id {
+			now := time.Now()
+			identity.Email = &email
+			identity.LastLoginAt = &now
+			return nil
+		}
+	}
+	return domain.ErrExternalIdentityNotFound
+}
+
+// memoryDeveloperRepo holds developers keyed by email. Deleted developers
+// are hidden from lookups, as in the database.
+type memoryDeveloperRepo struct {
+	domain.DeveloperRepository
+
+	developers map[string]*domain.Developer
+}
+
+func (r *memoryDeveloperRepo) Create(ctx context.Context, input *domain.CreateDeveloperInput, passwordHash string) (*domain.Developer, error) {
+	if _, ok := r.developers[input.Email]; ok {
+		return nil, domain.ErrEmailExists
+	}
+	dev := &domain.Developer{
+		ID:           uuid.New(),
+		Email:        input.Email,
+		PasswordHash: passwordHash,
+		FullName:     input.FullName,
+		Status:       domain.StatusPending,
+	}
+	r.developers[dev.Email] = dev
+	return dev, nil
+}
+
+func (r *memoryDeveloperRepo) GetByEmail(ctx context.Context, email string) (*domain.Developer, error) {
+	dev, ok := r.developers[email]
+	if !ok || dev.Status == domain.StatusDeleted {
+		return nil, domain.ErrNotFound
+	}
+	return dev, nil
+}
+
+func (r *memoryDeveloperRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Developer, error) {
+	for _, dev := range r.developers {
+		if dev.ID == id && dev.Status != domain.StatusDeleted {
+			return dev, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (r *memoryDeveloperRepo) VerifyEmail(ctx context.Context, id uuid.UUID) error {
+	dev, err := r.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	dev.EmailVerified = true
+	dev.Status = domain.StatusActive
+	return nil
+}
+
+// memoryOneTimeTokenRepo holds one-time tokens keyed by hash
+type memoryOneTimeTokenRepo struct {
+	domain.OneTimeTokenRepository
+
+	tokens map[string]*domain.OneTimeToken
+}
+
+func (r *memoryOneTimeTokenRepo) Create(ctx context.Context, token *domain.OneTimeToken) error {
+	token.ID = uuid.New()
+	token.CreatedAt = time.Now()
+	r.tokens[token.TokenHash] = token
+	return nil
+}
+
+func (r *memoryOneTimeTokenRepo) Consume(ctx context.Context, tokenHash string, purpose domain.TokenPurpose) (*domain.OneTimeToken, error) {
+	token, ok := r.tokens[tokenHash]
+	if !ok || token.Purpose != purpose || token.UsedAt != nil || !token.ExpiresAt.After(time.Now()) {
+		return nil, domain.ErrInvalidOneTimeToken
+	}
+	now := time.Now()
+	token.UsedAt = &now
+	return token, nil
+}
+
+func newTestFederationService(t *testing.T, developers ...*domain.Developer) (*FederationService, *testOIDCServer, *memoryExternalIdentityRepo, *memoryDeveloperRepo) {
+	t.Helper()
+
+	srv := newTestOIDCServer(t)
+	repo := &memoryExternalIdentityRepo{logins: map[string]*domain.FederatedLogin{}}
+	developerRepo := &memoryDeveloperRepo{developers: map[string]*domain.Developer{}}
+	for _, dev := range developers {
+		developerRepo.developers[dev.Email] = dev
+	}
+	tokenRepo := &memoryOneTimeTokenRepo{tokens: map[string]*domain.OneTimeToken{}}
+
+	svc := NewFederationService(IdentityProviders{"test": srv.provider()}, repo, developerRepo, tokenRepo)
+	return svc, srv, repo, developerRepo
+}
+
+// beginTestLogin starts a login, or a link for developerID, and primes the
+// provider to answer it with the given claims; it returns the state and the
+// browser binding
+func beginTestLogin(t *testing.T, svc *FederationService, srv *testOIDCServer, developerID *uuid.UUID, override jwt.MapClaims) (string, string) {
+	t.Helper()
+
+	start, err := svc.Begin(context.Background(), "test", developerID)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	authURL, err := url.Parse(start.AuthorizationURL)
+	if err != nil {
+		t.Fatalf("invalid authorization url: %v", err)
+	}
+
+	query := authURL.Query()
+	srv.issue(query.Get("nonce"), override)
+	return query.Get("state"), start.Binding
+}
+
+func TestFederationCompleteRejectsUnverifiedEmail(t *testing.T) {
+	svc, srv, repo, _ := newTestFederationService(t)
+	state, binding := beginTestLogin(t, svc, srv, nil, jwt.MapClaims{"email_verified": false})
+
+	_, err := svc.Complete(context.Background(), "test", state, binding, "code")
+	if !errors.Is(err, domain.ErrExternalEmailUnverified) {
+		t.Fatalf("Complete error = %v, want %v", err, domain.ErrExternalEmailUnverified)
+	}
+	if len(repo.identities) != 0 {
+		t.Fatalf("an identity was linked for an unverified email")
+	}
+}
+
+func TestFederationCompleteConflictsWithUnverifiedAccount(t *testing.T) {
+	existing := &domain.Developer{
+		ID:            uuid.New(),
+		Email:         "dev@example.com",
+		Status:        domain.StatusPending,
+		EmailVerified: false,
+	}
+	svc, srv, repo, _ := newTestFederationService(t, existing)
+	state, binding := beginTestLogin(t, svc, srv, nil, nil)
+
+	_, err := svc.Complete(context.Background(), "test", state, binding, "code")
+	if !errors.Is(err, domain.ErrExternalEmailConflict) {
+		t.Fatalf("Complete error = %v, want %v", err, domain.ErrExternalEmailConflict)
+	}
+	if len(repo.identities) != 0 {
+		t.Fatalf("the identity was linked to an account that never proved the email")
+	}
+}
+
+func TestFederationCompleteRejectsOtherBrowser(t *testing.T) {
+	svc, srv, _, _ := newTestFederationService(t)
+	state, _ := beginTestLogin(t, svc, srv, nil, nil)
+
+	_, err := svc.Complete(context.Background(), "test", state, "another-browser", "code")
+	if !errors.Is(err, domain.ErrFederatedLoginNotFound) {
+		t.Fatalf("Complete error = %v, want %v", err, domain.ErrFederatedLoginNotFound)
+	}
+
+	// The state is spent even though the binding did not match
+	if _, err := svc.Complete(context.Background(), "test", state, "", "code"); !errors.Is(err, domain.ErrFederatedLoginNotFound) {
+		t.Fatalf("replayed Complete error = %v, want %v", err, domain.ErrFederatedLoginNotFound)
+	}
+}
+
+func TestFederationCompleteCreatesDeveloper(t *testing.T) {
+	svc, srv, repo, developers := newTestFederationService(t)
+	state, binding := beginTestLogin(t, svc, srv, nil, nil)
+
+	result, err := svc.Complete(context.Background(), "test", state, binding, "code")
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if !result.Linked || result.LoginCode == "" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	dev, ok := developers.developers["dev@example.com"]
+	if !ok {
+		t.Fatal("no developer was created")
+	}
+	if dev.ID != result.DeveloperID || !dev.EmailVerified || dev.FullName == nil || *dev.FullName != "Dev" {
+		t.Fatalf("unexpected developer %+v", dev)
+	}
+	if len(repo.identities) != 1 || repo.identities[0].DeveloperID != dev.ID {
+		t.Fatalf("identity not linked to the new developer: %+v", repo.identities)
+	}
+
+	signedIn, err := svc.Exchange(context.Background(), result.LoginCode)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if signedIn.ID != dev.ID {
+		t.Fatalf("Exchange signed in %s, want %s", signedIn.ID, dev.ID)
+	}
+	if _, err := svc.Exchange(context.Background(), result.LoginCode); !errors.Is(err, domain.ErrInvalidOneTimeToken) {
+		t.Fatalf("replayed Exchange error = %v, want %v", err, domain.ErrInvalidOneTimeToken)
+	}
+
+	// The next login finds the identity instead of registering again
+	state, binding = beginTestLogin(t, svc, srv, nil, nil)
+	result, err = svc.Complete(context.Background(), "test", state, binding, "code")
+	if err != nil {
+		t.Fatalf("second Complete: %v", err)
+	}
+	if result.Linked || result.DeveloperID != dev.ID {
+		t.Fatalf("unexpected second result %+v", result)
+	}
+	if len(developers.developers) != 1 || len(repo.identities) != 1 {
+		t.Fatal("the second login created another developer or identity")
+	}
+}
+
+func TestFederationCompleteMatchesVerifiedEmail(t *testing.T) {
+	existing := &domain.Developer{
+		ID:            uuid.New(),
+		Email:         "dev@example.com",
+		Status:        domain.StatusActive,
+		EmailVerified: true,
+	}
+	svc, srv, repo, developers := newTestFederationService(t, existing)
+	state, binding := beginTestLogin(t, svc, srv, nil, nil)
+
+	result, err := svc.Complete(context.Background(), "test", state, binding, "code")
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if result.DeveloperID != existing.ID || !result.Linked {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if len(developers.developers) != 1 {
+		t.Fatal("a developer was created despite the matching account")
+	}
+	if len(repo.identities) != 1 || repo.identities[0].DeveloperID != existing.ID {
+		t.Fatalf("identity not linked to the existing developer: %+v", repo.identities)
+	}
+}
+
+func TestFederationLink(t *testing.T) {
+	existing := &domain.Developer{
+		ID:            uuid.New(),
+		Email:         "someone@example.com",
+		Status:        domain.StatusActive,
+		EmailVerified: true,
+	}
+	svc, srv, repo, developers := newTestFederationService(t, existing)
+
+	// Linking needs no email match, and an unverified upstream email is fine
+	state, binding := beginTestLogin(t, svc, srv, &existing.ID, jwt.MapClaims{"email_verified": false})
+	result, err := svc.Complete(context.Background(), "test", state, binding, "code")
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if result.DeveloperID != existing.ID || !result.Linked || result.LoginCode != "" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if len(repo.identities) != 1 || repo.identities[0].DeveloperID != existing.ID {
+		t.Fatalf("identity not linked to the developer: %+v", repo.identities)
+	}
+	if len(developers.developers) != 1 {
+		t.Fatal("linking created a developer")
+	}
+
+	// Linking the same identity again is a no-op
+	state, binding = beginTestLogin(t, svc, srv, &existing.ID, nil)
+	result, err = svc.Complete(context.Background(), "test", state, binding, "code")
+	if err != nil {
+		t.Fatalf("repeated Complete: %v", err)
+	}
+	if result.Linked || len(repo.identities) != 1 {
+		t.Fatalf("repeated link changed the identities: %+v", result)
+	}
+}
+
+func TestFederationLinkRejectsIdentityOfAnotherDeveloper(t *testing.T) {
+	owner := &domain.Developer{ID: uuid.New(), Email: "owner@example.com", Status: domain.StatusActive, EmailVerified: true}
+	other := &domain.Developer{ID: uuid.New(), Email: "other@example.com", Status: domain.StatusActive, EmailVerified: true}
+	svc, srv, repo, _ := newTestFederationService(t, owner, other)
+	repo.identities = append(repo.identities, &domain.ExternalIdentity{
+		ID:          uuid.New(),
+		DeveloperID: owner.ID,
+		Provider:    "test",
+		Subject:     "upstream-user",
+	})
+
+	state, binding := beginTestLogin(t, svc, srv, &other.ID, nil)
+	_, err := svc.Complete(context.Background(), "test", state, binding, "code")
+	if !errors.Is(err, domain.ErrExternalIdentityLinked) {
+		t.Fatalf("Complete error = %v, want %v", err, domain.ErrExternalIdentityLinked)
+	}
+	if len(repo.identities) != 1 || repo.identities[0].DeveloperID != owner.ID {
+		t.Fatalf("the identity moved to another developer: %+v", repo.identities)
+	}
+}
+
+func TestFederationCompleteRejectsDeletedAccount(t *testing.T) {
+	deleted := &domain.Developer{ID: uuid.New(), Email: "dev@example.com", Status: domain.StatusDeleted, EmailVerified: true}
+	svc, srv, repo, _ := newTestFederationService(t, deleted)
+	repo.identities = append(repo.identities, &domain.ExternalIdentity{
+		ID:          uuid.New(),
+		DeveloperID: deleted.ID,
+		Provider:    "test",
+		Subject:     "upstream-user",
+	})
+
+	state, binding := beginTestLogin(t, svc, srv, nil, nil)
+	_, err := svc.Complete(context.Background(), "test", state, binding, "code")
+	if !errors.Is(err, domain.ErrExternalAccountDeleted) {
+		t.Fatalf("Complete error = %v, want %v", err, domain.ErrExternalAccountDeleted)
+	}
+}
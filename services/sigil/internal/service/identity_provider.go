@@ -0,0 +1,513 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+	"github.com/vivek-344/diagon/sigil/utils"
+)
+
+const (
+	providerHTTPTimeout = 10 * time.Second
+	jwksRefreshInterval = time.Minute // limits refetches on unknown key IDs
+	maxProviderResponse = 1 << 20
+)
+
+var defaultOIDCProviderScopes = []string{domain.ScopeOpenID, domain.ScopeEmail, domain.ScopeProfile}
+
+// IdentityProvider is an upstream provider developers can sign in with
+type IdentityProvider interface {
+	Name() string
+	DisplayName() string
+
+	// AuthorizationURL is where the browser is sent to sign in
+	AuthorizationURL(ctx context.Context, state string, nonce string, codeChallenge string) (string, error)
+
+	// Identify redeems the authorization code and returns the signed-in user
+	Identify(ctx context.Context, code string, codeVerifier string, nonce string) (*domain.ExternalProfile, error)
+}
+
+// IdentityProviders are the configured providers keyed by name
+type IdentityProviders map[string]IdentityProvider
+
+// identityProviderDefinition is one entry of the providers file
+type identityProviderDefinition struct {
+	Kind         string   `json:"kind"`
+	DisplayName  string   `json:"display_name"`
+	Issuer       string   `json:"issuer"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	Scopes       []string `json:"scopes"`
+	APIURL       string   `json:"api_url"` // github only, for GitHub Enterprise
+}
+
+// NewIdentityProviders parses a JSON object of providers keyed by name. Each
+// provider calls back to callbackBaseURL/{name}/callback. Empty input
+// disables federated login.
+func NewIdentityProviders(raw []byte, callbackBaseURL string) (IdentityProviders, error) {
+	providers := IdentityProviders{}
+	if len(raw) == 0 {
+		return providers, nil
+	}
+
+	var definitions map[string]identityProviderDefinition
+	if err := json.Unmarshal(raw, &definitions); err != nil {
+		return nil, fmt.Errorf("invalid identity providers: %w", err)
+	}
+
+	httpClient := &http.Client{Timeout: providerHTTPTimeout}
+	for name, def := range definitions {
+		if name == "" || url.PathEscape(name) != name {
+			return nil, fmt.Errorf("invalid identity providers: %q is not a valid name", name)
+		}
+		if def.ClientID == "" {
+			return nil, fmt.Errorf("invalid identity providers: %q needs a client_id", name)
+		}
+		if def.DisplayName == "" {
+			def.DisplayName = name
+		}
+		redirectURI := callbackBaseURL + "/" + name + "/callback"
+
+		switch def.Kind {
+		case domain.IdentityProviderOIDC:
+			if def.Issuer == "" {
+				return nil, fmt.Errorf("invalid identity providers: %q needs an issuer", name)
+			}
+			if len(def.Scopes) == 0 {
+				def.Scopes = defaultOIDCProviderScopes
+			} else if !slices.Contains(def.Scopes, domain.ScopeOpenID) {
+				def.Scopes = append([]string{domain.ScopeOpenID}, def.Scopes...)
+			}
+			providers[name] = &oidcProvider{
+				name:         name,
+				displayName:  def.DisplayName,
+				issuer:       strings.TrimSuffix(def.Issuer, "/"),
+				clientID:     def.ClientID,
+				clientSecret: def.ClientSecret,
+				scopes:       def.Scopes,
+				redirectURI:  redirectURI,
+				httpClient:   httpClient,
+			}
+		case domain.IdentityProviderGitHub:
+			if def.Issuer == "" {
+				def.Issuer = "https://github.com"
+			}
+			if def.APIURL == "" {
+				def.APIURL = "https://api.github.com"
+			}
+			if len(def.Scopes) == 0 {
+				def.Scopes = []string{"read:user", "user:email"}
+			}
+			providers[name] = &githubProvider{
+				name:         name,
+				displayName:  def.DisplayName,
+				baseURL:      strings.TrimSuffix(def.Issuer, "/"),
+				apiURL:       strings.TrimSuffix(def.APIURL, "/"),
+				clientID:     def.ClientID,
+				clientSecret: def.ClientSecret,
+				scopes:       def.Scopes,
+				redirectURI:  redirectURI,
+				httpClient:   httpClient,
+			}
+		default:
+			return nil, fmt.Errorf("invalid identity providers: %q has unknown kind %q", name, def.Kind)
+		}
+	}
+	return providers, nil
+}
+
+// Sorted lists the providers by name
+func (p IdentityProviders) Sorted() []IdentityProvider {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sorted := make([]IdentityProvider, 0, len(names))
+	for _, name := range names {
+		sorted = append(sorted, p[name])
+	}
+	return sorted
+}
+
+// oidcProvider is an OpenID Connect provider such as Google or GitLab, found
+// through discovery; identity comes from the verified ID token
+type oidcProvider struct {
+	name         string
+	displayName  string
+	issuer       string
+	clientID     string
+	clientSecret string
+	scopes       []string
+	redirectURI  string
+	httpClient   *http.Client
+
+	// mu guards the caches below; it is never held across a fetch
+	mu            sync.Mutex
+	metadata      *oidcProviderMetadata
+	keys          map[string]any
+	keysFetchedAt time.Time
+}
+
+type oidcProviderMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// upstreamIDTokenClaims are the ID token claims Sigil relies on (OIDC Core 2, 5.1)
+type upstreamIDTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce           string `json:"nonce"`
+	AuthorizedParty string `json:"azp"`
+	Email           string `json:"email"`
+	EmailVerified   bool   `json:"email_verified"`
+	Name            string `json:"name"`
+}
+
+func (p *oidcProvider) Name() string        { return p.name }
+func (p *oidcProvider) DisplayName() string { return p.displayName }
+
+func (p *oidcProvider) AuthorizationURL(ctx context.Context, state string, nonce string, codeChallenge string) (string, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return withQueryParams(metadata.AuthorizationEndpoint, url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.clientID},
+		"redirect_uri":          {p.redirectURI},
+		"scope":                 {strings.Join(p.scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {domain.CodeChallengeS256},
+	})
+}
+
+func (p *oidcProvider) Identify(ctx context.Context, code string, codeVerifier string, nonce string) (*domain.ExternalProfile, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	err = exchangeProviderCode(ctx, p.httpClient, metadata.TokenEndpoint, p.clientID, p.clientSecret, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.redirectURI},
+		"code_verifier": {codeVerifier},
+	}, &tokens)
+	if err != nil {
+		return nil, err
+	}
+	if tokens.IDToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+
+	claims := &upstreamIDTokenClaims{}
+	_, err = jwt.ParseWithClaims(tokens.IDToken, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.publicKey(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithIssuer(p.issuer),
+		jwt.WithAudience(p.clientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id token: %w", err)
+	}
+
+	// OIDC Core 3.1.3.7: other audiences are only allowed for the authorized party
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.clientID {
+		return nil, errors.New("invalid id token: unexpected azp")
+	}
+	if claims.Nonce != nonce {
+		return nil, errors.New("invalid id token: nonce mismatch")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("invalid id token: missing sub")
+	}
+
+	return &domain.ExternalProfile{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+	}, nil
+}
+
+// discover fetches and caches the provider metadata (OIDC Discovery 4).
+// Concurrent first calls may each fetch it; any of the results is kept.
+func (p *oidcProvider) discover(ctx context.Context) (*oidcProviderMetadata, error) {
+	p.mu.Lock()
+	cached := p.metadata
+	p.mu.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	metadata := &oidcProviderMetadata{}
+	if err := getProviderJSON(ctx, p.httpClient, p.issuer+"/.well-known/openid-configuration", "", metadata); err != nil {
+		return nil, fmt.Errorf("failed to discover %s: %w", p.name, err)
+	}
+	if strings.TrimSuffix(metadata.Issuer, "/") != p.issuer {
+		return nil, fmt.Errorf("failed to discover %s: issuer mismatch %q", p.name, metadata.Issuer)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, fmt.Errorf("failed to discover %s: incomplete metadata", p.name)
+	}
+
+	p.mu.Lock()
+	p.metadata = metadata
+	p.mu.Unlock()
+	return metadata, nil
+}
+
+// publicKey returns the provider's signing key, refetching the key set when
+// the key ID is unknown so provider key rotation is picked up
+func (p *oidcProvider) publicKey(ctx context.Context, kid string) (any, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The caller that claims the refetch does it; others meanwhile see the old set
+	p.mu.Lock()
+	key, ok := lookupProviderKey(p.keys, kid)
+	refetch := !ok && time.Since(p.keysFetchedAt) >= jwksRefreshInterval
+	if refetch {
+		p.keysFetchedAt = time.Now()
+	}
+	p.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	if !refetch {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	var set utils.JWKS
+	if err := getProviderJSON(ctx, p.httpClient, metadata.JWKSURI, "", &set); err != nil {
+		return nil, fmt.Errorf("failed to fetch signing keys: %w", err)
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			continue // keys of unsupported types are skipped
+		}
+		keys[jwk.KeyID] = key
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+
+	if key, ok := lookupProviderKey(keys, kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookupProviderKey finds a key by ID; tokens without a kid need a single-key set
+func lookupProviderKey(keys map[string]any, kid string) (any, bool) {
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, true
+		}
+	}
+	key, ok := keys[kid]
+	return key, ok
+}
+
+// githubProvider signs in with GitHub's OAuth apps, which do not speak
+// OpenID Connect; identity comes from the REST API
+type githubProvider struct {
+	name         string
+	displayName  string
+	baseURL      string
+	apiURL       string
+	clientID     string
+	clientSecret string
+	scopes       []string
+	redirectURI  string
+	httpClient   *http.Client
+}
+
+func (p *githubProvider) Name() string        { return p.name }
+func (p *githubProvider) DisplayName() string { return p.displayName }
+
+// AuthorizationURL ignores the nonce, which GitHub has no use for; state and
+// PKCE still bind the callback to this login
+func (p *githubProvider) AuthorizationURL(ctx context.Context, state string, nonce string, codeChallenge string) (string, error) {
+	return withQueryParams(p.baseURL+"/login/oauth/authorize", url.Values{
+		"client_id":             {p.clientID},
+		"redirect_uri":          {p.redirectURI},
+		"scope":                 {strings.Join(p.scopes, " ")},
+		"state":                 {state},
+		"allow_signup":          {"false"},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {domain.CodeChallengeS256},
+	})
+}
+
+func (p *githubProvider) Identify(ctx context.Context, code string, codeVerifier string, nonce string) (*domain.ExternalProfile, error) {
+	var tokens struct {
+		AccessToken string `json:"access_token"`
+		Error       string `json:"error"`
+	}
+	err := exchangeProviderCode(ctx, p.httpClient, p.baseURL+"/login/oauth/access_token", p.clientID, p.clientSecret, url.Values{
+		"code":          {code},
+		"redirect_uri":  {p.redirectURI},
+		"code_verifier": {codeVerifier},
+	}, &tokens)
+	if err != nil {
+		return nil, err
+	}
+	// GitHub reports token errors with a 200
+	if tokens.AccessToken == "" {
+		return nil, fmt.Errorf("token exchange failed: %s", tokens.Error)
+	}
+
+	var user struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := getProviderJSON(ctx, p.httpClient, p.apiURL+"/user", tokens.AccessToken, &user); err != nil {
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+	if user.ID == 0 {
+		return nil, errors.New("user response has no id")
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getProviderJSON(ctx, p.httpClient, p.apiURL+"/user/emails", tokens.AccessToken, &emails); err != nil {
+		return nil, fmt.Errorf("failed to fetch emails: %w", err)
+	}
+
+	profile := &domain.ExternalProfile{
+		Subject: strconv.FormatInt(user.ID, 10),
+		Name:    user.Name,
+	}
+	if profile.Name == "" {
+		profile.Name = user.Login
+	}
+	for _, email := range emails {
+		if email.Primary {
+			profile.Email = email.Email
+			profile.EmailVerified = email.Verified
+		}
+	}
+	return profile, nil
+}
+
+// exchangeProviderCode redeems an authorization code at a provider's token
+// endpoint, authenticating with client_secret_basic when there is a secret
+func exchangeProviderCode(
+	ctx context.Context,
+	httpClient *http.Client,
+	endpoint string,
+	clientID string,
+	clientSecret string,
+	form url.Values,
+	out any,
+) error {
+	if clientSecret == "" {
+		form.Set("client_id", clientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if clientSecret != "" {
+		// RFC 6749 2.3.1: credentials are form encoded before basic auth
+		req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+	}
+
+	if err := doProviderRequest(httpClient, req, out); err != nil {
+		return fmt.Errorf("token exchange failed: %w", err)
+	}
+	return nil
+}
+
+// getProviderJSON fetches a JSON document, with a bearer token when given
+func getProviderJSON(ctx context.Context, httpClient *http.Client, endpoint string, accessToken string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	return doProviderRequest(httpClient, req, out)
+}
+
+func doProviderRequest(httpClient *http.Client, req *http.Request, out any) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxProviderResponse))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var oauthErr struct {
+			Error string `json:"error"`
+		}
+		_ = json.Unmarshal(body, &oauthErr)
+		return fmt.Errorf("%s returned %d %s", req.URL.Host, resp.StatusCode, oauthErr.Error)
+	}
+	return json.Unmarshal(body, out)
+}
+
+// withQueryParams appends params to an endpoint that may already have a query
+func withQueryParams(endpoint string, params url.Values) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
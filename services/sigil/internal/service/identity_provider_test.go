@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/vivek-344/diagon/sigil/utils"
+)
+
+const testProviderClientID = "sigil-test"
+
+// testOIDCServer is an upstream OpenID Connect provider. Its token endpoint
+// answers every code with an ID token carrying claims, signed with kid.
+type testOIDCServer struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	signingKeys map[string]ed25519.PrivateKey
+	publishKeys []string // key IDs served from the JWKS endpoint
+	kid         string
+	claims      jwt.MapClaims
+	jwksFetches int
+}
+
+func newTestOIDCServer(t *testing.T) *testOIDCServer {
+	t.Helper()
+
+	srv := &testOIDCServer{signingKeys: map[string]ed25519.PrivateKey{}}
+	srv.addKey(t, "key-1")
+	srv.publishKeys = []string{"key-1"}
+	srv.kid = "key-1"
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeTestJSON(w, map[string]string{
+			"issuer":                 srv.URL,
+			"authorization_endpoint": srv.URL + "/authorize",
+			"token_endpoint":         srv.URL + "/token",
+			"jwks_uri":               srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		srv.mu.Lock()
+		defer srv.mu.Unlock()
+
+		srv.jwksFetches++
+		set := utils.JWKS{}
+		for _, kid := range srv.publishKeys {
+			set.Keys = append(set.Keys, utils.JWK{
+				KeyType:   "OKP",
+				KeyID:     kid,
+				Use:       "sig",
+				Algorithm: "EdDSA",
+				Curve:     "Ed25519",
+				X:         base64.RawURLEncoding.EncodeToString(srv.signingKeys[kid].Public().(ed25519.PublicKey)),
+			})
+		}
+		writeTestJSON(w, set)
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		srv.mu.Lock()
+		defer srv.mu.Unlock()
+
+		token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, srv.claims)
+		token.Header["kid"] = srv.kid
+		idToken, err := token.SignedString(srv.signingKeys[srv.kid])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeTestJSON(w, map[string]string{"id_token": idToken})
+	})
+
+	srv.Server = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func (s *testOIDCServer) addKey(t *testing.T, kid string) {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	s.mu.Lock()
+	s.signingKeys[kid] = key
+	s.mu.Unlock()
+}
+
+// issue sets the claims of the next ID token, starting from valid ones
+func (s *testOIDCServer) issue(nonce string, override jwt.MapClaims) {
+	claims := jwt.MapClaims{
+		"iss":            s.URL,
+		"aud":            testProviderClientID,
+		"sub":            "upstream-user",
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          nonce,
+		"email":          "dev@example.com",
+		"email_verified": true,
+		"name":           "Dev",
+	}
+	for key, value := range override {
+		claims[key] = value
+	}
+
+	s.mu.Lock()
+	s.claims = claims
+	s.mu.Unlock()
+}
+
+func (s *testOIDCServer) keySetFetches() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.jwksFetches
+}
+
+func (s *testOIDCServer) provider() *oidcProvider {
+	return &oidcProvider{
+		name:        "test",
+		displayName: "Test",
+		issuer:      s.URL,
+		clientID:    testProviderClientID,
+		scopes:      defaultOIDCProviderScopes,
+		redirectURI: "https://sigil.example.com/auth/federated/test/callback",
+		httpClient:  s.Client(),
+	}
+}
+
+func writeTestJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func TestOIDCProviderIdentify(t *testing.T) {
+	srv := newTestOIDCServer(t)
+	provider := srv.provider()
+	srv.issue("nonce-1", nil)
+
+	profile, err := provider.Identify(context.Background(), "code", "verifier", "nonce-1")
+	if err != nil {
+		t.Fatalf("Identify: %v", err)
+	}
+	if profile.Subject != "upstream-user" || profile.Email != "dev@example.com" || !profile.EmailVerified {
+		t.Fatalf("unexpected profile %+v", profile)
+	}
+}
+
+func TestOIDCProviderIdentifyRejectsInvalidTokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		override jwt.MapClaims
+		wantErr  string
+	}{
+		{name: "nonce mismatch", override: jwt.MapClaims{"nonce": "other-nonce"}, wantErr: "nonce mismatch"},
+		{name: "wrong audience", override: jwt.MapClaims{"aud": "another-client"}, wantErr: "audience"},
+		{name: "wrong issuer", override: jwt.MapClaims{"iss": "https://evil.example.com"}, wantErr: "issuer"},
+		{name: "expired", override: jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}, wantErr: "expired"},
+		{name: "missing subject", override: jwt.MapClaims{"sub": ""}, wantErr: "missing sub"},
+		{
+			name:     "foreign authorized party",
+			override: jwt.MapClaims{"aud": []string{testProviderClientID, "another-client"}, "azp": "another-client"},
+			wantErr:  "unexpected azp",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestOIDCServer(t)
+			provider := srv.provider()
+			srv.issue("nonce-1", tt.override)
+
+			_, err := provider.Identify(context.Background(), "code", "verifier", "nonce-1")
+			if err == nil {
+				t.Fatal("Identify accepted an invalid id token")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Identify error = %q, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOIDCProviderRefetchesKeysOnUnknownKeyID(t *testing.T) {
+	srv := newTestOIDCServer(t)
+	provider := srv.provider()
+	srv.issue("nonce-1", nil)
+
+	if _, err := provider.Identify(context.Background(), "code", "verifier", "nonce-1"); err != nil {
+		t.Fatalf("Identify: %v", err)
+	}
+
+	// The provider rotates to a key Sigil has not seen
+	srv.addKey(t, "key-2")
+	srv.mu.Lock()
+	srv.publishKeys = []string{"key-1", "key-2"}
+	srv.kid = "key-2"
+	srv.mu.Unlock()
+
+	// Within the refresh interval an unknown key is refused without a refetch
+	if _, err := provider.Identify(context.Background(), "code", "verifier", "nonce-1"); err == nil {
+		t.Fatal("Identify accepted a token signed with an unfetched key")
+	}
+	if fetches := srv.keySetFetches(); fetches != 1 {
+		t.Fatalf("key set fetched %d times within the refresh interval, want 1", fetches)
+	}
+
+	provider.mu.Lock()
+	provider.keysFetchedAt = time.Now().Add(-2 * jwksRefreshInterval)
+	provider.mu.Unlock()
+
+	if _, err := provider.Identify(context.Background(), "code", "verifier", "nonce-1"); err != nil {
+		t.Fatalf("Identify after rotation: %v", err)
+	}
+	if fetches := srv.keySetFetches(); fetches != 2 {
+		t.Fatalf("key set fetched %d times, want 2", fetches)
+	}
+}
+
+func TestOIDCProviderRejectsUnpublishedKey(t *testing.T) {
+	srv := newTestOIDCServer(t)
+	provider := srv.provider()
+	srv.addKey(t, "rogue")
+	srv.mu.Lock()
+	srv.kid = "rogue"
+	srv.mu.Unlock()
+	srv.issue("nonce-1", nil)
+
+	if _, err := provider.Identify(context.Background(), "code", "verifier", "nonce-1"); err == nil {
+		t.Fatal("Identify accepted a token signed with a key missing from the key set")
+	}
+}
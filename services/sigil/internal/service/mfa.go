@@ -0,0 +1,358 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+	"github.com/vivek-344/diagon/sigil/utils"
+)
+
+const (
+	totpIssuer           = "DIAGON"
+	recoveryCodeCount    = 10
+	mfaChallengeAttempts = 5
+
+	// Wrong codes in a row, across challenges and Disable, before codes are
+	// refused for mfaLockoutDuration
+	mfaFailedAttempts  = 10
+	mfaLockoutDuration = 15 * time.Minute
+
+	// Recovery codes are 16 base32 characters, shown as XXXX-XXXX-XXXX-XXXX
+	recoveryCodeAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567"
+	recoveryCodeLength   = 16
+)
+
+type MFAService struct {
+	mfaRepo       domain.MFARepository
+	developerRepo domain.DeveloperRepository
+	authSvc       *AuthService
+	jwtManager    *utils.JWTManager
+	encryptionKey []byte
+}
+
+func NewMFAService(
+	mfaRepo domain.MFARepository,
+	developerRepo domain.DeveloperRepository,
+	authSvc *AuthService,
+	jwtManager *utils.JWTManager,
+	encryptionKey []byte,
+) *MFAService {
+	return &MFAService{
+		mfaRepo:       mfaRepo,
+		developerRepo: developerRepo,
+		authSvc:       authSvc,
+		jwtManager:    jwtManager,
+		encryptionKey: encryptionKey,
+	}
+}
+
+type MFAEnrollment struct {
+	Secret     string
+	OTPAuthURI string
+}
+
+// Enroll generates a new pending TOTP secret; it only takes effect after Confirm
+func (s *MFAService) Enroll(ctx context.Context, developerID uuid.UUID) (*MFAEnrollment, error) {
+	slog.Debug("enrolling developer in mfa", "developer_id", developerID)
+	if len(s.encryptionKey) == 0 {
+		return nil, domain.ErrMFAUnavailable
+	}
+
+	dev, err := s.developerRepo.GetByID(ctx, developerID)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to fetch developer: %w", err)
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate totp secret: %w", err)
+	}
+
+	encrypted, err := utils.EncryptSecret(s.encryptionKey, secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt totp secret: %w", err)
+	}
+
+	if err := s.mfaRepo.SavePending(ctx, developerID, encrypted); err != nil {
+		if err == domain.ErrMFAAlreadyEnabled {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to save mfa enrollment: %w", err)
+	}
+
+	return &MFAEnrollment{
+		Secret:     secret,
+		OTPAuthURI: utils.TOTPURI(totpIssuer, dev.Email, secret),
+	}, nil
+}
+
+// Confirm activates a pending enrollment and returns the one-time recovery codes
+func (s *MFAService) Confirm(ctx context.Context, developerID uuid.UUID, code string) ([]string, error) {
+	mfa, err := s.get(ctx, developerID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa.Enabled() {
+		return nil, domain.ErrMFAAlreadyEnabled
+	}
+
+	step, err := s.matchTOTP(mfa, code)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate recovery codes: %w", err)
+	}
+
+	if err := s.mfaRepo.Enable(ctx, developerID, step, hashes); err != nil {
+		if err == domain.ErrMFAAlreadyEnabled {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to enable mfa: %w", err)
+	}
+
+	slog.Info("mfa enabled", "developer_id", developerID)
+	return codes, nil
+}
+
+// Disable removes MFA after checking a current TOTP or recovery code
+func (s *MFAService) Disable(ctx context.Context, developerID uuid.UUID, code string) error {
+	if err := s.verifyCode(ctx, developerID, code); err != nil {
+		return err
+	}
+
+	if err := s.mfaRepo.Delete(ctx, developerID); err != nil {
+		if err == domain.ErrMFANotEnrolled {
+			return err
+		}
+		return fmt.Errorf("failed to disable mfa: %w", err)
+	}
+
+	slog.Info("mfa disabled", "developer_id", developerID)
+	return nil
+}
+
+// IsEnabled reports whether login must go through an MFA challenge
+func (s *MFAService) IsEnabled(ctx context.Context, developerID uuid.UUID) (bool, error) {
+	mfa, err := s.mfaRepo.Get(ctx, developerID)
+	if err != nil {
+		if err == domain.ErrMFANotEnrolled {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to fetch mfa: %w", err)
+	}
+	return mfa.Enabled(), nil
+}
+
+// StartChallenge issues the short-lived token the client exchanges at
+// /auth/mfa/verify. The token is single use and allows mfaChallengeAttempts codes.
+func (s *MFAService) StartChallenge(ctx context.Context, dev *domain.Developer) (string, error) {
+	challenge := &domain.MFAChallenge{
+		ID:          uuid.New(),
+		DeveloperID: dev.ID,
+		ExpiresAt:   time.Now().Add(utils.MFATokenTTL),
+	}
+	if err := s.mfaRepo.CreateChallenge(ctx, challenge); err != nil {
+		return "", fmt.Errorf("failed to store mfa challenge: %w", err)
+	}
+
+	token, err := s.jwtManager.GenerateMFAToken(dev.ID, dev.Email, challenge.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to issue mfa token: %w", err)
+	}
+	return token, nil
+}
+
+// CompleteChallenge checks the second factor and finishes the login
+func (s *MFAService) CompleteChallenge(ctx context.Context, mfaToken string, code string, client domain.ClientInfo) (*domain.Developer, *utils.TokenPair, error) {
+	claims, err := s.jwtManager.ValidateMFAToken(mfaToken)
+	if err != nil {
+		return nil, nil, domain.ErrInvalidMFAChallenge
+	}
+	challengeID, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return nil, nil, domain.ErrInvalidMFAChallenge
+	}
+
+	challenge, err := s.mfaRepo.AttemptChallenge(ctx, challengeID, mfaChallengeAttempts)
+	if err != nil {
+		if err == domain.ErrInvalidMFAChallenge {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("failed to fetch mfa challenge: %w", err)
+	}
+	if challenge.DeveloperID != claims.DeveloperID {
+		return nil, nil, domain.ErrInvalidMFAChallenge
+	}
+
+	dev, err := s.developerRepo.GetByID(ctx, claims.DeveloperID)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("failed to fetch developer: %w", err)
+	}
+	if dev.Status == domain.StatusSuspended {
+		return nil, nil, domain.ErrAccountSuspended
+	}
+
+	if err := s.verifyCode(ctx, dev.ID, code); err != nil {
+		if err == domain.ErrInvalidMFACode {
+			slog.Debug("mfa challenge attempt failed", "developer_id", dev.ID, "attempts", challenge.Attempts)
+		}
+		return nil, nil, err
+	}
+
+	if err := s.mfaRepo.ConsumeChallenge(ctx, challenge.ID); err != nil {
+		if err == domain.ErrInvalidMFAChallenge {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("failed to consume mfa challenge: %w", err)
+	}
+
+	tokens, err := s.authSvc.IssueTokens(ctx, dev, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dev, tokens, nil
+}
+
+// verifyCode checks a second factor against the developer's failure budget
+func (s *MFAService) verifyCode(ctx context.Context, developerID uuid.UUID, code string) error {
+	mfa, err := s.get(ctx, developerID)
+	if err != nil {
+		return err
+	}
+	if !mfa.Enabled() {
+		return domain.ErrMFANotEnrolled
+	}
+
+	if err := s.mfaRepo.AttemptCode(ctx, developerID, mfaFailedAttempts, mfaLockoutDuration); err != nil {
+		if err == domain.ErrMFALocked {
+			slog.Warn("mfa locked after repeated failures", "developer_id", developerID)
+			return err
+		}
+		return fmt.Errorf("failed to count mfa attempt: %w", err)
+	}
+
+	if err := s.checkCode(ctx, mfa, code); err != nil {
+		return err
+	}
+
+	if err := s.mfaRepo.ResetAttempts(ctx, developerID); err != nil {
+		return fmt.Errorf("failed to reset mfa attempts: %w", err)
+	}
+	return nil
+}
+
+// checkCode accepts either a TOTP code or an unused recovery code
+func (s *MFAService) checkCode(ctx context.Context, mfa *domain.MFA, code string) error {
+	developerID := mfa.DeveloperID
+	code = strings.TrimSpace(code)
+	if isRecoveryCode(code) {
+		err := s.mfaRepo.UseRecoveryCode(ctx, developerID, hashRecoveryCode(code))
+		if err != nil {
+			if err == domain.ErrInvalidMFACode {
+				return err
+			}
+			return fmt.Errorf("failed to use recovery code: %w", err)
+		}
+		slog.Info("mfa recovery code used", "developer_id", developerID)
+		return nil
+	}
+
+	step, err := s.matchTOTP(mfa, code)
+	if err != nil {
+		return err
+	}
+
+	// Each time step is accepted once, so an observed code cannot be replayed
+	if err := s.mfaRepo.UseStep(ctx, developerID, step); err != nil {
+		if err == domain.ErrInvalidMFACode {
+			return err
+		}
+		return fmt.Errorf("failed to record totp use: %w", err)
+	}
+	return nil
+}
+
+func (s *MFAService) get(ctx context.Context, developerID uuid.UUID) (*domain.MFA, error) {
+	mfa, err := s.mfaRepo.Get(ctx, developerID)
+	if err != nil {
+		if err == domain.ErrMFANotEnrolled {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to fetch mfa: %w", err)
+	}
+	return mfa, nil
+}
+
+func (s *MFAService) matchTOTP(mfa *domain.MFA, code string) (int64, error) {
+	if len(s.encryptionKey) == 0 {
+		return 0, domain.ErrMFAUnavailable
+	}
+	secret, err := utils.DecryptSecret(s.encryptionKey, mfa.SecretEncrypted)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decrypt totp secret: %w", err)
+	}
+
+	step, ok := utils.ValidateTOTP(secret, strings.TrimSpace(code), time.Now())
+	if !ok {
+		return 0, domain.ErrInvalidMFACode
+	}
+	return step, nil
+}
+
+// generateRecoveryCodes returns codes formatted XXXX-XXXX-XXXX-XXXX (80 bits each) and their hashes
+func generateRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+
+	for i := range codes {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := encoding.EncodeToString(b)
+		codes[i] = raw[0:4] + "-" + raw[4:8] + "-" + raw[8:12] + "-" + raw[12:16]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+func hashRecoveryCode(code string) string {
+	return utils.HashToken(normalizeRecoveryCode(code))
+}
+
+// isRecoveryCode reports whether code has the shape of a recovery code, with
+// or without separators and in any case
+func isRecoveryCode(code string) bool {
+	normalized := normalizeRecoveryCode(code)
+	if len(normalized) != recoveryCodeLength {
+		return false
+	}
+	for _, c := range normalized {
+		if !strings.ContainsRune(recoveryCodeAlphabet, c) {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
@@ -0,0 +1,356 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+	"github.com/vivek-344/diagon/sigil/utils"
+)
+
+const (
+	authorizationCodeTTL = 5 * time.Minute
+	maxRedirectURIs      = 10
+)
+
+// defaultOAuthScopes are requested when an authorization request names none
+var defaultOAuthScopes = []string{domain.ScopeDevelopersRead}
+
+// OAuthService runs the authorization code grant with PKCE for third-party
+// clients. Tokens come from AuthService, in a session per authorization.
+type OAuthService struct {
+	repo          domain.OAuthRepository
+	developerRepo domain.DeveloperRepository
+	authSvc       *AuthService
+}
+
+func NewOAuthService(repo domain.OAuthRepository, developerRepo domain.DeveloperRepository, authSvc *AuthService) *OAuthService {
+	return &OAuthService{
+		repo:          repo,
+		developerRepo: developerRepo,
+		authSvc:       authSvc,
+	}
+}
+
+// RegisterClient adds a client owned by the developer. Confidential clients
+// get a secret, returned in clear only here; public clients get none.
+func (s *OAuthService) RegisterClient(
+	ctx context.Context,
+	developerID uuid.UUID,
+	name string,
+	redirectURIs []string,
+	confidential bool,
+) (string, *domain.OAuthClient, error) {
+	slog.Debug("registering oauth client", "developer_id", developerID)
+
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 255 {
+		return "", nil, domain.ErrInvalidInput
+	}
+	if len(redirectURIs) == 0 || len(redirectURIs) > maxRedirectURIs {
+		return "", nil, domain.ErrInvalidRedirectURI
+	}
+	for _, uri := range redirectURIs {
+		if !domain.ValidRedirectURI(uri) {
+			return "", nil, domain.ErrInvalidRedirectURI
+		}
+	}
+
+	clientID, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate client id: %w", err)
+	}
+
+	client := &domain.OAuthClient{
+		ClientID:     domain.OAuthClientIDPrefix + clientID[:24],
+		Name:         name,
+		RedirectURIs: redirectURIs,
+		CreatedBy:    &developerID,
+	}
+
+	var rawSecret string
+	if confidential {
+		secret, err := utils.GenerateOpaqueToken()
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to generate client secret: %w", err)
+		}
+		rawSecret = domain.OAuthClientSecretPrefix + secret
+		hash := utils.HashToken(rawSecret)
+		client.SecretHash = &hash
+	}
+
+	if err := s.repo.CreateClient(ctx, client); err != nil {
+		return "", nil, fmt.Errorf("failed to store oauth client: %w", err)
+	}
+
+	slog.Info("oauth client registered", "developer_id", developerID, "client_id", client.ClientID)
+	return rawSecret, client, nil
+}
+
+func (s *OAuthService) ListClients(ctx context.Context, developerID uuid.UUID) ([]*domain.OAuthClient, error) {
+	clients, err := s.repo.ListClientsByDeveloper(ctx, developerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list oauth clients: %w", err)
+	}
+	return clients, nil
+}
+
+// HasClients reports whether any OAuth client is registered and not revoked
+func (s *OAuthService) HasClients(ctx context.Context) (bool, error) {
+	count, err := s.repo.CountActiveClients(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to count oauth clients: %w", err)
+	}
+	return count > 0, nil
+}
+
+// RevokeClient stops new authorizations, exchanges and refreshes for the
+// client. Access tokens already issued stay valid until they expire.
+func (s *OAuthService) RevokeClient(ctx context.Context, developerID uuid.UUID, id uuid.UUID) error {
+	if err := s.repo.RevokeClient(ctx, id, developerID); err != nil {
+		if err == domain.ErrOAuthClientNotFound {
+			return err
+		}
+		return fmt.Errorf("failed to revoke oauth client: %w", err)
+	}
+
+	slog.Info("oauth client revoked", "developer_id", developerID, "oauth_client_id", id)
+	return nil
+}
+
+// ValidateAuthorization checks an authorization request and fills in default
+// scopes. ErrOAuthClientNotFound and ErrInvalidRedirectURI must be shown to
+// the user; other errors may be sent to the redirect URI (RFC 6749 4.1.2.1).
+func (s *OAuthService) ValidateAuthorization(ctx context.Context, req *domain.AuthorizationRequest) (*domain.OAuthClient, error) {
+	client, err := s.repo.GetClientByClientID(ctx, req.ClientID)
+	if err != nil {
+		if err == domain.ErrOAuthClientNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to fetch oauth client: %w", err)
+	}
+	if client.RevokedAt != nil {
+		return nil, domain.ErrOAuthClientNotFound
+	}
+	if !client.AllowsRedirect(req.RedirectURI) {
+		return nil, domain.ErrInvalidRedirectURI
+	}
+
+	if req.ResponseType != "code" {
+		return client, domain.ErrUnsupportedResponse
+	}
+	if req.CodeChallengeMethod != domain.CodeChallengeS256 || len(req.CodeChallenge) != 43 {
+		return client, domain.ErrInvalidCodeChallenge
+	}
+
+	if len(req.Scopes) == 0 {
+		req.Scopes = defaultOAuthScopes
+	}
+	for _, scope := range req.Scopes {
+		if !domain.IsKnownScope(scope) && !domain.IsOIDCScope(scope) {
+			return client, domain.ErrInvalidScope
+		}
+	}
+	if len(req.Nonce) > 255 {
+		return client, domain.ErrInvalidNonce
+	}
+
+	return client, nil
+}
+
+// ConsentPrompt validates the request for the consent screen and reports
+// whether the developer already approved every requested scope
+func (s *OAuthService) ConsentPrompt(ctx context.Context, developerID uuid.UUID, req *domain.AuthorizationRequest) (*domain.OAuthClient, bool, error) {
+	client, err := s.ValidateAuthorization(ctx, req)
+	if err != nil {
+		return client, false, err
+	}
+
+	consented, err := s.repo.GetConsent(ctx, developerID, client.ID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to fetch consent: %w", err)
+	}
+
+	for _, scope := range req.Scopes {
+		if !domain.HasScope(consented, scope) {
+			return client, false, nil
+		}
+	}
+	return client, true, nil
+}
+
+// Authorize records the developer's consent and returns a single-use
+// authorization code for the client's redirect URI
+func (s *OAuthService) Authorize(ctx context.Context, developerID uuid.UUID, req *domain.AuthorizationRequest) (string, error) {
+	client, err := s.ValidateAuthorization(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.repo.SaveConsent(ctx, developerID, client.ID, req.Scopes); err != nil {
+		return "", fmt.Errorf("failed to store consent: %w", err)
+	}
+
+	rawCode, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate authorization code: %w", err)
+	}
+
+	code := &domain.AuthorizationCode{
+		CodeHash:            utils.HashToken(rawCode),
+		OAuthClientID:       client.ID,
+		DeveloperID:         developerID,
+		RedirectURI:         req.RedirectURI,
+		Scopes:              req.Scopes,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		ExpiresAt:           time.Now().Add(authorizationCodeTTL),
+	}
+	if req.Nonce != "" {
+		code.Nonce = &req.Nonce
+	}
+	if err := s.repo.CreateCode(ctx, code); err != nil {
+		return "", fmt.Errorf("failed to store authorization code: %w", err)
+	}
+
+	slog.Info("oauth authorization granted", "developer_id", developerID, "client_id", client.ClientID, "scopes", req.Scopes)
+	return rawCode, nil
+}
+
+// ExchangeCode redeems an authorization code for a token pair. A code
+// presented twice also revokes the session it was first exchanged for.
+func (s *OAuthService) ExchangeCode(
+	ctx context.Context,
+	clientID string,
+	clientSecret string,
+	rawCode string,
+	redirectURI string,
+	codeVerifier string,
+	info domain.ClientInfo,
+) (*utils.TokenPair, []string, error) {
+	client, err := s.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	code, err := s.repo.ConsumeCode(ctx, utils.HashToken(rawCode))
+	if err != nil {
+		if err == domain.ErrAuthorizationCodeUsed {
+			s.revokeCodeSession(ctx, code)
+			return nil, nil, domain.ErrInvalidGrant
+		}
+		if err == domain.ErrInvalidGrant {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("failed to consume authorization code: %w", err)
+	}
+
+	if code.OAuthClientID != client.ID || code.RedirectURI != redirectURI || time.Now().After(code.ExpiresAt) {
+		return nil, nil, domain.ErrInvalidGrant
+	}
+	if !verifyCodeChallenge(code.CodeChallenge, codeVerifier) {
+		return nil, nil, domain.ErrInvalidGrant
+	}
+
+	dev, err := s.developerRepo.GetByID(ctx, code.DeveloperID)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return nil, nil, domain.ErrInvalidGrant
+		}
+		return nil, nil, fmt.Errorf("failed to fetch developer: %w", err)
+	}
+	if dev.Status == domain.StatusSuspended {
+		return nil, nil, domain.ErrInvalidGrant
+	}
+
+	var nonce string
+	if code.Nonce != nil {
+		nonce = *code.Nonce
+	}
+	pair, session, err := s.authSvc.IssueOAuthTokens(ctx, dev, client, code.Scopes, nonce, info)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.repo.SetCodeSession(ctx, code.ID, session.ID); err != nil {
+		slog.Warn("failed to link authorization code to session", "session_id", session.ID, "error", err)
+	}
+
+	return pair, sessionScopes(dev, session), nil
+}
+
+// Refresh rotates a refresh token issued to the client
+func (s *OAuthService) Refresh(ctx context.Context, clientID string, clientSecret string, rawToken string, info domain.ClientInfo) (*utils.TokenPair, []string, error) {
+	client, err := s.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pair, scopes, err := s.authSvc.RefreshOAuth(ctx, rawToken, client, info)
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidRefreshToken, domain.ErrExpiredRefreshToken, domain.ErrRefreshTokenReused,
+			domain.ErrAccountSuspended, domain.ErrNotFound:
+			return nil, nil, domain.ErrInvalidGrant
+		}
+		return nil, nil, err
+	}
+	return pair, scopes, nil
+}
+
+// authenticateClient checks the secret of confidential clients; public
+// clients must not present one
+func (s *OAuthService) authenticateClient(ctx context.Context, clientID string, clientSecret string) (*domain.OAuthClient, error) {
+	if !strings.HasPrefix(clientID, domain.OAuthClientIDPrefix) {
+		return nil, domain.ErrInvalidClientCredentials
+	}
+
+	client, err := s.repo.GetClientByClientID(ctx, clientID)
+	if err != nil {
+		if err == domain.ErrOAuthClientNotFound {
+			return nil, domain.ErrInvalidClientCredentials
+		}
+		return nil, fmt.Errorf("failed to fetch oauth client: %w", err)
+	}
+	if client.RevokedAt != nil {
+		return nil, domain.ErrInvalidClientCredentials
+	}
+
+	if client.Confidential() {
+		hash := utils.HashToken(clientSecret)
+		if clientSecret == "" || subtle.ConstantTimeCompare([]byte(hash), []byte(*client.SecretHash)) != 1 {
+			return nil, domain.ErrInvalidClientCredentials
+		}
+	} else if clientSecret != "" {
+		return nil, domain.ErrInvalidClientCredentials
+	}
+
+	return client, nil
+}
+
+func (s *OAuthService) revokeCodeSession(ctx context.Context, code *domain.AuthorizationCode) {
+	slog.Warn("authorization code reuse detected", "developer_id", code.DeveloperID, "session_id", code.SessionID)
+	if code.SessionID == nil {
+		return
+	}
+	if err := s.authSvc.RevokeSession(ctx, code.DeveloperID, *code.SessionID); err != nil && err != domain.ErrSessionNotFound {
+		slog.Error("failed to revoke session of reused code", "session_id", *code.SessionID, "error", err)
+	}
+}
+
+// verifyCodeChallenge checks a PKCE verifier against its S256 challenge (RFC 7636 4.6)
+func verifyCodeChallenge(challenge string, verifier string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
@@ -0,0 +1,409 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+	"github.com/vivek-344/diagon/sigil/internal/mailer"
+	"github.com/vivek-344/diagon/sigil/utils"
+)
+
+const invitationTTL = 7 * 24 * time.Hour
+
+type OrganizationService struct {
+	repo          domain.OrganizationRepository
+	developerRepo domain.DeveloperRepository
+	sessionRepo   domain.SessionRepository
+	plans         *PlanService
+	mailer        mailer.Mailer
+	appBaseURL    string
+}
+
+func NewOrganizationService(
+	repo domain.OrganizationRepository,
+	developerRepo domain.DeveloperRepository,
+	sessionRepo domain.SessionRepository,
+	plans *PlanService,
+	mailer mailer.Mailer,
+	appBaseURL string,
+) *OrganizationService {
+	return &OrganizationService{
+		repo:          repo,
+		developerRepo: developerRepo,
+		sessionRepo:   sessionRepo,
+		plans:         plans,
+		mailer:        mailer,
+		appBaseURL:    appBaseURL,
+	}
+}
+
+// Create starts an organization owned by the developer
+func (s *OrganizationService) Create(ctx context.Context, developerID uuid.UUID, name string, slug string) (*domain.Organization, error) {
+	slog.Debug("creating organization", "developer_id", developerID, "slug", slug)
+
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 255 || !domain.ValidSlug(slug) {
+		return nil, domain.ErrInvalidOrganization
+	}
+
+	org := &domain.Organization{Name: name, Slug: slug}
+	if err := s.repo.Create(ctx, org, developerID); err != nil {
+		if err == domain.ErrOrganizationSlugTaken {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+
+	slog.Info("organization created", "org_id", org.ID, "owner_id", developerID)
+	return org, nil
+}
+
+// List returns the organizations the developer belongs to with their role in each
+func (s *OrganizationService) List(ctx context.Context, developerID uuid.UUID) ([]*domain.OrganizationMembership, error) {
+	memberships, err := s.repo.ListForDeveloper(ctx, developerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	return memberships, nil
+}
+
+// Get returns an organization to one of its members
+func (s *OrganizationService) Get(ctx context.Context, developerID uuid.UUID, orgID uuid.UUID) (*domain.OrganizationMembership, error) {
+	role, err := s.memberRole(ctx, orgID, developerID)
+	if err != nil {
+		return nil, err
+	}
+
+	org, err := s.repo.GetByID(ctx, orgID)
+	if err != nil {
+		if err == domain.ErrOrganizationNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to fetch organization: %w", err)
+	}
+	return &domain.OrganizationMembership{Organization: org, Role: role}, nil
+}
+
+// Rename changes the display name; admins and the owner only
+func (s *OrganizationService) Rename(ctx context.Context, developerID uuid.UUID, orgID uuid.UUID, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 255 {
+		return domain.ErrInvalidOrganization
+	}
+
+	role, err := s.memberRole(ctx, orgID, developerID)
+	if err != nil {
+		return err
+	}
+	if !role.CanManageMembers() {
+		return domain.ErrForbidden
+	}
+
+	if err := s.repo.Rename(ctx, orgID, name); err != nil {
+		if err == domain.ErrOrganizationNotFound {
+			return err
+		}
+		return fmt.Errorf("failed to rename organization: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the organization with its memberships and invitations; owner only
+func (s *OrganizationService) Delete(ctx context.Context, developerID uuid.UUID, orgID uuid.UUID) error {
+	role, err := s.memberRole(ctx, orgID, developerID)
+	if err != nil {
+		return err
+	}
+	if role != domain.OrgRoleOwner {
+		return domain.ErrForbidden
+	}
+
+	if err := s.repo.Delete(ctx, orgID); err != nil {
+		if err == domain.ErrOrganizationNotFound {
+			return err
+		}
+		return fmt.Errorf("failed to delete organization: %w", err)
+	}
+
+	slog.Info("organization deleted", "org_id", orgID, "developer_id", developerID)
+	return nil
+}
+
+// ListMembers returns the members to any other member
+func (s *OrganizationService) ListMembers(ctx context.Context, developerID uuid.UUID, orgID uuid.UUID) ([]*domain.OrganizationMember, error) {
+	if _, err := s.memberRole(ctx, orgID, developerID); err != nil {
+		return nil, err
+	}
+
+	members, err := s.repo.ListMembers(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members: %w", err)
+	}
+	return members, nil
+}
+
+// SetMemberRole changes another member's role. Ownership only moves through
+// TransferOwnership, and admins cannot grant or revoke admin.
+func (s *OrganizationService) SetMemberRole(ctx context.Context, developerID uuid.UUID, orgID uuid.UUID, memberID uuid.UUID, role domain.OrgRole) error {
+	if !role.Valid() || role == domain.OrgRoleOwner {
+		return domain.ErrInvalidOrgRole
+	}
+	if developerID == memberID {
+		return domain.ErrForbidden
+	}
+
+	actorRole, err := s.memberRole(ctx, orgID, developerID)
+	if err != nil {
+		return err
+	}
+	current, err := s.targetRole(ctx, orgID, memberID)
+	if err != nil {
+		return err
+	}
+	if !actorRole.CanManage(current) || !actorRole.CanManage(role) {
+		return domain.ErrForbidden
+	}
+
+	if err := s.repo.SetMemberRole(ctx, orgID, memberID, role); err != nil {
+		if err == domain.ErrNotOrganizationMember {
+			return domain.ErrMemberNotFound
+		}
+		return fmt.Errorf("failed to set member role: %w", err)
+	}
+
+	slog.Info("organization member role changed", "org_id", orgID, "developer_id", memberID, "role", role, "changed_by", developerID)
+	return nil
+}
+
+// RemoveMember removes a member, or lets a member leave. The owner cannot
+// leave until ownership has been transferred.
+func (s *OrganizationService) RemoveMember(ctx context.Context, developerID uuid.UUID, orgID uuid.UUID, memberID uuid.UUID) error {
+	actorRole, err := s.memberRole(ctx, orgID, developerID)
+	if err != nil {
+		return err
+	}
+
+	if developerID == memberID {
+		if actorRole == domain.OrgRoleOwner {
+			return domain.ErrOwnerCannotLeave
+		}
+	} else {
+		current, err := s.targetRole(ctx, orgID, memberID)
+		if err != nil {
+			return err
+		}
+		if !actorRole.CanManage(current) {
+			return domain.ErrForbidden
+		}
+	}
+
+	if err := s.repo.RemoveMember(ctx, orgID, memberID); err != nil {
+		if err == domain.ErrNotOrganizationMember {
+			return domain.ErrMemberNotFound
+		}
+		return fmt.Errorf("failed to remove member: %w", err)
+	}
+
+	// Refreshes would drop the context anyway; clearing it now keeps session lists accurate
+	if err := s.sessionRepo.ClearOrganization(ctx, memberID, orgID); err != nil {
+		slog.Warn("failed to clear organization context", "org_id", orgID, "developer_id", memberID, "error", err)
+	}
+
+	slog.Info("organization member removed", "org_id", orgID, "developer_id", memberID, "removed_by", developerID)
+	return nil
+}
+
+// TransferOwnership hands the organization to another member; the previous owner becomes an admin
+func (s *OrganizationService) TransferOwnership(ctx context.Context, developerID uuid.UUID, orgID uuid.UUID, newOwnerID uuid.UUID) error {
+	actorRole, err := s.memberRole(ctx, orgID, developerID)
+	if err != nil {
+		return err
+	}
+	if actorRole != domain.OrgRoleOwner || developerID == newOwnerID {
+		return domain.ErrForbidden
+	}
+
+	if err := s.repo.TransferOwnership(ctx, orgID, developerID, newOwnerID); err != nil {
+		if err == domain.ErrNotOrganizationMember {
+			return domain.ErrMemberNotFound
+		}
+		if err == domain.ErrForbidden {
+			return err
+		}
+		return fmt.Errorf("failed to transfer ownership: %w", err)
+	}
+
+	slog.Info("organization ownership transferred", "org_id", orgID, "from", developerID, "to", newOwnerID)
+	return nil
+}
+
+// Invite emails a single-use invitation link. Earlier pending invitations
+// for the same address are revoked. Pending invitations take a seat.
+func (s *OrganizationService) Invite(ctx context.Context, developerID uuid.UUID, orgID uuid.UUID, email string, role domain.OrgRole) (*domain.OrganizationInvitation, error) {
+	slog.Debug("inviting to organization", "org_id", orgID, "developer_id", developerID)
+
+	email = strings.TrimSpace(email)
+	if !utils.IsValidEmail(email) {
+		return nil, domain.ErrInvalidEmail
+	}
+	if !role.Valid() || role == domain.OrgRoleOwner {
+		return nil, domain.ErrInvalidOrgRole
+	}
+
+	actorRole, err := s.memberRole(ctx, orgID, developerID)
+	if err != nil {
+		return nil, err
+	}
+	if !actorRole.CanManage(role) {
+		return nil, domain.ErrForbidden
+	}
+
+	org, err := s.repo.GetByID(ctx, orgID)
+	if err != nil {
+		if err == domain.ErrOrganizationNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to fetch organization: %w", err)
+	}
+
+	if existing, err := s.developerRepo.GetByEmail(ctx, email); err == nil {
+		if _, err := s.repo.GetMemberRole(ctx, orgID, existing.ID); err == nil {
+			return nil, domain.ErrAlreadyMember
+		}
+	} else if err != domain.ErrNotFound {
+		return nil, fmt.Errorf("failed to fetch developer: %w", err)
+	}
+
+	if err := s.plans.CheckSeatQuota(ctx, orgID, email); err != nil {
+		return nil, err
+	}
+
+	rawToken, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate invitation token: %w", err)
+	}
+
+	invitation := &domain.OrganizationInvitation{
+		OrganizationID: orgID,
+		Email:          email,
+		Role:           role,
+		TokenHash:      utils.HashToken(rawToken),
+		InvitedBy:      &developerID,
+		ExpiresAt:      time.Now().Add(invitationTTL),
+	}
+	if err := s.repo.CreateInvitation(ctx, invitation); err != nil {
+		return nil, fmt.Errorf("failed to store invitation: %w", err)
+	}
+
+	link := s.appBaseURL + "/accept-invitation?token=" + url.QueryEscape(rawToken)
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      email,
+		Subject: "You have been invited to " + org.Name + " on DIAGON",
+		Body: "You have been invited to join " + org.Name + " on DIAGON as " + string(role) + ".\n\n" +
+			"Sign in or create an account with this email address, then open the link below:\n\n" +
+			link + "\n\n" +
+			"The invitation expires in 7 days. If you were not expecting it, you can ignore this email.",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send invitation email: %w", err)
+	}
+
+	slog.Info("organization invitation sent", "org_id", orgID, "invitation_id", invitation.ID, "invited_by", developerID)
+	return invitation, nil
+}
+
+// ListInvitations returns pending invitations; admins and the owner only
+func (s *OrganizationService) ListInvitations(ctx context.Context, developerID uuid.UUID, orgID uuid.UUID) ([]*domain.OrganizationInvitation, error) {
+	role, err := s.memberRole(ctx, orgID, developerID)
+	if err != nil {
+		return nil, err
+	}
+	if !role.CanManageMembers() {
+		return nil, domain.ErrForbidden
+	}
+
+	invitations, err := s.repo.ListPendingInvitations(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+	return invitations, nil
+}
+
+// RevokeInvitation withdraws a pending invitation; admins and the owner only
+func (s *OrganizationService) RevokeInvitation(ctx context.Context, developerID uuid.UUID, orgID uuid.UUID, invitationID uuid.UUID) error {
+	role, err := s.memberRole(ctx, orgID, developerID)
+	if err != nil {
+		return err
+	}
+	if !role.CanManageMembers() {
+		return domain.ErrForbidden
+	}
+
+	if err := s.repo.RevokeInvitation(ctx, invitationID, orgID); err != nil {
+		if err == domain.ErrInvitationNotFound {
+			return err
+		}
+		return fmt.Errorf("failed to revoke invitation: %w", err)
+	}
+	return nil
+}
+
+// AcceptInvitation adds the developer to the inviting organization. The
+// invitation must have been addressed to the developer's email.
+func (s *OrganizationService) AcceptInvitation(ctx context.Context, developerID uuid.UUID, rawToken string) (*domain.OrganizationMembership, error) {
+	invitation, err := s.repo.GetPendingInvitation(ctx, utils.HashToken(rawToken))
+	if err != nil {
+		if err == domain.ErrInvalidInvitation {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to fetch invitation: %w", err)
+	}
+
+	dev, err := s.developerRepo.GetByID(ctx, developerID)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to fetch developer: %w", err)
+	}
+	if !strings.EqualFold(dev.Email, invitation.Email) {
+		return nil, domain.ErrInvitationMismatch
+	}
+
+	if err := s.repo.AcceptInvitation(ctx, invitation.ID, developerID); err != nil {
+		if err == domain.ErrInvalidInvitation || err == domain.ErrAlreadyMember {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+
+	slog.Info("organization invitation accepted", "org_id", invitation.OrganizationID, "developer_id", developerID)
+	return s.Get(ctx, developerID, invitation.OrganizationID)
+}
+
+func (s *OrganizationService) memberRole(ctx context.Context, orgID uuid.UUID, developerID uuid.UUID) (domain.OrgRole, error) {
+	role, err := s.repo.GetMemberRole(ctx, orgID, developerID)
+	if err != nil {
+		if err == domain.ErrNotOrganizationMember {
+			return "", err
+		}
+		return "", fmt.Errorf("failed to fetch membership: %w", err)
+	}
+	return role, nil
+}
+
+// targetRole looks up the member an operation applies to
+func (s *OrganizationService) targetRole(ctx context.Context, orgID uuid.UUID, memberID uuid.UUID) (domain.OrgRole, error) {
+	role, err := s.memberRole(ctx, orgID, memberID)
+	if err == domain.ErrNotOrganizationMember {
+		return "", domain.ErrMemberNotFound
+	}
+	return role, err
+}
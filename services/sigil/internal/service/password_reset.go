@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+	"github.com/vivek-344/diagon/sigil/internal/mailer"
+	"github.com/vivek-344/diagon/sigil/utils"
+)
+
+const (
+	passwordResetTokenTTL = 30 * time.Minute
+	passwordResetCooldown = time.Minute
+)
+
+type PasswordResetService struct {
+	developerRepo domain.DeveloperRepository
+	tokenRepo     domain.OneTimeTokenRepository
+	developerSvc  *DeveloperService
+	authSvc       *AuthService
+	mailer        mailer.Mailer
+	appBaseURL    string
+}
+
+func NewPasswordResetService(
+	developerRepo domain.DeveloperRepository,
+	tokenRepo domain.OneTimeTokenRepository,
+	developerSvc *DeveloperService,
+	authSvc *AuthService,
+	mailer mailer.Mailer,
+	appBaseURL string,
+) *PasswordResetService {
+	return &PasswordResetService{
+		developerRepo: developerRepo,
+		tokenRepo:     tokenRepo,
+		developerSvc:  developerSvc,
+		authSvc:       authSvc,
+		mailer:        mailer,
+		appBaseURL:    appBaseURL,
+	}
+}
+
+// RequestReset mails a reset link if the email belongs to an active account.
+// It reports nothing about whether the account exists, and issuing happens in
+// the background so response timing doesn't reveal it either.
+func (s *PasswordResetService) RequestReset(ctx context.Context, email string) error {
+	slog.Debug("password reset requested")
+
+	dev, err := s.developerRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return nil
+		}
+		return fmt.Errorf("failed to fetch developer: %w", err)
+	}
+	if dev.Status == domain.StatusSuspended {
+		return nil
+	}
+
+	go func() {
+		if err := s.issueReset(context.WithoutCancel(ctx), dev); err != nil {
+			slog.Error("failed to issue password reset", "developer_id", dev.ID, "error", err)
+		}
+	}()
+	return nil
+}
+
+func (s *PasswordResetService) issueReset(ctx context.Context, dev *domain.Developer) error {
+	lastSent, err := s.tokenRepo.LatestCreatedAt(ctx, dev.ID, domain.PurposePasswordReset)
+	if err != nil {
+		return fmt.Errorf("failed to check reset cooldown: %w", err)
+	}
+	if lastSent != nil && time.Since(*lastSent) < passwordResetCooldown {
+		slog.Debug("password reset within cooldown, skipping", "developer_id", dev.ID)
+		return nil
+	}
+
+	if err := s.tokenRepo.InvalidateAll(ctx, dev.ID, domain.PurposePasswordReset); err != nil {
+		return fmt.Errorf("failed to invalidate reset tokens: %w", err)
+	}
+
+	rawToken, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate reset token: %w", err)
+	}
+
+	err = s.tokenRepo.Create(ctx, &domain.OneTimeToken{
+		DeveloperID: dev.ID,
+		Purpose:     domain.PurposePasswordReset,
+		TokenHash:   utils.HashToken(rawToken),
+		ExpiresAt:   time.Now().Add(passwordResetTokenTTL),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store reset token: %w", err)
+	}
+
+	link := s.appBaseURL + "/reset-password?token=" + url.QueryEscape(rawToken)
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      dev.Email,
+		Subject: "Reset your DIAGON password",
+		Body: "We received a request to reset the password for your DIAGON account.\n\n" +
+			"Choose a new password by opening the link below:\n\n" +
+			link + "\n\n" +
+			"The link expires in 30 minutes and can only be used once. " +
+			"If you did not request a reset, you can ignore this email.",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send reset email: %w", err)
+	}
+
+	slog.Info("password reset email sent", "developer_id", dev.ID)
+	return nil
+}
+
+// ResetPassword consumes a reset token, sets the new password and signs out every session
+func (s *PasswordResetService) ResetPassword(ctx context.Context, rawToken string, newPassword string) error {
+	// Validate first so a weak password doesn't burn the token
+	if err := utils.IsStrongPassword(newPassword); err != nil {
+		return err
+	}
+
+	token, err := s.tokenRepo.Consume(ctx, utils.HashToken(rawToken), domain.PurposePasswordReset)
+	if err != nil {
+		if err == domain.ErrInvalidOneTimeToken {
+			return err
+		}
+		return fmt.Errorf("failed to consume reset token: %w", err)
+	}
+
+	if err := s.developerSvc.ResetPassword(ctx, token.DeveloperID, newPassword); err != nil {
+		return err
+	}
+
+	if err := s.authSvc.RevokeOtherSessions(ctx, token.DeveloperID, uuid.Nil); err != nil {
+		return err
+	}
+
+	slog.Info("developer password reset via email", "developer_id", token.DeveloperID)
+	return nil
+}
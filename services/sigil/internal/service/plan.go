@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+)
+
+// PlanService resolves plan tiers against the catalog and enforces quotas.
+// Checks count before the write, so concurrent creates may overshoot by one.
+type PlanService struct {
+	catalog       domain.PlanCatalog
+	developerRepo domain.DeveloperRepository
+	orgRepo       domain.OrganizationRepository
+	usageRepo     domain.UsageRepository
+}
+
+func NewPlanService(
+	catalog domain.PlanCatalog,
+	developerRepo domain.DeveloperRepository,
+	orgRepo domain.OrganizationRepository,
+	usageRepo domain.UsageRepository,
+) *PlanService {
+	return &PlanService{
+		catalog:       catalog,
+		developerRepo: developerRepo,
+		orgRepo:       orgRepo,
+		usageRepo:     usageRepo,
+	}
+}
+
+type planDefinition struct {
+	Name              string `json:"name"`
+	MaxProjects       int    `json:"max_projects"`
+	MaxAPIKeys        int    `json:"max_api_keys"`
+	MaxOrgMembers     int    `json:"max_org_members"`
+	RequestsPerMinute int    `json:"requests_per_minute"`
+}
+
+// NewPlanCatalog parses a JSON object of plans keyed by tier, using -1 for
+// unlimited. Empty input yields the default catalog. A free plan is required.
+func NewPlanCatalog(raw []byte) (domain.PlanCatalog, error) {
+	if len(raw) == 0 {
+		return domain.DefaultPlanCatalog, nil
+	}
+
+	var definitions map[string]planDefinition
+	if err := json.Unmarshal(raw, &definitions); err != nil {
+		return nil, fmt.Errorf("invalid plan catalog: %w", err)
+	}
+	if _, ok := definitions[domain.PlanFree]; !ok {
+		return nil, fmt.Errorf("invalid plan catalog: the %q plan is required", domain.PlanFree)
+	}
+
+	catalog := make(domain.PlanCatalog, len(definitions))
+	for tier, def := range definitions {
+		plan := domain.Plan{
+			Tier:              tier,
+			Name:              def.Name,
+			MaxProjects:       def.MaxProjects,
+			MaxAPIKeys:        def.MaxAPIKeys,
+			MaxOrgMembers:     def.MaxOrgMembers,
+			RequestsPerMinute: def.RequestsPerMinute,
+		}
+		for _, limit := range []int{plan.MaxProjects, plan.MaxAPIKeys, plan.MaxOrgMembers, plan.RequestsPerMinute} {
+			if limit < domain.Unlimited {
+				return nil, fmt.Errorf("invalid plan catalog: negative limit in %q", tier)
+			}
+		}
+		if plan.Name == "" {
+			plan.Name = tier
+		}
+		catalog[tier] = plan
+	}
+	return catalog, nil
+}
+
+// Plans lists the catalog, most restrictive first
+func (s *PlanService) Plans() []domain.Plan {
+	plans := make([]domain.Plan, 0, len(s.catalog))
+	for _, plan := range s.catalog {
+		plans = append(plans, plan)
+	}
+	sort.Slice(plans, func(i, j int) bool {
+		if plans[i].RequestsPerMinute != plans[j].RequestsPerMinute {
+			return plans[i].RequestsPerMinute < plans[j].RequestsPerMinute
+		}
+		return plans[i].Tier < plans[j].Tier
+	})
+	return plans
+}
+
+// ValidTier reports whether a developer may be moved to the tier
+func (s *PlanService) ValidTier(tier string) bool {
+	return s.catalog.Has(tier)
+}
+
+func (s *PlanService) DeveloperPlan(ctx context.Context, developerID uuid.UUID) (domain.Plan, error) {
+	dev, err := s.developerRepo.GetByID(ctx, developerID)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return domain.Plan{}, err
+		}
+		return domain.Plan{}, fmt.Errorf("failed to fetch developer: %w", err)
+	}
+	return s.catalog.Lookup(dev.PlanTier), nil
+}
+
+// OrganizationPlan is the plan of the organization's owner
+func (s *PlanService) OrganizationPlan(ctx context.Context, orgID uuid.UUID) (domain.Plan, error) {
+	tier, err := s.usageRepo.GetOrganizationPlanTier(ctx, orgID)
+	if err != nil {
+		if err == domain.ErrOrganizationNotFound {
+			return domain.Plan{}, err
+		}
+		return domain.Plan{}, fmt.Errorf("failed to fetch organization plan: %w", err)
+	}
+	return s.catalog.Lookup(tier), nil
+}
+
+// CheckProjectQuota is called before a project is created or unarchived,
+// personally or in an organization when orgID is set
+func (s *PlanService) CheckProjectQuota(ctx context.Context, developerID uuid.UUID, orgID *uuid.UUID) error {
+	var (
+		plan domain.Plan
+		used int
+		err  error
+	)
+
+	if orgID != nil {
+		if plan, err = s.OrganizationPlan(ctx, *orgID); err != nil {
+			return err
+		}
+		used, err = s.usageRepo.CountProjectsByOrganization(ctx, *orgID)
+	} else {
+		if plan, err = s.DeveloperPlan(ctx, developerID); err != nil {
+			return err
+		}
+		used, err = s.usageRepo.CountProjectsByDeveloper(ctx, developerID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to count projects: %w", err)
+	}
+
+	return s.check(plan, domain.EntitlementProjects, used)
+}
+
+func (s *PlanService) CheckAPIKeyQuota(ctx context.Context, developerID uuid.UUID) error {
+	plan, err := s.DeveloperPlan(ctx, developerID)
+	if err != nil {
+		return err
+	}
+
+	used, err := s.usageRepo.CountActiveAPIKeys(ctx, developerID)
+	if err != nil {
+		return fmt.Errorf("failed to count api keys: %w", err)
+	}
+
+	return s.check(plan, domain.EntitlementAPIKeys, used)
+}
+
+// CheckSeatQuota is called before inviting email. A pending invitation to
+// the same address is replaced, so it does not take a seat of its own.
+func (s *PlanService) CheckSeatQuota(ctx context.Context, orgID uuid.UUID, email string) error {
+	plan, err := s.OrganizationPlan(ctx, orgID)
+	if err != nil {
+		return err
+	}
+
+	used, err := s.usageRepo.CountOrgSeats(ctx, orgID, email)
+	if err != nil {
+		return fmt.Errorf("failed to count organization seats: %w", err)
+	}
+
+	return s.check(plan, domain.EntitlementOrgMembers, used)
+}
+
+// Usage reports the developer's personal usage against their plan
+func (s *PlanService) Usage(ctx context.Context, developerID uuid.UUID) (*domain.Usage, error) {
+	plan, err := s.DeveloperPlan(ctx, developerID)
+	if err != nil {
+		return nil, err
+	}
+
+	projects, err := s.usageRepo.CountProjectsByDeveloper(ctx, developerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count projects: %w", err)
+	}
+	apiKeys, err := s.usageRepo.CountActiveAPIKeys(ctx, developerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count api keys: %w", err)
+	}
+
+	return &domain.Usage{Plan: plan, Projects: projects, APIKeys: apiKeys}, nil
+}
+
+// OrganizationUsage reports an organization's usage against its owner's
+// plan; admins, billing members and the owner only
+func (s *PlanService) OrganizationUsage(ctx context.Context, developerID uuid.UUID, orgID uuid.UUID) (*domain.Usage, error) {
+	role, err := s.orgRepo.GetMemberRole(ctx, orgID, developerID)
+	if err != nil {
+		if err == domain.ErrNotOrganizationMember {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to fetch membership: %w", err)
+	}
+	if !role.CanManageBilling() && !role.CanManageMembers() {
+		return nil, domain.ErrForbidden
+	}
+
+	plan, err := s.OrganizationPlan(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	projects, err := s.usageRepo.CountProjectsByOrganization(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count projects: %w", err)
+	}
+	seats, err := s.usageRepo.CountOrgSeats(ctx, orgID, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to count organization seats: %w", err)
+	}
+
+	return &domain.Usage{Plan: plan, Projects: projects, OrgMembers: seats}, nil
+}
+
+func (s *PlanService) check(plan domain.Plan, entitlement domain.Entitlement, used int) error {
+	if plan.Allows(entitlement, used) {
+		return nil
+	}
+
+	slog.Info("quota exceeded", "plan", plan.Tier, "entitlement", entitlement, "used", used)
+	return &domain.QuotaError{
+		Plan:        plan.Tier,
+		Entitlement: entitlement,
+		Limit:       plan.Limit(entitlement),
+	}
+}
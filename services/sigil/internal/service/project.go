@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+)
+
+type ProjectService struct {
+	repo    domain.ProjectRepository
+	orgRepo domain.OrganizationRepository
+	plans   *PlanService
+}
+
+func NewProjectService(repo domain.ProjectRepository, orgRepo domain.OrganizationRepository, plans *PlanService) *ProjectService {
+	return &ProjectService{
+		repo:    repo,
+		orgRepo: orgRepo,
+		plans:   plans,
+	}
+}
+
+// Create adds a project with the default environments. Organization projects
+// need an organization admin or the owner.
+func (s *ProjectService) Create(ctx context.Context, developerID uuid.UUID, input domain.CreateProjectInput) (*domain.Project, error) {
+	slog.Debug("creating project", "developer_id", developerID, "org_id", input.OrganizationID, "slug", input.Slug)
+
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len(name) > 255 || !domain.ValidSlug(input.Slug) {
+		return nil, domain.ErrInvalidProject
+	}
+
+	project := &domain.Project{Name: name, Slug: input.Slug}
+	if input.OrganizationID != nil {
+		role, err := s.orgRole(ctx, *input.OrganizationID, developerID)
+		if err != nil {
+			return nil, err
+		}
+		if !role.CanManageProjects() {
+			return nil, domain.ErrForbidden
+		}
+		project.OwnerOrganizationID = input.OrganizationID
+	} else {
+		project.OwnerDeveloperID = &developerID
+	}
+
+	if err := s.plans.CheckProjectQuota(ctx, developerID, input.OrganizationID); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, project, domain.DefaultEnvironments); err != nil {
+		if err == domain.ErrProjectSlugTaken {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to create project: %w", err)
+	}
+
+	slog.Info("project created", "project_id", project.ID, "developer_id", developerID, "org_id", input.OrganizationID)
+	return project, nil
+}
+
+// List returns the developer's personal projects, or those of an
+// organization they belong to when orgID is set
+func (s *ProjectService) List(ctx context.Context, developerID uuid.UUID, orgID *uuid.UUID, includeArchived bool) ([]*domain.Project, error) {
+	var (
+		projects []*domain.Project
+		err      error
+	)
+
+	if orgID != nil {
+		if _, err := s.orgRole(ctx, *orgID, developerID); err != nil {
+			return nil, err
+		}
+		projects, err = s.repo.ListByOrganization(ctx, *orgID, includeArchived)
+	} else {
+		projects, err = s.repo.ListByDeveloper(ctx, developerID, includeArchived)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+	return projects, nil
+}
+
+// Get returns a project the developer can see
+func (s *ProjectService) Get(ctx context.Context, developerID uuid.UUID, projectID uuid.UUID) (*domain.Project, error) {
+	return s.Authorize(ctx, developerID, projectID, false)
+}
+
+func (s *ProjectService) Rename(ctx context.Context, developerID uuid.UUID, projectID uuid.UUID, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 255 {
+		return domain.ErrInvalidProject
+	}
+
+	project, err := s.Authorize(ctx, developerID, projectID, true)
+	if err != nil {
+		return err
+	}
+	if project.ArchivedAt != nil {
+		return domain.ErrProjectArchived
+	}
+
+	if err := s.repo.Rename(ctx, projectID, name); err != nil {
+		if err == domain.ErrProjectNotFound {
+			return err
+		}
+		return fmt.Errorf("failed to rename project: %w", err)
+	}
+	return nil
+}
+
+// SetArchived archives or restores a project. Archived projects are read-only
+// and do not count towards the project quota.
+func (s *ProjectService) SetArchived(ctx context.Context, developerID uuid.UUID, projectID uuid.UUID, archived bool) error {
+	project, err := s.Authorize(ctx, developerID, projectID, true)
+	if err != nil {
+		return err
+	}
+	if !archived && project.ArchivedAt != nil {
+		if err := s.plans.CheckProjectQuota(ctx, developerID, project.OwnerOrganizationID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.repo.SetArchived(ctx, projectID, archived); err != nil {
+		if err == domain.ErrProjectNotFound {
+			return err
+		}
+		return fmt.Errorf("failed to archive project: %w", err)
+	}
+
+	slog.Info("project archive state changed", "project_id", projectID, "archived", archived, "developer_id", developerID)
+	return nil
+}
+
+func (s *ProjectService) ListEnvironments(ctx context.Context, developerID uuid.UUID, projectID uuid.UUID) ([]*domain.Environment, error) {
+	if _, err := s.Authorize(ctx, developerID, projectID, false); err != nil {
+		return nil, err
+	}
+
+	environments, err := s.repo.ListEnvironments(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list environments: %w", err)
+	}
+	return environments, nil
+}
+
+func (s *ProjectService) AddEnvironment(ctx context.Context, developerID uuid.UUID, projectID uuid.UUID, name domain.EnvironmentName) (*domain.Environment, error) {
+	if !name.Valid() {
+		return nil, domain.ErrInvalidEnvironment
+	}
+
+	project, err := s.Authorize(ctx, developerID, projectID, true)
+	if err != nil {
+		return nil, err
+	}
+	return s.addEnvironment(ctx, project, name)
+}
+
+func (s *ProjectService) RemoveEnvironment(ctx context.Context, developerID uuid.UUID, projectID uuid.UUID, envID uuid.UUID) error {
+	project, err := s.Authorize(ctx, developerID, projectID, true)
+	if err != nil {
+		return err
+	}
+	return s.removeEnvironment(ctx, project, envID)
+}
+
+// ClientProject returns the project a service client token is bound to. The
+// token itself carries the project, so no membership check applies.
+func (s *ProjectService) ClientProject(ctx context.Context, projectID uuid.UUID) (*domain.Project, error) {
+	project, err := s.repo.GetByID(ctx, projectID)
+	if err != nil {
+		if err == domain.ErrProjectNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to fetch project: %w", err)
+	}
+	return project, nil
+}
+
+func (s *ProjectService) ClientListEnvironments(ctx context.Context, projectID uuid.UUID) ([]*domain.Environment, error) {
+	if _, err := s.ClientProject(ctx, projectID); err != nil {
+		return nil, err
+	}
+
+	environments, err := s.repo.ListEnvironments(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list environments: %w", err)
+	}
+	return environments, nil
+}
+
+func (s *ProjectService) ClientAddEnvironment(ctx context.Context, projectID uuid.UUID, name domain.EnvironmentName) (*domain.Environment, error) {
+	if !name.Valid() {
+		return nil, domain.ErrInvalidEnvironment
+	}
+
+	project, err := s.ClientProject(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return s.addEnvironment(ctx, project, name)
+}
+
+func (s *ProjectService) ClientRemoveEnvironment(ctx context.Context, projectID uuid.UUID, envID uuid.UUID) error {
+	project, err := s.ClientProject(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	return s.removeEnvironment(ctx, project, envID)
+}
+
+func (s *ProjectService) addEnvironment(ctx context.Context, project *domain.Project, name domain.EnvironmentName) (*domain.Environment, error) {
+	if project.ArchivedAt != nil {
+		return nil, domain.ErrProjectArchived
+	}
+
+	env := &domain.Environment{ProjectID: project.ID, Name: name}
+	if err := s.repo.CreateEnvironment(ctx, env); err != nil {
+		if err == domain.ErrEnvironmentExists {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to create environment: %w", err)
+	}
+	return env, nil
+}
+
+func (s *ProjectService) removeEnvironment(ctx context.Context, project *domain.Project, envID uuid.UUID) error {
+	if project.ArchivedAt != nil {
+		return domain.ErrProjectArchived
+	}
+
+	if err := s.repo.DeleteEnvironment(ctx, envID, project.ID); err != nil {
+		if err == domain.ErrEnvironmentNotFound {
+			return err
+		}
+		return fmt.Errorf("failed to delete environment: %w", err)
+	}
+	return nil
+}
+
+// Authorize loads a project the developer owns or whose organization they
+// belong to. Changes to organization projects need an organization admin.
+// Projects the developer cannot see are reported as not found.
+func (s *ProjectService) Authorize(ctx context.Context, developerID uuid.UUID, projectID uuid.UUID, write bool) (*domain.Project, error) {
+	project, err := s.repo.GetByID(ctx, projectID)
+	if err != nil {
+		if err == domain.ErrProjectNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to fetch project: %w", err)
+	}
+
+	if project.OwnerDeveloperID != nil {
+		if *project.OwnerDeveloperID != developerID {
+			return nil, domain.ErrProjectNotFound
+		}
+		return project, nil
+	}
+
+	role, err := s.orgRole(ctx, *project.OwnerOrganizationID, developerID)
+	if err != nil {
+		if err == domain.ErrNotOrganizationMember {
+			return nil, domain.ErrProjectNotFound
+		}
+		return nil, err
+	}
+	if write && !role.CanManageProjects() {
+		return nil, domain.ErrForbidden
+	}
+	return project, nil
+}
+
+func (s *ProjectService) orgRole(ctx context.Context, orgID uuid.UUID, developerID uuid.UUID) (domain.OrgRole, error) {
+	role, err := s.orgRepo.GetMemberRole(ctx, orgID, developerID)
+	if err != nil {
+		if err == domain.ErrNotOrganizationMember {
+			return "", err
+		}
+		return "", fmt.Errorf("failed to fetch membership: %w", err)
+	}
+	return role, nil
+}
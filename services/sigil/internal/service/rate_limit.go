@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+)
+
+const rateLimitWindow = time.Minute
+
+// RateLimiter counts requests per developer in fixed one-minute windows
+// against the requests_per_minute entitlement. Counts are kept per instance,
+// so the effective limit scales with the number of replicas.
+type RateLimiter struct {
+	plans   *PlanService
+	mu      sync.Mutex
+	windows map[uuid.UUID]*rateWindow
+}
+
+type rateWindow struct {
+	start time.Time
+	limit int
+	count int
+}
+
+func NewRateLimiter(plans *PlanService) *RateLimiter {
+	return &RateLimiter{
+		plans:   plans,
+		windows: make(map[uuid.UUID]*rateWindow),
+	}
+}
+
+// Allow counts a request. The developer's plan is read once per window, so
+// plan changes apply from the next minute.
+func (l *RateLimiter) Allow(ctx context.Context, developerID uuid.UUID) (bool, time.Duration) {
+	now := time.Now()
+
+	l.mu.Lock()
+	window, ok := l.windows[developerID]
+	l.mu.Unlock()
+
+	if !ok || now.Sub(window.start) >= rateLimitWindow {
+		limit := domain.Unlimited
+		plan, err := l.plans.DeveloperPlan(ctx, developerID)
+		if err != nil {
+			// Fail open; authentication already succeeded
+			slog.Warn("failed to resolve plan for rate limiting", "developer_id", developerID, "error", err)
+		} else {
+			limit = plan.RequestsPerMinute
+		}
+
+		l.mu.Lock()
+		// Another request may have opened the window meanwhile
+		if current, ok := l.windows[developerID]; ok && now.Sub(current.start) < rateLimitWindow {
+			window = current
+		} else {
+			window = &rateWindow{start: now, limit: limit}
+			l.windows[developerID] = window
+		}
+		l.mu.Unlock()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if window.limit != domain.Unlimited && window.count >= window.limit {
+		return false, window.start.Add(rateLimitWindow).Sub(now)
+	}
+	window.count++
+	return true, 0
+}
+
+// Run drops expired windows until ctx is cancelled
+func (l *RateLimiter) Run(ctx context.Context) {
+	ticker := time.NewTicker(rateLimitWindow)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		cutoff := time.Now().Add(-rateLimitWindow)
+		l.mu.Lock()
+		for id, window := range l.windows {
+			if window.start.Before(cutoff) {
+				delete(l.windows, id)
+			}
+		}
+		l.mu.Unlock()
+	}
+}
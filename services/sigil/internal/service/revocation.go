@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+	"github.com/vivek-344/diagon/sigil/utils"
+)
+
+const revocationSyncInterval = 5 * time.Second
+
+// RevocationList caches recently revoked session IDs so that AuthMiddleware
+// can reject access tokens without a database round trip. Each instance polls
+// the sessions table, so revocations made elsewhere apply within seconds.
+type RevocationList struct {
+	repo     domain.SessionRepository
+	mu       sync.RWMutex
+	revoked  map[uuid.UUID]time.Time
+	lastSync time.Time
+}
+
+func NewRevocationList(repo domain.SessionRepository) *RevocationList {
+	return &RevocationList{
+		repo:    repo,
+		revoked: make(map[uuid.UUID]time.Time),
+		// Anything revoked earlier can no longer have a live access token
+		lastSync: time.Now().Add(-utils.AccessTokenTTL),
+	}
+}
+
+func (l *RevocationList) IsRevoked(sessionID uuid.UUID) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	_, ok := l.revoked[sessionID]
+	return ok
+}
+
+// Add marks sessions revoked locally ahead of the next sync
+func (l *RevocationList) Add(sessionIDs ...uuid.UUID) {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, id := range sessionIDs {
+		l.revoked[id] = now
+	}
+}
+
+// Run syncs the list until ctx is cancelled
+func (l *RevocationList) Run(ctx context.Context) {
+	ticker := time.NewTicker(revocationSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := l.sync(ctx); err != nil {
+			slog.Error("failed to sync session revocations", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (l *RevocationList) sync(ctx context.Context) error {
+	// Overlap the window so revocations committed during the last query are not missed
+	startedAt := time.Now()
+	since := l.lastSync.Add(-revocationSyncInterval)
+
+	ids, err := l.repo.ListRevokedSince(ctx, since)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, id := range ids {
+		if _, ok := l.revoked[id]; !ok {
+			l.revoked[id] = startedAt
+		}
+	}
+
+	// Access tokens outlive their session by at most one TTL
+	cutoff := startedAt.Add(-utils.AccessTokenTTL - revocationSyncInterval)
+	for id, seenAt := range l.revoked {
+		if seenAt.Before(cutoff) {
+			delete(l.revoked, id)
+		}
+	}
+
+	l.lastSync = startedAt
+	return nil
+}
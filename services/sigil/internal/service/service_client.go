@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+	"github.com/vivek-344/diagon/sigil/utils"
+)
+
+const (
+	// clientSecretPrefixLength is how much of a secret is kept in clear: "sgs_" plus 8 characters
+	clientSecretPrefixLength = len(domain.ServiceClientSecretPrefix) + 8
+
+	DefaultSecretOverlap = 24 * time.Hour
+	MaxSecretOverlap     = 7 * 24 * time.Hour
+)
+
+type ServiceClientService struct {
+	repo        domain.ServiceClientRepository
+	projectRepo domain.ProjectRepository
+	projectSvc  *ProjectService
+	jwtManager  *utils.JWTManager
+}
+
+func NewServiceClientService(
+	repo domain.ServiceClientRepository,
+	projectRepo domain.ProjectRepository,
+	projectSvc *ProjectService,
+	jwtManager *utils.JWTManager,
+) *ServiceClientService {
+	return &ServiceClientService{
+		repo:        repo,
+		projectRepo: projectRepo,
+		projectSvc:  projectSvc,
+		jwtManager:  jwtManager,
+	}
+}
+
+// Create registers a service client for the project and returns its secret
+// in clear alongside the stored record. The secret cannot be recovered afterwards.
+// Only trusted callers, superadmins acting with the admin scope, may grant
+// TrustedClientScopes.
+func (s *ServiceClientService) Create(
+	ctx context.Context,
+	developerID uuid.UUID,
+	projectID uuid.UUID,
+	name string,
+	scopes []string,
+	trusted bool,
+) (string, *domain.ServiceClient, error) {
+	slog.Debug("creating service client", "developer_id", developerID, "project_id", projectID)
+
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 255 {
+		return "", nil, domain.ErrInvalidInput
+	}
+	scopes, err := clientScopes(scopes, trusted)
+	if err != nil {
+		return "", nil, err
+	}
+
+	project, err := s.projectSvc.Authorize(ctx, developerID, projectID, true)
+	if err != nil {
+		return "", nil, err
+	}
+	if project.ArchivedAt != nil {
+		return "", nil, domain.ErrProjectArchived
+	}
+
+	clientID, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate client id: %w", err)
+	}
+	rawSecret, secret, err := newClientSecret()
+	if err != nil {
+		return "", nil, err
+	}
+
+	client := &domain.ServiceClient{
+		ProjectID: projectID,
+		Name:      name,
+		ClientID:  domain.ServiceClientIDPrefix + clientID[:24],
+		Scopes:    scopes,
+		CreatedBy: &developerID,
+	}
+	if err := s.repo.Create(ctx, client, secret); err != nil {
+		return "", nil, fmt.Errorf("failed to store service client: %w", err)
+	}
+
+	slog.Info("service client created", "project_id", projectID, "service_client_id", client.ID, "developer_id", developerID)
+	return rawSecret, client, nil
+}
+
+func (s *ServiceClientService) List(ctx context.Context, developerID uuid.UUID, projectID uuid.UUID) ([]*domain.ServiceClient, error) {
+	if _, err := s.projectSvc.Authorize(ctx, developerID, projectID, false); err != nil {
+		return nil, err
+	}
+
+	clients, err := s.repo.ListByProject(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service clients: %w", err)
+	}
+	return clients, nil
+}
+
+// RotateSecret issues a new secret. Existing secrets keep working for the
+// overlap so running servers can be redeployed; zero retires them at once.
+// Tokens minted from a retired secret stay valid until they expire, at most
+// utils.AccessTokenTTL (15 minutes).
+func (s *ServiceClientService) RotateSecret(
+	ctx context.Context,
+	developerID uuid.UUID,
+	projectID uuid.UUID,
+	id uuid.UUID,
+	overlap time.Duration,
+	trusted bool,
+) (string, error) {
+	if overlap < 0 || overlap > MaxSecretOverlap {
+		return "", domain.ErrInvalidInput
+	}
+	if err := s.authorizeClient(ctx, developerID, projectID, id, trusted); err != nil {
+		return "", err
+	}
+
+	rawSecret, secret, err := newClientSecret()
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.repo.RotateSecret(ctx, id, projectID, secret, time.Now().Add(overlap)); err != nil {
+		if err == domain.ErrServiceClientNotFound {
+			return "", err
+		}
+		return "", fmt.Errorf("failed to rotate client secret: %w", err)
+	}
+
+	slog.Info("service client secret rotated", "project_id", projectID, "service_client_id", id, "overlap", overlap, "developer_id", developerID)
+	return rawSecret, nil
+}
+
+// Revoke disables the client. Client tokens are not checked against the
+// database, so tokens already issued stay valid until they expire: at most
+// utils.AccessTokenTTL (15 minutes).
+func (s *ServiceClientService) Revoke(ctx context.Context, developerID uuid.UUID, projectID uuid.UUID, id uuid.UUID, trusted bool) error {
+	if err := s.authorizeClient(ctx, developerID, projectID, id, trusted); err != nil {
+		return err
+	}
+
+	if err := s.repo.Revoke(ctx, id, projectID); err != nil {
+		if err == domain.ErrServiceClientNotFound {
+			return err
+		}
+		return fmt.Errorf("failed to revoke service client: %w", err)
+	}
+
+	slog.Info("service client revoked", "project_id", projectID, "service_client_id", id, "developer_id", developerID)
+	return nil
+}
+
+// IssueToken implements the client_credentials grant. Requested scopes must
+// be a subset of the client's; none requested grants all of them.
+func (s *ServiceClientService) IssueToken(ctx context.Context, clientID string, rawSecret string, requested []string) (string, []string, error) {
+	client, err := s.Authenticate(ctx, clientID, rawSecret)
+	if err != nil {
+		return "", nil, err
+	}
+
+	scopes := client.Scopes
+	if len(requested) > 0 {
+		for _, scope := range requested {
+			if !domain.HasScope(client.Scopes, scope) {
+				return "", nil, domain.ErrInvalidScope
+			}
+		}
+		scopes = requested
+	}
+
+	token, err := s.jwtManager.GenerateClientToken(client.ClientID, client.ProjectID, scopes)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to issue client token: %w", err)
+	}
+
+	if err := s.repo.Touch(ctx, client.ID); err != nil {
+		slog.Warn("failed to record service client usage", "service_client_id", client.ID, "error", err)
+	}
+
+	slog.Debug("client token issued", "service_client_id", client.ID, "project_id", client.ProjectID)
+	return token, scopes, nil
+}
+
+// Authenticate checks a client's credentials. Clients of archived projects
+// are rejected like revoked ones.
+func (s *ServiceClientService) Authenticate(ctx context.Context, clientID string, rawSecret string) (*domain.ServiceClient, error) {
+	if !strings.HasPrefix(clientID, domain.ServiceClientIDPrefix) || !strings.HasPrefix(rawSecret, domain.ServiceClientSecretPrefix) {
+		return nil, domain.ErrInvalidClientCredentials
+	}
+
+	client, err := s.repo.GetByClientID(ctx, clientID)
+	if err != nil {
+		if err == domain.ErrServiceClientNotFound {
+			return nil, domain.ErrInvalidClientCredentials
+		}
+		return nil, fmt.Errorf("failed to fetch service client: %w", err)
+	}
+	if client.RevokedAt != nil {
+		return nil, domain.ErrInvalidClientCredentials
+	}
+
+	matched, err := s.repo.MatchSecret(ctx, client.ID, utils.HashToken(rawSecret))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check client secret: %w", err)
+	}
+	if !matched {
+		return nil, domain.ErrInvalidClientCredentials
+	}
+
+	project, err := s.projectRepo.GetByID(ctx, client.ProjectID)
+	if err != nil {
+		if err == domain.ErrProjectNotFound {
+			return nil, domain.ErrInvalidClientCredentials
+		}
+		return nil, fmt.Errorf("failed to fetch project: %w", err)
+	}
+	if project.ArchivedAt != nil {
+		return nil, domain.ErrInvalidClientCredentials
+	}
+
+	return client, nil
+}
+
+// authorizeClient checks the developer may change the project's client.
+// Clients holding TrustedClientScopes are left to trusted callers, as their
+// secret unlocks every developer's credentials.
+func (s *ServiceClientService) authorizeClient(
+	ctx context.Context,
+	developerID uuid.UUID,
+	projectID uuid.UUID,
+	id uuid.UUID,
+	trusted bool,
+) error {
+	if _, err := s.projectSvc.Authorize(ctx, developerID, projectID, true); err != nil {
+		return err
+	}
+
+	client, err := s.repo.GetByID(ctx, id, projectID)
+	if err != nil {
+		if err == domain.ErrServiceClientNotFound {
+			return err
+		}
+		return fmt.Errorf("failed to fetch service client: %w", err)
+	}
+	if domain.HasTrustedScope(client.Scopes) && !trusted {
+		return domain.ErrForbidden
+	}
+	return nil
+}
+
+func newClientSecret() (string, *domain.ServiceClientSecret, error) {
+	token, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate client secret: %w", err)
+	}
+	rawSecret := domain.ServiceClientSecretPrefix + token
+
+	return rawSecret, &domain.ServiceClientSecret{
+		Prefix:     rawSecret[:clientSecretPrefixLength],
+		SecretHash: utils.HashToken(rawSecret),
+	}, nil
+}
+
+// clientScopes validates requested scopes against the client vocabulary.
+// Clients created without scopes are read-only.
+func clientScopes(requested []string, trusted bool) ([]string, error) {
+	if len(requested) == 0 {
+		return domain.DefaultClientScopes, nil
+	}
+
+	scopes := make([]string, 0, len(requested))
+	for _, scope := range requested {
+		if domain.HasScope(domain.TrustedClientScopes, scope) {
+			if !trusted {
+				return nil, domain.ErrForbidden
+			}
+		} else if !domain.HasScope(domain.ClientScopes, scope) {
+			return nil, domain.ErrInvalidScope
+		}
+		if !domain.HasScope(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
@@ -0,0 +1,386 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+	"github.com/vivek-344/diagon/sigil/utils"
+)
+
+// TokenService answers introspection (RFC 7662) and revocation (RFC 7009)
+// requests for every kind of Sigil credential. Callers are service clients
+// holding token:introspect or token:revoke, which may act on any token, and
+// confidential OAuth clients, which may act only on tokens issued to them.
+type TokenService struct {
+	jwtManager        *utils.JWTManager
+	developerRepo     domain.DeveloperRepository
+	sessionRepo       domain.SessionRepository
+	refreshRepo       domain.RefreshTokenRepository
+	apiKeyRepo        domain.APIKeyRepository
+	serviceClientRepo domain.ServiceClientRepository
+	oauthRepo         domain.OAuthRepository
+	serviceClientSvc  *ServiceClientService
+	oauthSvc          *OAuthService
+	authSvc           *AuthService
+}
+
+func NewTokenService(
+	jwtManager *utils.JWTManager,
+	developerRepo domain.DeveloperRepository,
+	sessionRepo domain.SessionRepository,
+	refreshRepo domain.RefreshTokenRepository,
+	apiKeyRepo domain.APIKeyRepository,
+	serviceClientRepo domain.ServiceClientRepository,
+	oauthRepo domain.OAuthRepository,
+	serviceClientSvc *ServiceClientService,
+	oauthSvc *OAuthService,
+	authSvc *AuthService,
+) *TokenService {
+	return &TokenService{
+		jwtManager:        jwtManager,
+		developerRepo:     developerRepo,
+		sessionRepo:       sessionRepo,
+		refreshRepo:       refreshRepo,
+		apiKeyRepo:        apiKeyRepo,
+		serviceClientRepo: serviceClientRepo,
+		oauthRepo:         oauthRepo,
+		serviceClientSvc:  serviceClientSvc,
+		oauthSvc:          oauthSvc,
+		authSvc:           authSvc,
+	}
+}
+
+// tokenCaller is the authenticated client of an introspection or revocation
+// request; oauthClient is nil for service clients
+type tokenCaller struct {
+	clientID    string
+	oauthClient *domain.OAuthClient
+}
+
+// owns reports whether the caller may see or revoke a token issued to
+// oauthClientID; service clients may act on any token
+func (c *tokenCaller) owns(oauthClientID string) bool {
+	return c.oauthClient == nil || c.oauthClient.ClientID == oauthClientID
+}
+
+// Introspect reports whether a token is active and what it grants. Tokens
+// the caller may not see are reported inactive.
+func (s *TokenService) Introspect(ctx context.Context, clientID string, clientSecret string, rawToken string) (*domain.TokenIntrospection, error) {
+	caller, err := s.authenticate(ctx, clientID, clientSecret, domain.ScopeTokenIntrospect)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := s.inspect(ctx, rawToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.Active || !caller.owns(info.ClientID) || (caller.oauthClient != nil && info.Kind == domain.TokenKindAPIKey) {
+		return &domain.TokenIntrospection{Active: false}, nil
+	}
+
+	slog.Debug("token introspected", "caller", caller.clientID, "kind", info.Kind, "subject", info.Subject)
+	return info, nil
+}
+
+// Revoke invalidates a token. Access and refresh tokens end their whole
+// session; service client tokens are stateless and cannot be revoked.
+// Unknown, expired and already revoked tokens succeed (RFC 7009 2.2).
+func (s *TokenService) Revoke(ctx context.Context, clientID string, clientSecret string, rawToken string) error {
+	caller, err := s.authenticate(ctx, clientID, clientSecret, domain.ScopeTokenRevoke)
+	if err != nil {
+		return err
+	}
+
+	info, err := s.inspect(ctx, rawToken)
+	if err != nil {
+		return err
+	}
+	if !info.Active {
+		return nil
+	}
+	if !caller.owns(info.ClientID) || (caller.oauthClient != nil && info.Kind == domain.TokenKindAPIKey) {
+		return domain.ErrForbidden
+	}
+
+	switch {
+	case info.PrincipalType == domain.PrincipalServiceClient:
+		return domain.ErrUnsupportedTokenType
+	case info.Kind == domain.TokenKindAPIKey:
+		return s.revokeAPIKey(ctx, rawToken)
+	case info.SessionID != nil:
+		developerID, err := uuid.Parse(info.Subject)
+		if err != nil {
+			return fmt.Errorf("failed to parse subject: %w", err)
+		}
+		if err := s.authSvc.RevokeSession(ctx, developerID, *info.SessionID); err != nil && err != domain.ErrSessionNotFound {
+			return err
+		}
+	}
+
+	slog.Info("token revoked", "caller", caller.clientID, "kind", info.Kind, "subject", info.Subject)
+	return nil
+}
+
+// authenticate accepts service clients holding scope and confidential OAuth clients
+func (s *TokenService) authenticate(ctx context.Context, clientID string, clientSecret string, scope string) (*tokenCaller, error) {
+	if strings.HasPrefix(clientID, domain.ServiceClientIDPrefix) {
+		client, err := s.serviceClientSvc.Authenticate(ctx, clientID, clientSecret)
+		if err != nil {
+			return nil, err
+		}
+		if !domain.HasScope(client.Scopes, scope) {
+			return nil, domain.ErrForbidden
+		}
+		trusted, err := s.trustedByCreator(ctx, client)
+		if err != nil {
+			return nil, err
+		}
+		if !trusted {
+			return nil, domain.ErrForbidden
+		}
+		return &tokenCaller{clientID: client.ClientID}, nil
+	}
+
+	client, err := s.oauthSvc.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if !client.Confidential() {
+		return nil, domain.ErrInvalidClientCredentials
+	}
+	return &tokenCaller{clientID: client.ClientID, oauthClient: client}, nil
+}
+
+// trustedByCreator reports whether the developer who granted the client its
+// trusted scopes may still do so; the scopes lapse once they are demoted,
+// suspended or deleted
+func (s *TokenService) trustedByCreator(ctx context.Context, client *domain.ServiceClient) (bool, error) {
+	if client.CreatedBy == nil {
+		return false, nil
+	}
+
+	dev, err := s.developerRepo.GetByID(ctx, *client.CreatedBy)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to fetch client creator: %w", err)
+	}
+	if dev.Status == domain.StatusSuspended || dev.Status == domain.StatusDeleted {
+		return false, nil
+	}
+	return dev.Role.CanTrustClients(), nil
+}
+
+// inspect describes a token of any kind, recognised by its format
+func (s *TokenService) inspect(ctx context.Context, rawToken string) (*domain.TokenIntrospection, error) {
+	switch tokenKind(rawToken) {
+	case domain.TokenKindAPIKey:
+		return s.introspectAPIKey(ctx, rawToken)
+	case domain.TokenKindAccess:
+		return s.introspectAccessToken(ctx, rawToken)
+	}
+	return s.introspectRefreshToken(ctx, rawToken)
+}
+
+func (s *TokenService) introspectAccessToken(ctx context.Context, rawToken string) (*domain.TokenIntrospection, error) {
+	claims, err := s.jwtManager.ValidateAccessToken(rawToken)
+	if err != nil {
+		return &domain.TokenIntrospection{Active: false}, nil
+	}
+
+	info := &domain.TokenIntrospection{
+		Active:    true,
+		Kind:      domain.TokenKindAccess,
+		Subject:   claims.Subject,
+		ClientID:  claims.ClientID,
+		Scopes:    claims.Scopes(),
+		OrgID:     claims.OrgID,
+		ProjectID: claims.ProjectID,
+		ExpiresAt: &claims.ExpiresAt.Time,
+		IssuedAt:  &claims.IssuedAt.Time,
+	}
+
+	if claims.TokenType == utils.TokenTypeClient {
+		client, err := s.serviceClientRepo.GetByClientID(ctx, claims.ClientID)
+		if err != nil {
+			if err == domain.ErrServiceClientNotFound {
+				return &domain.TokenIntrospection{Active: false}, nil
+			}
+			return nil, fmt.Errorf("failed to fetch service client: %w", err)
+		}
+		if client.RevokedAt != nil {
+			return &domain.TokenIntrospection{Active: false}, nil
+		}
+		info.PrincipalType = domain.PrincipalServiceClient
+		return info, nil
+	}
+
+	session, err := s.sessionRepo.GetByID(ctx, claims.SessionID)
+	if err != nil {
+		if err == domain.ErrSessionNotFound {
+			return &domain.TokenIntrospection{Active: false}, nil
+		}
+		return nil, fmt.Errorf("failed to fetch session: %w", err)
+	}
+	if session.RevokedAt != nil {
+		return &domain.TokenIntrospection{Active: false}, nil
+	}
+
+	dev, err := s.activeDeveloper(ctx, claims.DeveloperID)
+	if err != nil {
+		return nil, err
+	}
+	if dev == nil {
+		return &domain.TokenIntrospection{Active: false}, nil
+	}
+
+	info.PrincipalType = domain.PrincipalDeveloper
+	info.Username = dev.Email
+	info.SessionID = &session.ID
+	return info, nil
+}
+
+func (s *TokenService) introspectRefreshToken(ctx context.Context, rawToken string) (*domain.TokenIntrospection, error) {
+	token, err := s.refreshRepo.GetByHash(ctx, utils.HashToken(rawToken))
+	if err != nil {
+		if err == domain.ErrInvalidRefreshToken {
+			return &domain.TokenIntrospection{Active: false}, nil
+		}
+		return nil, fmt.Errorf("failed to fetch refresh token: %w", err)
+	}
+	if token.RevokedAt != nil || token.ConsumedAt != nil || time.Now().After(token.ExpiresAt) {
+		return &domain.TokenIntrospection{Active: false}, nil
+	}
+
+	session, err := s.sessionRepo.GetByID(ctx, token.SessionID)
+	if err != nil {
+		if err == domain.ErrSessionNotFound {
+			return &domain.TokenIntrospection{Active: false}, nil
+		}
+		return nil, fmt.Errorf("failed to fetch session: %w", err)
+	}
+	if session.RevokedAt != nil {
+		return &domain.TokenIntrospection{Active: false}, nil
+	}
+
+	dev, err := s.activeDeveloper(ctx, token.DeveloperID)
+	if err != nil {
+		return nil, err
+	}
+	if dev == nil {
+		return &domain.TokenIntrospection{Active: false}, nil
+	}
+
+	info := &domain.TokenIntrospection{
+		Active:        true,
+		Kind:          domain.TokenKindRefresh,
+		PrincipalType: domain.PrincipalDeveloper,
+		Subject:       dev.ID.String(),
+		Username:      dev.Email,
+		Scopes:        sessionScopes(dev, session),
+		SessionID:     &session.ID,
+		OrgID:         session.OrganizationID,
+		ExpiresAt:     &token.ExpiresAt,
+		IssuedAt:      &token.CreatedAt,
+	}
+	if session.OAuthClientID != nil {
+		client, err := s.oauthRepo.GetClientByID(ctx, *session.OAuthClientID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch oauth client: %w", err)
+		}
+		info.ClientID = client.ClientID
+	}
+	return info, nil
+}
+
+func (s *TokenService) introspectAPIKey(ctx context.Context, rawKey string) (*domain.TokenIntrospection, error) {
+	key, err := s.apiKeyRepo.GetByHash(ctx, utils.HashToken(rawKey))
+	if err != nil {
+		if err == domain.ErrAPIKeyNotFound {
+			return &domain.TokenIntrospection{Active: false}, nil
+		}
+		return nil, fmt.Errorf("failed to fetch api key: %w", err)
+	}
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt)) {
+		return &domain.TokenIntrospection{Active: false}, nil
+	}
+
+	dev, err := s.activeDeveloper(ctx, key.DeveloperID)
+	if err != nil {
+		return nil, err
+	}
+	if dev == nil {
+		return &domain.TokenIntrospection{Active: false}, nil
+	}
+
+	// As in AuthenticateAPIKey, a key never carries more than its owner holds
+	scopes := make([]string, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		if domain.HasScope(domain.GrantedScopes(dev), scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return &domain.TokenIntrospection{
+		Active:        true,
+		Kind:          domain.TokenKindAPIKey,
+		PrincipalType: domain.PrincipalDeveloper,
+		Subject:       dev.ID.String(),
+		Username:      dev.Email,
+		Scopes:        scopes,
+		ExpiresAt:     key.ExpiresAt,
+		IssuedAt:      &key.CreatedAt,
+	}, nil
+}
+
+func (s *TokenService) revokeAPIKey(ctx context.Context, rawKey string) error {
+	key, err := s.apiKeyRepo.GetByHash(ctx, utils.HashToken(rawKey))
+	if err != nil {
+		if err == domain.ErrAPIKeyNotFound {
+			return nil
+		}
+		return fmt.Errorf("failed to fetch api key: %w", err)
+	}
+	if err := s.apiKeyRepo.Revoke(ctx, key.ID, key.DeveloperID); err != nil && err != domain.ErrAPIKeyNotFound {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	slog.Info("api key revoked", "developer_id", key.DeveloperID, "api_key_id", key.ID)
+	return nil
+}
+
+// activeDeveloper returns nil for developers that were removed or suspended
+func (s *TokenService) activeDeveloper(ctx context.Context, id uuid.UUID) (*domain.Developer, error) {
+	dev, err := s.developerRepo.GetByID(ctx, id)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to fetch developer: %w", err)
+	}
+	if dev.Status == domain.StatusSuspended {
+		return nil, nil
+	}
+	return dev, nil
+}
+
+// tokenKind tells credentials apart by format: API keys carry their prefix,
+// access tokens are JWTs, anything else may be a refresh token
+func tokenKind(rawToken string) string {
+	switch {
+	case strings.HasPrefix(rawToken, domain.APIKeyPrefix):
+		return domain.TokenKindAPIKey
+	case strings.Count(rawToken, ".") == 2:
+		return domain.TokenKindAccess
+	}
+	return domain.TokenKindRefresh
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+	"github.com/vivek-344/diagon/sigil/internal/mailer"
+	"github.com/vivek-344/diagon/sigil/utils"
+)
+
+const (
+	verificationTokenTTL = 24 * time.Hour
+	verificationCooldown = time.Minute
+)
+
+type VerificationService struct {
+	developerRepo domain.DeveloperRepository
+	tokenRepo     domain.OneTimeTokenRepository
+	mailer        mailer.Mailer
+	appBaseURL    string
+}
+
+func NewVerificationService(
+	developerRepo domain.DeveloperRepository,
+	tokenRepo domain.OneTimeTokenRepository,
+	mailer mailer.Mailer,
+	appBaseURL string,
+) *VerificationService {
+	return &VerificationService{
+		developerRepo: developerRepo,
+		tokenRepo:     tokenRepo,
+		mailer:        mailer,
+		appBaseURL:    appBaseURL,
+	}
+}
+
+// SendVerification issues a fresh verification token, invalidating older ones, and mails it
+func (s *VerificationService) SendVerification(ctx context.Context, dev *domain.Developer) error {
+	slog.Debug("sending verification email", "developer_id", dev.ID)
+
+	if err := s.tokenRepo.InvalidateAll(ctx, dev.ID, domain.PurposeEmailVerification); err != nil {
+		return fmt.Errorf("failed to invalidate verification tokens: %w", err)
+	}
+
+	rawToken, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate verification token: %w", err)
+	}
+
+	err = s.tokenRepo.Create(ctx, &domain.OneTimeToken{
+		DeveloperID: dev.ID,
+		Purpose:     domain.PurposeEmailVerification,
+		TokenHash:   utils.HashToken(rawToken),
+		ExpiresAt:   time.Now().Add(verificationTokenTTL),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store verification token: %w", err)
+	}
+
+	link := s.appBaseURL + "/verify-email?token=" + url.QueryEscape(rawToken)
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      dev.Email,
+		Subject: "Verify your DIAGON email address",
+		Body: "Welcome to DIAGON!\n\n" +
+			"Confirm your email address by opening the link below:\n\n" +
+			link + "\n\n" +
+			"The link expires in 24 hours. If you did not create an account, you can ignore this email.",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send verification email: %w", err)
+	}
+
+	slog.Info("verification email sent", "developer_id", dev.ID)
+	return nil
+}
+
+// Resend mails a new verification link unless one was sent within the cooldown
+func (s *VerificationService) Resend(ctx context.Context, developerID uuid.UUID) error {
+	dev, err := s.developerRepo.GetByID(ctx, developerID)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return err
+		}
+		return fmt.Errorf("failed to fetch developer: %w", err)
+	}
+	if dev.EmailVerified {
+		return domain.ErrEmailAlreadyVerified
+	}
+
+	lastSent, err := s.tokenRepo.LatestCreatedAt(ctx, developerID, domain.PurposeEmailVerification)
+	if err != nil {
+		return fmt.Errorf("failed to check verification cooldown: %w", err)
+	}
+	if lastSent != nil && time.Since(*lastSent) < verificationCooldown {
+		return domain.ErrTooManyRequests
+	}
+
+	return s.SendVerification(ctx, dev)
+}
+
+// Verify consumes a verification token and marks the developer's email verified
+func (s *VerificationService) Verify(ctx context.Context, rawToken string) error {
+	token, err := s.tokenRepo.Consume(ctx, utils.HashToken(rawToken), domain.PurposeEmailVerification)
+	if err != nil {
+		if err == domain.ErrInvalidOneTimeToken {
+			return err
+		}
+		return fmt.Errorf("failed to consume verification token: %w", err)
+	}
+
+	if err := s.developerRepo.VerifyEmail(ctx, token.DeveloperID); err != nil {
+		if err == domain.ErrNotFound {
+			return err
+		}
+		return fmt.Errorf("verification failed: %w", err)
+	}
+
+	slog.Info("developer email verified", "developer_id", token.DeveloperID)
+	return nil
+}
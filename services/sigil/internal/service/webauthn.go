@@ -0,0 +1,358 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+
+	"github.com/vivek-344/diagon/sigil/internal/domain"
+	"github.com/vivek-344/diagon/sigil/utils"
+)
+
+const webAuthnChallengeTTL = 5 * time.Minute
+
+type WebAuthnService struct {
+	webAuthn      *webauthn.WebAuthn
+	repo          domain.WebAuthnRepository
+	developerRepo domain.DeveloperRepository
+	authSvc       *AuthService
+
+	// Keys the decoy credentials offered for unknown emails
+	decoyKey []byte
+}
+
+func NewWebAuthnService(
+	webAuthn *webauthn.WebAuthn,
+	repo domain.WebAuthnRepository,
+	developerRepo domain.DeveloperRepository,
+	authSvc *AuthService,
+) *WebAuthnService {
+	decoyKey := make([]byte, 32)
+	rand.Read(decoyKey)
+
+	return &WebAuthnService{
+		webAuthn:      webAuthn,
+		repo:          repo,
+		developerRepo: developerRepo,
+		authSvc:       authSvc,
+		decoyKey:      decoyKey,
+	}
+}
+
+// webAuthnUser adapts a developer and their credentials to webauthn.User.
+// The user handle is the developer's UUID.
+type webAuthnUser struct {
+	dev         *domain.Developer
+	credentials []webauthn.Credential
+}
+
+func (u *webAuthnUser) WebAuthnID() []byte {
+	return u.dev.ID[:]
+}
+
+func (u *webAuthnUser) WebAuthnName() string {
+	return u.dev.Email
+}
+
+func (u *webAuthnUser) WebAuthnDisplayName() string {
+	if u.dev.FullName != nil && *u.dev.FullName != "" {
+		return *u.dev.FullName
+	}
+	return u.dev.Email
+}
+
+func (u *webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	return u.credentials
+}
+
+func (u *webAuthnUser) WebAuthnIcon() string {
+	return ""
+}
+
+// BeginRegistration starts adding an authenticator to the developer's account
+func (s *WebAuthnService) BeginRegistration(ctx context.Context, developerID uuid.UUID) (uuid.UUID, *protocol.CredentialCreation, error) {
+	user, err := s.loadUser(ctx, developerID)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(user.credentials))
+	for _, credential := range user.credentials {
+		exclusions = append(exclusions, credential.Descriptor())
+	}
+
+	creation, session, err := s.webAuthn.BeginRegistration(user,
+		webauthn.WithExclusions(exclusions),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementPreferred),
+	)
+	if err != nil {
+		return uuid.Nil, nil, fmt.Errorf("failed to begin registration: %w", err)
+	}
+
+	challengeID, err := s.saveChallenge(ctx, &developerID, domain.CeremonyRegistration, session)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	return challengeID, creation, nil
+}
+
+// FinishRegistration verifies the attestation and stores the new credential
+func (s *WebAuthnService) FinishRegistration(
+	ctx context.Context,
+	developerID uuid.UUID,
+	challengeID uuid.UUID,
+	name string,
+	response *protocol.ParsedCredentialCreationData,
+) (*domain.WebAuthnCredential, error) {
+	session, challenge, err := s.consumeChallenge(ctx, challengeID, domain.CeremonyRegistration)
+	if err != nil {
+		return nil, err
+	}
+	if challenge.DeveloperID == nil || *challenge.DeveloperID != developerID {
+		return nil, domain.ErrChallengeNotFound
+	}
+
+	user, err := s.loadUser(ctx, developerID)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := s.webAuthn.CreateCredential(user, *session, response)
+	if err != nil {
+		slog.Debug("webauthn registration rejected", "developer_id", developerID, "error", err)
+		return nil, domain.ErrWebAuthnFailed
+	}
+
+	data, err := json.Marshal(credential)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode credential: %w", err)
+	}
+
+	if name == "" {
+		name = "Passkey"
+	}
+	stored := &domain.WebAuthnCredential{
+		DeveloperID:  developerID,
+		Name:         name,
+		CredentialID: credential.ID,
+		Data:         data,
+	}
+	if err := s.repo.CreateCredential(ctx, stored); err != nil {
+		if err == domain.ErrCredentialExists {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to store credential: %w", err)
+	}
+
+	slog.Info("webauthn credential registered", "developer_id", developerID, "credential_id", stored.ID)
+	return stored, nil
+}
+
+// BeginLogin starts an assertion. Without an email the browser offers any
+// discoverable passkey for this relying party. Unknown emails and accounts
+// without passkeys get a decoy challenge, so the response does not reveal
+// which accounts exist; finishing it always fails.
+func (s *WebAuthnService) BeginLogin(ctx context.Context, email string) (uuid.UUID, *protocol.CredentialAssertion, error) {
+	var (
+		assertion *protocol.CredentialAssertion
+		session   *webauthn.SessionData
+		err       error
+	)
+
+	if email == "" {
+		assertion, session, err = s.webAuthn.BeginDiscoverableLogin()
+	} else {
+		var user *webAuthnUser
+		dev, lookupErr := s.developerRepo.GetByEmail(ctx, email)
+		switch {
+		case lookupErr == domain.ErrNotFound:
+			user = s.decoyUser(email)
+		case lookupErr != nil:
+			return uuid.Nil, nil, fmt.Errorf("failed to fetch developer: %w", lookupErr)
+		default:
+			if user, err = s.loadUser(ctx, dev.ID); err != nil {
+				return uuid.Nil, nil, err
+			}
+			if len(user.credentials) == 0 {
+				user = s.decoyUser(email)
+			}
+		}
+		assertion, session, err = s.webAuthn.BeginLogin(user)
+	}
+	if err != nil {
+		return uuid.Nil, nil, fmt.Errorf("failed to begin login: %w", err)
+	}
+
+	challengeID, err := s.saveChallenge(ctx, nil, domain.CeremonyLogin, session)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	return challengeID, assertion, nil
+}
+
+// FinishLogin verifies the assertion and issues tokens through the same path as password login
+func (s *WebAuthnService) FinishLogin(
+	ctx context.Context,
+	challengeID uuid.UUID,
+	response *protocol.ParsedCredentialAssertionData,
+	client domain.ClientInfo,
+) (*domain.Developer, *utils.TokenPair, error) {
+	session, _, err := s.consumeChallenge(ctx, challengeID, domain.CeremonyLogin)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stored, err := s.repo.GetCredential(ctx, response.RawID)
+	if err != nil {
+		if err == domain.ErrCredentialNotFound {
+			return nil, nil, domain.ErrWebAuthnFailed
+		}
+		return nil, nil, fmt.Errorf("failed to fetch credential: %w", err)
+	}
+
+	user, err := s.loadUser(ctx, stored.DeveloperID)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return nil, nil, domain.ErrWebAuthnFailed
+		}
+		return nil, nil, err
+	}
+	if user.dev.Status == domain.StatusSuspended {
+		return nil, nil, domain.ErrAccountSuspended
+	}
+
+	var credential *webauthn.Credential
+	if session.UserID == nil {
+		credential, err = s.webAuthn.ValidateDiscoverableLogin(func(rawID, userHandle []byte) (webauthn.User, error) {
+			if string(userHandle) != string(user.WebAuthnID()) {
+				return nil, domain.ErrCredentialNotFound
+			}
+			return user, nil
+		}, *session, response)
+	} else {
+		credential, err = s.webAuthn.ValidateLogin(user, *session, response)
+	}
+	if err != nil {
+		slog.Debug("webauthn assertion rejected", "developer_id", user.dev.ID, "error", err)
+		return nil, nil, domain.ErrWebAuthnFailed
+	}
+
+	if credential.Authenticator.CloneWarning {
+		slog.Warn("webauthn sign count regression, possible cloned authenticator",
+			"developer_id", user.dev.ID, "credential_id", stored.ID)
+		return nil, nil, domain.ErrCredentialCloned
+	}
+
+	data, err := json.Marshal(credential)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode credential: %w", err)
+	}
+	if err := s.repo.UpdateCredentialData(ctx, stored.ID, data); err != nil {
+		return nil, nil, fmt.Errorf("failed to update credential: %w", err)
+	}
+
+	tokens, err := s.authSvc.IssueTokens(ctx, user.dev, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user.dev, tokens, nil
+}
+
+func (s *WebAuthnService) ListCredentials(ctx context.Context, developerID uuid.UUID) ([]*domain.WebAuthnCredential, error) {
+	credentials, err := s.repo.ListCredentials(ctx, developerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list credentials: %w", err)
+	}
+	return credentials, nil
+}
+
+func (s *WebAuthnService) DeleteCredential(ctx context.Context, developerID uuid.UUID, id uuid.UUID) error {
+	if err := s.repo.DeleteCredential(ctx, id, developerID); err != nil {
+		if err == domain.ErrCredentialNotFound {
+			return err
+		}
+		return fmt.Errorf("failed to delete credential: %w", err)
+	}
+	slog.Info("webauthn credential removed", "developer_id", developerID, "credential_id", id)
+	return nil
+}
+
+func (s *WebAuthnService) loadUser(ctx context.Context, developerID uuid.UUID) (*webAuthnUser, error) {
+	dev, err := s.developerRepo.GetByID(ctx, developerID)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to fetch developer: %w", err)
+	}
+
+	stored, err := s.repo.ListCredentials(ctx, developerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list credentials: %w", err)
+	}
+
+	user := &webAuthnUser{dev: dev, credentials: make([]webauthn.Credential, 0, len(stored))}
+	for _, c := range stored {
+		var credential webauthn.Credential
+		if err := json.Unmarshal(c.Data, &credential); err != nil {
+			return nil, fmt.Errorf("failed to decode credential %s: %w", c.ID, err)
+		}
+		user.credentials = append(user.credentials, credential)
+	}
+	return user, nil
+}
+
+// decoyUser stands in for an unknown email with one credential ID derived
+// from the email, so repeated requests offer the same credential
+func (s *WebAuthnService) decoyUser(email string) *webAuthnUser {
+	mac := hmac.New(sha256.New, s.decoyKey)
+	mac.Write([]byte(email))
+
+	return &webAuthnUser{
+		dev:         &domain.Developer{ID: uuid.New(), Email: email},
+		credentials: []webauthn.Credential{{ID: mac.Sum(nil)}},
+	}
+}
+
+func (s *WebAuthnService) saveChallenge(ctx context.Context, developerID *uuid.UUID, ceremony string, session *webauthn.SessionData) (uuid.UUID, error) {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to encode webauthn session: %w", err)
+	}
+
+	challenge := &domain.WebAuthnChallenge{
+		DeveloperID: developerID,
+		Ceremony:    ceremony,
+		SessionData: data,
+		ExpiresAt:   time.Now().Add(webAuthnChallengeTTL),
+	}
+	if err := s.repo.SaveChallenge(ctx, challenge); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to save webauthn challenge: %w", err)
+	}
+	return challenge.ID, nil
+}
+
+func (s *WebAuthnService) consumeChallenge(ctx context.Context, id uuid.UUID, ceremony string) (*webauthn.SessionData, *domain.WebAuthnChallenge, error) {
+	challenge, err := s.repo.ConsumeChallenge(ctx, id, ceremony)
+	if err != nil {
+		if err == domain.ErrChallengeNotFound {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("failed to consume webauthn challenge: %w", err)
+	}
+
+	var session webauthn.SessionData
+	if err := json.Unmarshal(challenge.SessionData, &session); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode webauthn session: %w", err)
+	}
+	return &session, challenge, nil
+}
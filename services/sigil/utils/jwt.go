@@ -1,7 +1,13 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -9,66 +15,230 @@ import (
 )
 
 var (
-	ErrInvalidToken = errors.New("invalid token")
-	ErrExpiredToken = errors.New("token has expired")
+	ErrInvalidToken   = errors.New("invalid token")
+	ErrExpiredToken   = errors.New("token has expired")
+	ErrWrongTokenType = errors.New("wrong token type")
+)
+
+type TokenType string
+
+const (
+	TokenTypeAccess TokenType = "access"
+	TokenTypeMFA    TokenType = "mfa"
+	TokenTypeClient TokenType = "client" // issued to service clients by the client_credentials grant
 )
 
 type JWTClaims struct {
-	DeveloperID uuid.UUID `json:"developer_id"`
-	Email       string    `json:"email"`
+	DeveloperID uuid.UUID  `json:"developer_id"`
+	Email       string     `json:"email"`
+	Role        string     `json:"role,omitempty"`
+	SessionID   uuid.UUID  `json:"sid,omitempty"`
+	TokenType   TokenType  `json:"token_type"`
+	Scope       string     `json:"scope,omitempty"` // space delimited, as in RFC 8693
+	OrgID       *uuid.UUID `json:"org_id,omitempty"`
+	OrgRole     string     `json:"org_role,omitempty"`
+	ClientID    string     `json:"client_id,omitempty"` // RFC 9068; the subject of client tokens, the OAuth client of delegated ones
+	ProjectID   *uuid.UUID `json:"project_id,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// OrgContext is the organization an access token acts within
+type OrgContext struct {
+	ID   uuid.UUID
+	Role string
+}
+
+// Scopes splits the scope claim
+func (c *JWTClaims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// IDTokenClaims is an OpenID Connect ID token (OIDC Core 2); the issuer is
+// the OIDC issuer URL and the audience the OAuth client
+type IDTokenClaims struct {
+	Email         string    `json:"email,omitempty"`
+	EmailVerified *bool     `json:"email_verified,omitempty"`
+	Name          string    `json:"name,omitempty"`
+	Nonce         string    `json:"nonce,omitempty"`
+	SessionID     uuid.UUID `json:"sid"`
 	jwt.RegisteredClaims
 }
 
 type TokenPair struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
+	IDToken      string `json:"id_token,omitempty"` // only for OAuth sessions with the openid scope
 }
 
-// GenerateTokenPair creates both access and refresh tokens
-func GenerateTokenPair(developerID uuid.UUID, email string, jwtSecret string) (*TokenPair, error) {
-	// Access token - expires in 15 minutes
-	accessToken, err := generateToken(developerID, email, jwtSecret, 15*time.Minute)
-	if err != nil {
-		return nil, err
+const (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 7 * 24 * time.Hour
+	MFATokenTTL     = 5 * time.Minute
+	IDTokenTTL      = 15 * time.Minute
+)
+
+// JWTManager signs and validates Sigil tokens for a single issuer/audience.
+// ID tokens are signed for oidcIssuer, which OIDC requires to be a URL.
+type JWTManager struct {
+	keys       *KeySet
+	issuer     string
+	audience   string
+	oidcIssuer string
+}
+
+func NewJWTManager(keys *KeySet, issuer string, audience string, oidcIssuer string) *JWTManager {
+	return &JWTManager{
+		keys:       keys,
+		issuer:     issuer,
+		audience:   audience,
+		oidcIssuer: oidcIssuer,
 	}
+}
 
-	// Refresh token - expires in 7 days
-	refreshToken, err := generateToken(developerID, email, jwtSecret, 7*24*time.Hour)
-	if err != nil {
-		return nil, err
+// OIDCIssuer is the issuer URL published in the OpenID Connect discovery document
+func (m *JWTManager) OIDCIssuer() string {
+	return m.oidcIssuer
+}
+
+// SigningAlgorithm is the JWS algorithm of the active key
+func (m *JWTManager) SigningAlgorithm() string {
+	return m.keys.Active().Method.Alg()
+}
+
+// JWKS returns the public keys downstream services verify tokens with
+func (m *JWTManager) JWKS() JWKS {
+	return m.keys.JWKS()
+}
+
+// GenerateAccessToken creates a short-lived signed access token bound to a
+// session; org is nil when the session has no active organization
+func (m *JWTManager) GenerateAccessToken(developerID uuid.UUID, email string, role string, sessionID uuid.UUID, scopes []string, org *OrgContext) (string, error) {
+	return m.generateToken(accessClaims(developerID, email, role, sessionID, scopes, org), AccessTokenTTL)
+}
+
+// GenerateDelegatedAccessToken creates an access token for a session a
+// third-party OAuth client was authorized for; it names the client in client_id
+func (m *JWTManager) GenerateDelegatedAccessToken(developerID uuid.UUID, email string, role string, sessionID uuid.UUID, clientID string, scopes []string, org *OrgContext) (string, error) {
+	claims := accessClaims(developerID, email, role, sessionID, scopes, org)
+	claims.ClientID = clientID
+	return m.generateToken(claims, AccessTokenTTL)
+}
+
+func accessClaims(developerID uuid.UUID, email string, role string, sessionID uuid.UUID, scopes []string, org *OrgContext) JWTClaims {
+	claims := JWTClaims{
+		DeveloperID: developerID,
+		Email:       email,
+		Role:        role,
+		SessionID:   sessionID,
+		TokenType:   TokenTypeAccess,
+		Scope:       strings.Join(scopes, " "),
+	}
+	if org != nil {
+		claims.OrgID = &org.ID
+		claims.OrgRole = org.Role
+	}
+	return claims
+}
+
+// GenerateClientToken creates a short-lived access token for a project's
+// service client; its subject is the client ID
+func (m *JWTManager) GenerateClientToken(clientID string, projectID uuid.UUID, scopes []string) (string, error) {
+	return m.generateToken(JWTClaims{
+		ClientID:  clientID,
+		ProjectID: &projectID,
+		TokenType: TokenTypeClient,
+		Scope:     strings.Join(scopes, " "),
+	}, AccessTokenTTL)
+}
+
+// GenerateIDToken creates an OpenID Connect ID token for the developer,
+// addressed to the OAuth client that requested it
+func (m *JWTManager) GenerateIDToken(developerID uuid.UUID, clientID string, claims IDTokenClaims) (string, error) {
+	now := time.Now()
+	id := claims.ID
+	if id == "" {
+		id = uuid.NewString()
 	}
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        id,
+		Issuer:    m.oidcIssuer,
+		Subject:   developerID.String(),
+		Audience:  jwt.ClaimStrings{clientID},
+		ExpiresAt: jwt.NewNumericDate(now.Add(IDTokenTTL)),
+		IssuedAt:  jwt.NewNumericDate(now),
+	}
+	return m.sign(claims)
+}
 
-	return &TokenPair{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-	}, nil
+// ValidateAccessToken validates a bearer token presented to protected routes.
+// Check TokenType to tell developer tokens from service client tokens.
+func (m *JWTManager) ValidateAccessToken(tokenString string) (*JWTClaims, error) {
+	return m.validateToken(tokenString, TokenTypeAccess, TokenTypeClient)
 }
 
-// generateToken creates a JWT token with the given expiry
-func generateToken(developerID uuid.UUID, email string, jwtSecret string, expiry time.Duration) (string, error) {
+// GenerateMFAToken creates the challenge token returned after a correct
+// password when the developer still has to present a second factor. The
+// challenge ID becomes the jti so the server can track its use.
+func (m *JWTManager) GenerateMFAToken(developerID uuid.UUID, email string, challengeID uuid.UUID) (string, error) {
 	claims := JWTClaims{
 		DeveloperID: developerID,
 		Email:       email,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiry)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-		},
+		TokenType:   TokenTypeMFA,
 	}
+	claims.ID = challengeID.String()
+	return m.generateToken(claims, MFATokenTTL)
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(jwtSecret))
+// ValidateMFAToken validates a challenge token presented at /auth/mfa/verify
+func (m *JWTManager) ValidateMFAToken(tokenString string) (*JWTClaims, error) {
+	return m.validateToken(tokenString, TokenTypeMFA)
 }
 
-// ValidateToken validates and parses a JWT token
-func ValidateToken(tokenString string, jwtSecret string) (*JWTClaims, error) {
+// generateToken fills in the registered claims and signs with the active key
+func (m *JWTManager) generateToken(claims JWTClaims, expiry time.Duration) (string, error) {
+	now := time.Now()
+	subject := claims.DeveloperID.String()
+	if claims.TokenType == TokenTypeClient {
+		subject = claims.ClientID
+	}
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        uuid.NewString(),
+		Issuer:    m.issuer,
+		Subject:   subject,
+		Audience:  jwt.ClaimStrings{m.audience},
+		ExpiresAt: jwt.NewNumericDate(now.Add(expiry)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+	}
+	return m.sign(claims)
+}
+
+// sign signs claims with the active key, naming it in the kid header
+func (m *JWTManager) sign(claims jwt.Claims) (string, error) {
+	key := m.keys.Active()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
+
+// validateToken validates and parses a JWT token, enforcing issuer, audience and type
+func (m *JWTManager) validateToken(tokenString string, expected ...TokenType) (*JWTClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// Verify signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := m.keys.Lookup(kid)
+		if !ok {
 			return nil, ErrInvalidToken
 		}
-		return []byte(jwtSecret), nil
-	})
+		// Verify signing method matches the key
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, ErrInvalidToken
+		}
+		return key.Public, nil
+	},
+		jwt.WithIssuer(m.issuer),
+		jwt.WithAudience(m.audience),
+		jwt.WithExpirationRequired(),
+	)
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
@@ -82,5 +252,24 @@ func ValidateToken(tokenString string, jwtSecret string) (*JWTClaims, error) {
 		return nil, ErrInvalidToken
 	}
 
+	if !slices.Contains(expected, claims.TokenType) {
+		return nil, ErrWrongTokenType
+	}
+
 	return claims, nil
 }
+
+// GenerateOpaqueToken creates a random URL-safe token suitable for refresh tokens
+func GenerateOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns the hex-encoded SHA-256 of an opaque token for storage
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,300 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrNoSigningKey = errors.New("no signing key configured")
+
+// SigningKey is a single JWT key. Keys without a private half can only verify,
+// which is how retired keys stay trusted until their tokens expire.
+type SigningKey struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private any
+	Public  any
+}
+
+func (k *SigningKey) canSign() bool {
+	return k.Private != nil
+}
+
+// KeySet holds every key Sigil trusts plus the one it currently signs with
+type KeySet struct {
+	active *SigningKey
+	keys   map[string]*SigningKey
+	order  []*SigningKey
+}
+
+// NewHMACKeySet builds a single-key set from a shared secret
+func NewHMACKeySet(secret string) *KeySet {
+	key := &SigningKey{
+		ID:      "hs256",
+		Method:  jwt.SigningMethodHS256,
+		Private: []byte(secret),
+		Public:  []byte(secret),
+	}
+	return &KeySet{
+		active: key,
+		keys:   map[string]*SigningKey{key.ID: key},
+		order:  []*SigningKey{key},
+	}
+}
+
+// ParseKeySet loads RSA and Ed25519 keys from concatenated PEM blocks. The
+// active key is activeKeyID if given, otherwise the first private key.
+func ParseKeySet(pemData []byte, activeKeyID string) (*KeySet, error) {
+	ks := &KeySet{keys: make(map[string]*SigningKey)}
+
+	for {
+		var block *pem.Block
+		block, pemData = pem.Decode(pemData)
+		if block == nil {
+			break
+		}
+
+		key, err := parsePEMBlock(block)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := ks.keys[key.ID]; exists {
+			// A private key supersedes its own public half
+			if !key.canSign() {
+				continue
+			}
+			ks.removeKey(key.ID)
+		}
+		ks.keys[key.ID] = key
+		ks.order = append(ks.order, key)
+	}
+
+	if len(ks.order) == 0 {
+		return nil, errors.New("no PEM keys found")
+	}
+
+	for _, key := range ks.order {
+		if !key.canSign() {
+			continue
+		}
+		if activeKeyID == "" || key.ID == activeKeyID {
+			ks.active = key
+			break
+		}
+	}
+	if ks.active == nil {
+		if activeKeyID != "" {
+			return nil, fmt.Errorf("active key %q not found among private keys", activeKeyID)
+		}
+		return nil, ErrNoSigningKey
+	}
+
+	return ks, nil
+}
+
+func (ks *KeySet) removeKey(id string) {
+	delete(ks.keys, id)
+	for i, key := range ks.order {
+		if key.ID == id {
+			ks.order = append(ks.order[:i], ks.order[i+1:]...)
+			return
+		}
+	}
+}
+
+// Active returns the key new tokens are signed with
+func (ks *KeySet) Active() *SigningKey {
+	return ks.active
+}
+
+// Lookup resolves the verification key for a token header
+func (ks *KeySet) Lookup(kid string) (*SigningKey, bool) {
+	if kid == "" {
+		// Tokens signed before kids were introduced
+		if len(ks.order) == 1 {
+			return ks.order[0], true
+		}
+		return nil, false
+	}
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicKey decodes an RSA, EC or Ed25519 public JWK into the key type the
+// jwt package verifies with
+func (k JWK) PublicKey() (any, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA modulus: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported EC curve %q", k.Curve)
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+		size := (curve.Params().BitSize + 7) / 8
+		if errX != nil || errY != nil || len(x) != size || len(y) != size {
+			return nil, errors.New("invalid EC point")
+		}
+		point := append(append([]byte{4}, x...), y...)
+		return ecdsa.ParseUncompressedPublicKey(curve, point)
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || k.Curve != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.KeyType)
+}
+
+// JWKS returns the public halves of every asymmetric key in the set
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range ks.order {
+		jwk, ok := publicJWK(key.Public)
+		if !ok {
+			continue
+		}
+		jwk.KeyID = key.ID
+		jwk.Use = "sig"
+		jwk.Algorithm = key.Method.Alg()
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+func parsePEMBlock(block *pem.Block) (*SigningKey, error) {
+	var (
+		parsed any
+		err    error
+	)
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", block.Type, err)
+	}
+
+	key := &SigningKey{}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+
+	key.ID, err = thumbprint(key.Public)
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func publicJWK(pub any) (JWK, bool) {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			KeyType: "RSA",
+			N:       base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			E:       base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		}, true
+	case ed25519.PublicKey:
+		return JWK{
+			KeyType: "OKP",
+			Curve:   "Ed25519",
+			X:       base64.RawURLEncoding.EncodeToString(k),
+		}, true
+	}
+	return JWK{}, false
+}
+
+// thumbprint computes the RFC 7638 JWK thumbprint used as the key ID
+func thumbprint(pub any) (string, error) {
+	jwk, ok := publicJWK(pub)
+	if !ok {
+		return "", fmt.Errorf("unsupported public key type %T", pub)
+	}
+
+	// Required members only, in lexicographic order
+	var members any
+	switch jwk.KeyType {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.KeyType, jwk.N}
+	case "OKP":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Curve, jwk.KeyType, jwk.X}
+	}
+
+	b, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}